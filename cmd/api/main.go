@@ -2,72 +2,427 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"io"
+	"learning/internal/avatar"
 	"learning/internal/config"
+	"learning/internal/contentfilter"
 	"learning/internal/database"
 	"learning/internal/handlers"
+	"learning/internal/hashtag"
+	"learning/internal/logging"
+	"learning/internal/media"
+	"learning/internal/mention"
+	"learning/internal/messaging"
+	"learning/internal/metrics"
 	"learning/internal/middleware"
+	"learning/internal/migrate"
+	"learning/internal/notification"
+	"learning/internal/post"
+	"learning/internal/ratelimit"
+	"learning/internal/realtime"
+	"learning/internal/relationship"
+	"learning/internal/reload"
+	"learning/internal/report"
+	"learning/internal/session"
+	"learning/internal/signup"
+	"learning/internal/stream"
+	"learning/internal/tracing"
 	"learning/internal/user"
-	"log"
+	"learning/migrations"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	log.Println("Starting application...")
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			configCommand(args[1:])
+			return
+		case "migrate":
+			migrateCommand(args[1:])
+			return
+		case "admin":
+			adminCommand(args[1:])
+			return
+		}
+	}
+	serve(args)
+}
+
+// configCommand runs "config print [--redacted] [flags]", which writes the effective
+// configuration as YAML
+func configCommand(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fatal("invalid command", fmt.Errorf("usage: config print [--redacted] [flags]"))
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	redacted := flags.Bool("redacted", false, "mask secrets")
+	cfg, err := config.Load(flags, args[1:])
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+	if err := cfg.Print(os.Stdout, *redacted); err != nil {
+		fatal("failed to print configuration", err)
+	}
+}
+
+// migrateCommand runs "migrate [flags] up|down N|goto V|force V|status" against the
+// configured database
+func migrateCommand(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+
+	command, operand := flags.Arg(0), flags.Arg(1)
+	needsVersion := command == "down" || command == "goto" || command == "force"
+	number, err := strconv.ParseUint(operand, 10, 32)
+	if command == "" || flags.NArg() > 2 || (needsVersion && err != nil) || (!needsVersion && operand != "") {
+		fatal("invalid command", fmt.Errorf("usage: migrate [flags] up|down N|goto VERSION|force VERSION|status"))
+	}
+
+	// Migrations only ever run against the primary
+	cfg.DataBase.Replicas = nil
+	db, err := database.New(cfg)
+	if err != nil {
+		fatal("failed to connect to database", err)
+	}
+	defer db.Close()
+
+	runner, err := migrate.NewRunner(db, migrations.FS)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		err = runner.Up(ctx)
+	case "down":
+		err = runner.Down(ctx, int(number))
+	case "goto":
+		err = runner.Goto(ctx, uint(number))
+	case "force":
+		err = runner.Force(ctx, uint(number))
+	case "status":
+		err = printMigrationStatus(ctx, runner)
+	default:
+		err = fmt.Errorf("unknown migrate command %q", command)
+	}
+	if err != nil {
+		db.Close()
+		fatal("migration failed", err)
+	}
+}
+
+// adminCommand runs "admin [flags] grant|revoke USERNAME", which changes a user's admin role
+func adminCommand(args []string) {
+	flags := flag.NewFlagSet("admin", flag.ExitOnError)
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+
+	command, username := flags.Arg(0), flags.Arg(1)
+	if (command != "grant" && command != "revoke") || username == "" || flags.NArg() > 2 {
+		fatal("invalid command", fmt.Errorf("usage: admin [flags] grant|revoke USERNAME"))
+	}
+
+	cfg.DataBase.Replicas = nil
+	db, err := database.New(cfg)
+	if err != nil {
+		fatal("failed to connect to database", err)
+	}
+	defer db.Close()
+
+	if err := user.NewRepository(db).SetAdmin(context.Background(), username, command == "grant"); err != nil {
+		db.Close()
+		fatal("failed to change admin role", err)
+	}
+	slog.Info("admin role changed", "username", username, "admin", command == "grant")
+}
+
+// printMigrationStatus writes the schema version and every migration's state to stdout
+func printMigrationStatus(ctx context.Context, runner *migrate.Runner) error {
+	status, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if status.Dirty {
+		fmt.Printf("\nschema version %d is dirty: check the schema, then run migrate force VERSION\n", status.Version)
+	} else {
+		fmt.Printf("\nschema version %d\n", status.Version)
+	}
+	return nil
+}
+
+// serve runs the API until it receives SIGINT or SIGTERM
+func serve(args []string) {
+	slog.Info("starting application")
 
 	// Load configuration
-	cfg, err := config.Load()
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+	if flags.NArg() > 0 {
+		fatal("invalid command", fmt.Errorf("unknown command %q", flags.Arg(0)))
+	}
+
+	// Structured logging; the standard log package is routed through it as well.
+	// The level can be changed by a reload.
+	var logLevel slog.LevelVar
+	if err := logLevel.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		fatal("failed to initialize logging", err)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Format, &logLevel)
+	if err != nil {
+		fatal("failed to initialize logging", err)
+	}
+	slog.SetDefault(logger)
+	slog.Info("configuration loaded")
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal("failed to initialize tracing", err)
 	}
-	log.Println("Configuration loaded successfully")
 
 	// Initialize database
 	db, err := database.New(cfg)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("failed to connect to database", err)
 	}
 	defer db.Close()
-	log.Println("Database connected successfully")
+
+	if cfg.DataBase.AutoMigrate {
+		runner, err := migrate.NewRunner(db, migrations.FS)
+		if err != nil {
+			fatal("failed to load migrations", err)
+		}
+		if err := runner.Up(context.Background()); err != nil {
+			fatal("failed to apply migrations", err)
+		}
+	}
 
 	// health routes will be registered via convenience function
 
 	// Setup router with middleware
 	router := mux.NewRouter()
 
-	// Apply global middleware
-	router.Use(middleware.LoggingMiddleware)
-	router.Use(middleware.RecoveryMiddleware)
-	router.Use(middleware.CORSMiddleware)
-	router.Use(middleware.SecurityHeadersMiddleware)
+	// Apply global middleware, including to requests that match no route
+	global := []mux.MiddlewareFunc{
+		middleware.RequestIDMiddleware,
+		middleware.TracingMiddleware,
+		middleware.MetricsMiddleware,
+		middleware.LoggingMiddleware,
+		middleware.RecoveryMiddleware,
+		middleware.SecurityHeadersMiddleware,
+		middleware.ReadYourWritesMiddleware,
+	}
+	router.Use(global...)
+	middleware.HandleUnmatched(router, global...)
 
-	// Register routes
+	// Rate limits: in-process by default, stored in the database to hold across instances
+	var rateStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "postgres" {
+		rateStore = ratelimit.NewPostgresStore(db)
+	}
+	limiter := ratelimit.NewLimiter(rateStore)
+
+	// Session tokens identify users on every route; RequireAuth and RequireAdmin guard the rest
 	apiRouter := router.PathPrefix("/api/v1").Subrouter()
+	sessions := session.Register(apiRouter, db, limiter, cfg.PublicURL)
+	router.Use(middleware.Authenticate(sessions))
+
+	apiPolicy := ratelimit.Policy{
+		Name:  "api",
+		Limit: ratelimit.PerMinute(cfg.RateLimit.PerMinute),
+		Key:   ratelimit.Any(ratelimit.ByUser, ratelimit.ByIP),
+	}
+
+	// Register routes
+	apiRouter.Use(limiter.Middleware(apiPolicy))
+
+	filters := contentfilter.Register(apiRouter, db)
+	signups, err := signup.Register(apiRouter, db, cfg.Signup)
+	if err != nil {
+		fatal("failed to initialize signup screening", err)
+	}
+
+	// Realtime fan-out: in-process by default, LISTEN/NOTIFY across instances
+	var broker realtime.Broker = realtime.NewMemoryBroker()
+	var pgBroker *realtime.PostgresBroker
+	if cfg.RealtimeBroker == "postgres" {
+		pgBroker = realtime.NewPostgresBroker(db)
+		broker = pgBroker
+	}
+	wsHub := realtime.NewHub(broker, realtime.NewAuthorizer(messaging.NewRepository(db)))
+	presence := realtime.Register(apiRouter, db, wsHub)
+
+	sseHub := stream.NewHub(stream.DefaultConfig())
+	notifications := notification.Register(apiRouter, db, notification.Publishers{sseHub, wsHub})
+	stream.Register(apiRouter, sseHub)
+
+	// Follows and blocks gate profiles and messaging, so they are registered first
+	relationships := relationship.Register(apiRouter, db, notifications)
+	reservedUsernames := user.Register(apiRouter, db, filters, signups, relationships, limiter, cfg.PublicURL, cfg.ReservedUsernames)
+	hashtags, trending := hashtag.Register(apiRouter, db)
+	mentions := mention.New(db, mention.EmitterNotifier{Emitter: notifications}, relationships)
+
+	messages := messaging.Register(apiRouter, db, relationships, wsHub)
+
+	blobStore, err := media.NewBlobStore(context.Background(), cfg.Media)
+	if err != nil {
+		fatal("failed to initialize media storage", err)
+	}
+	mediaService, err := media.Register(apiRouter, db, blobStore, cfg.Media)
+	if err != nil {
+		fatal("failed to initialize media service", err)
+	}
+	posts := post.Register(apiRouter, db, filters, hashtags, mentions, mediaService, relationships, sseHub)
+	avatarService := avatar.Register(apiRouter, db, blobStore, cfg.Media.MaxImageBytes)
+
+	report.Register(apiRouter, db, report.Targets{
+		report.TargetPost:    report.PostTarget{Posts: posts},
+		report.TargetMessage: report.MessageTarget{Messages: messages},
+	}, notifications)
 
-	user.Register(apiRouter, db)
 	handlers.RegisterHealth(router, db)
 
-	log.Println("Routes registered successfully")
+	// Cross-origin access follows the configured policy; media and images are public
+	// and can be embedded from any origin
+	cors, err := middleware.NewCORS(router, corsPolicy(cfg.CORS))
+	if err != nil {
+		fatal("failed to initialize CORS", err)
+	}
+	publicPolicy := middleware.CORSPolicy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet},
+		MaxAge:         cfg.CORS.MaxAge,
+	}
+	for _, name := range []string{media.RouteMedia, media.RouteContent, avatar.RouteImage} {
+		if err := cors.Override(name, publicPolicy); err != nil {
+			fatal("failed to initialize CORS", err)
+		}
+	}
+
+	// Settings reloaded on SIGHUP; changes to any others are logged and need a restart
+	reloader := reload.NewReloader(cfg, func() (*config.Config, error) {
+		flags := flag.NewFlagSet("api", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		return config.Load(flags, args)
+	})
+	reloader.Add("logging", func(next *config.Config) (func(), error) {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(next.Log.Level)); err != nil {
+			return nil, err
+		}
+		return func() { logLevel.Set(level) }, nil
+	}, "log.level")
+	reloader.Add("rate limits", func(next *config.Config) (func(), error) {
+		limits := map[string]ratelimit.Limit{apiPolicy.Name: ratelimit.PerMinute(next.RateLimit.PerMinute)}
+		return func() { limiter.SetLimits(limits) }, nil
+	}, "rate_limit.per_minute")
+	reloader.Add("cors", func(next *config.Config) (func(), error) {
+		return cors.PreparePolicy(corsPolicy(next.CORS))
+	}, "cors.allowed_origins", "cors.allowed_methods", "cors.allowed_headers", "cors.exposed_headers", "cors.allow_credentials", "cors.max_age")
+	reloader.Add("reserved usernames", func(next *config.Config) (func(), error) {
+		return reservedUsernames.PrepareConfigured(next.ReservedUsernames)
+	}, "users.reserved_usernames")
+	reloader.Add("signup screening", func(next *config.Config) (func(), error) {
+		return signups.PrepareConfig(next.Signup)
+	}, "signup.window", "signup.max_per_ip", "signup.max_per_subnet", "signup.disposable_domains_file", "signup.verify_score", "signup.reject_score")
+
+	// Metrics and the configuration status are served on the admin port only
+	metrics.Registry.MustRegister(metrics.NewPoolCollector("primary", db.Pool))
+	for addr, pool := range db.ReplicaPools() {
+		metrics.Registry.MustRegister(metrics.NewPoolCollector("replica "+addr, pool))
+	}
+	adminRouter := mux.NewRouter()
+	adminRouter.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	reload.Register(adminRouter, reloader)
+
+	slog.Info("routes registered")
+
+	// Start background jobs, stopped when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(logging.WithContext(context.Background(), logger))
+	defer stopJobs()
+	go reloader.Run(jobsCtx)
+	go db.Run(jobsCtx)
+	go rateStore.Run(jobsCtx)
+	go sessions.Run(jobsCtx)
+	go filters.Run(jobsCtx)
+	go signups.Run(jobsCtx)
+	go reservedUsernames.Run(jobsCtx)
+	go trending.Run(jobsCtx)
+	go sseHub.Run(jobsCtx)
+	go presence.Run(jobsCtx)
+	go mediaService.Run(jobsCtx)
+	if pgBroker != nil {
+		go pgBroker.Run(jobsCtx)
+	}
 
-	// Setup HTTP server
+	// Setup HTTP server. Streaming routes clear the WriteTimeout per connection.
 	server := &http.Server{
 		Addr:         ":" + cfg.ServerPort,
-		Handler:      router,
+		Handler:      cors.Handler(router),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Start server in goroutine
+	// Close open streams as soon as shutdown begins so Shutdown doesn't wait on them;
+	// hijacked WebSocket connections are not tracked by Shutdown at all
+	server.RegisterOnShutdown(sseHub.Close)
+	server.RegisterOnShutdown(wsHub.Close)
+
+	adminServer := &http.Server{
+		Addr:         ":" + cfg.AdminPort,
+		Handler:      adminRouter,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+
+	// Start servers in goroutines
 	go func() {
-		log.Printf("Server starting on port: %s", cfg.ServerPort)
+		slog.Info("server starting", "port", cfg.ServerPort)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Server failed to start:", err)
+			fatal("server failed to start", err)
+		}
+	}()
+	go func() {
+		slog.Info("admin server starting", "port", cfg.AdminPort)
+		if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal("admin server failed to start", err)
 		}
 	}()
 
@@ -76,13 +431,39 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Server shutting down...")
+	slog.Info("server shutting down")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("server forced to shutdown", err)
 	}
-	log.Println("Server stopped gracefully")
+	if err := adminServer.Shutdown(ctx); err != nil {
+		slog.Error("admin server forced to shutdown", "error", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	avatarService.Close()
+	slog.Info("server stopped gracefully")
+}
+
+// corsPolicy builds the default CORS policy from configuration
+func corsPolicy(cfg config.CORSConfig) middleware.CORSPolicy {
+	return middleware.CORSPolicy{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
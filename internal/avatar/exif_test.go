@@ -0,0 +1,142 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment returns an APP1 segment whose TIFF header, in the given byte order, holds
+// the orientation tag followed by a camera make that must not survive re-encoding
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8)) // first IFD
+	binary.Write(&tiff, order, uint16(2)) // entries
+
+	// Make (0x010F), ASCII, stored past the IFD
+	binary.Write(&tiff, order, uint16(0x010F))
+	binary.Write(&tiff, order, uint16(2))
+	binary.Write(&tiff, order, uint32(10))
+	binary.Write(&tiff, order, uint32(8+2+2*12+4))
+	// Orientation (0x0112), SHORT, stored inline
+	binary.Write(&tiff, order, uint16(0x0112))
+	binary.Write(&tiff, order, uint16(3))
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, orientation)
+	tiff.Write([]byte{0, 0})
+	binary.Write(&tiff, order, uint32(0)) // no next IFD
+	tiff.WriteString("SecretCam\x00")
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withSegment inserts segment right after a JPEG's start of image marker
+func withSegment(jpg, segment []byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+// testJPEG encodes a w x h image whose left half is red and right half blue
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	jpg := testJPEG(t, 8, 8)
+	truncated := exifSegment(binary.BigEndian, 6)
+	truncated = truncated[:len(truncated)-20]
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "no exif", data: jpg, want: 1},
+		{name: "little endian", data: withSegment(jpg, exifSegment(binary.LittleEndian, 6)), want: 6},
+		{name: "big endian", data: withSegment(jpg, exifSegment(binary.BigEndian, 8)), want: 8},
+		{name: "out of range", data: withSegment(jpg, exifSegment(binary.BigEndian, 9)), want: 1},
+		{name: "segment past the end", data: append(jpg[:2:2], truncated...), want: 1},
+		{name: "not a jpeg", data: []byte("GIF89a"), want: 1},
+		{name: "empty", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// The source is the 2x2 grid 1 2 / 3 4; want lists the result row by row
+	src := image.NewGray(image.Rect(0, 0, 2, 2))
+	copy(src.Pix, []uint8{1, 2, 3, 4})
+
+	tests := []struct {
+		orientation int
+		want        []uint8
+	}{
+		{orientation: 1, want: []uint8{1, 2, 3, 4}},
+		{orientation: 2, want: []uint8{2, 1, 4, 3}},
+		{orientation: 3, want: []uint8{4, 3, 2, 1}},
+		{orientation: 4, want: []uint8{3, 4, 1, 2}},
+		{orientation: 5, want: []uint8{1, 3, 2, 4}},
+		{orientation: 6, want: []uint8{3, 1, 4, 2}},
+		{orientation: 7, want: []uint8{4, 2, 3, 1}},
+		{orientation: 8, want: []uint8{2, 4, 1, 3}},
+	}
+
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+		got := make([]uint8, 0, 4)
+		for y := range 2 {
+			for x := range 2 {
+				got = append(got, color.GrayModel.Convert(dst.At(x, y)).(color.Gray).Y)
+			}
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("orient(%d) = %v, want %v", tt.orientation, got, tt.want)
+		}
+	}
+
+	wide := image.NewGray(image.Rect(0, 0, 3, 2))
+	for o := 1; o <= 8; o++ {
+		want := image.Pt(3, 2)
+		if o >= 5 {
+			want = image.Pt(2, 3)
+		}
+		if got := orient(wide, o).Bounds().Size(); got != want {
+			t.Errorf("orient(%d) of 3x2 is %v, want %v", o, got, want)
+		}
+	}
+}
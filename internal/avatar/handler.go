@@ -0,0 +1,140 @@
+package avatar
+
+import (
+	"errors"
+	"io"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteImage names the public image route, so policies such as CORS can be applied to it
+const RouteImage = "avatar.image"
+
+// Handler handles profile image HTTP requests
+type Handler struct {
+	service  ServiceInterface
+	maxBytes int64
+}
+
+// NewHandler creates a new profile image handler limiting request bodies to maxBytes
+func NewHandler(service ServiceInterface, maxBytes int64) *Handler {
+	return &Handler{service: service, maxBytes: maxBytes}
+}
+
+// RegisterRoutes registers profile image routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/images/{kind:avatar|banner}/{userId:[0-9]+}/{digest:[0-9a-f]{32}}/{size}.jpg", h.Serve).Methods(http.MethodGet).Name(RouteImage)
+
+	mr := r.PathPrefix("/users/me").Subrouter()
+	mr.Use(middleware.RequireAuth)
+
+	mr.HandleFunc("/{kind:avatar|banner}", h.Upload).Methods(http.MethodPut)
+	mr.HandleFunc("/{kind:avatar|banner}", h.Delete).Methods(http.MethodDelete)
+}
+
+// Upload handles replacing the caller's avatar or banner with the multipart "file" field
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	// Allow some headroom for multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes+64<<10)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "expected multipart/form-data body")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			utils.WriteError(w, http.StatusBadRequest, "missing file field")
+			return
+		}
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		image, err := h.service.SetImage(r.Context(), userID, mux.Vars(r)["kind"], part)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+
+		utils.WriteSuccess(w, http.StatusOK, image)
+		return
+	}
+}
+
+// Delete handles removing the caller's avatar or banner
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	if err := h.service.ClearImage(r.Context(), userID, mux.Vars(r)["kind"]); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "image removed")
+}
+
+// Serve streams a stored variant. Image URLs contain a digest of the source image,
+// so responses never change and are cached for a year.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["userId"])
+	if err != nil || userID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	etag := `"` + vars["digest"] + "-" + vars["size"] + `"`
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	content, err := h.service.OpenImage(r.Context(), vars["kind"], userID, vars["digest"], vars["size"])
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		h.handleError(w, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to stream image", "error", err)
+	}
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, "image too large")
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
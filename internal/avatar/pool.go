@@ -0,0 +1,90 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errPoolBusy is returned when the processing queue is full
+var errPoolBusy = errors.New("image processing queue is full")
+
+// errPoolClosed is returned when work is submitted after the pool has stopped
+var errPoolClosed = errors.New("image processing pool is closed")
+
+// task is a unit of work queued on the pool
+type task struct {
+	ctx  context.Context
+	fn   func() error
+	done chan error
+}
+
+// Pool runs CPU-heavy image processing on a fixed number of workers so large
+// uploads cannot occupy every request goroutine's CPU time at once
+type Pool struct {
+	tasks chan task
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewPool starts a pool with the given number of workers and queue capacity
+func NewPool(workers, queue int) *Pool {
+	p := &Pool{tasks: make(chan task, queue)}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Do queues fn and waits for it to finish. It fails fast with errPoolBusy when the
+// queue is full, and returns early if ctx ends (the work itself is then skipped if
+// it has not started yet).
+func (p *Pool) Do(ctx context.Context, fn func() error) error {
+	t := task{ctx: ctx, fn: fn, done: make(chan error, 1)}
+
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return errPoolClosed
+	}
+	select {
+	case p.tasks <- t:
+		p.mu.RUnlock()
+	default:
+		p.mu.RUnlock()
+		return errPoolBusy
+	}
+
+	select {
+	case err := <-t.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting work and waits for queued tasks to finish
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// work executes queued tasks until the pool is closed
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for t := range p.tasks {
+		if err := t.ctx.Err(); err != nil {
+			t.done <- err
+			continue
+		}
+		t.done <- t.fn()
+	}
+}
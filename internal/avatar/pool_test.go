@@ -0,0 +1,141 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	const workers = 2
+	p := NewPool(workers, 10)
+	defer p.Close()
+
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Do(context.Background(), func() error {
+				n := running.Add(1)
+				for {
+					m := peak.Load()
+					if n <= m || peak.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				<-release
+				running.Add(-1)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}()
+	}
+
+	for running.Load() < workers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // give a third task the chance to start if it could
+	close(release)
+	wg.Wait()
+
+	if got := peak.Load(); got != workers {
+		t.Errorf("ran %d tasks at once, want %d", got, workers)
+	}
+}
+
+// blockedPool returns a single-worker pool whose worker is busy until the returned
+// function is first called
+func blockedPool(t *testing.T, queue int) (*Pool, func()) {
+	t.Helper()
+	p := NewPool(1, queue)
+	started, release := make(chan struct{}), make(chan struct{})
+	go p.Do(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	var once sync.Once
+	return p, func() { once.Do(func() { close(release) }) }
+}
+
+func TestPoolFailsFastWhenBusy(t *testing.T) {
+	p, release := blockedPool(t, 1)
+	defer p.Close()
+	defer release()
+
+	queued := make(chan error, 1)
+	go func() { queued <- p.Do(context.Background(), func() error { return nil }) }()
+	for len(p.tasks) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := p.Do(context.Background(), func() error { return nil }); !errors.Is(err, errPoolBusy) {
+		t.Errorf("Do() on a full queue error = %v, want %v", err, errPoolBusy)
+	}
+	release()
+	if err := <-queued; err != nil {
+		t.Errorf("queued Do() error = %v", err)
+	}
+}
+
+func TestPoolCancellation(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancelFirst bool
+	}{
+		{name: "cancelled while queued"},
+		{name: "cancelled before queueing", cancelFirst: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, release := blockedPool(t, 1)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelFirst {
+				cancel()
+			}
+			var ran atomic.Bool
+			done := make(chan error, 1)
+			go func() {
+				done <- p.Do(ctx, func() error {
+					ran.Store(true)
+					return nil
+				})
+			}()
+			cancel()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("Do() error = %v, want %v", err, context.Canceled)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Do() did not return after its context was cancelled")
+			}
+
+			release()
+			p.Close()
+			if ran.Load() {
+				t.Error("cancelled task ran")
+			}
+		})
+	}
+}
+
+func TestPoolClosed(t *testing.T) {
+	p := NewPool(1, 1)
+	p.Close()
+	if err := p.Do(context.Background(), func() error { return nil }); !errors.Is(err, errPoolClosed) {
+		t.Errorf("Do() after Close error = %v, want %v", err, errPoolClosed)
+	}
+}
@@ -0,0 +1,129 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Register decoders for the accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"golang.org/x/image/draw"
+)
+
+// maxPixels guards against decompression bombs: images are rejected before decoding
+// when their header declares more pixels than this
+const maxPixels = 40_000_000
+
+// jpegQuality is the encoding quality of every generated variant
+const jpegQuality = 85
+
+// errUnsupportedImage is returned for data that is not a decodable image
+var errUnsupportedImage = errors.New("unsupported image")
+
+// Variant is one generated size of a profile image
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Encoded is a resized variant encoded as JPEG
+type Encoded struct {
+	Variant Variant
+	Data    []byte
+}
+
+// process decodes an uploaded image, applies its EXIF orientation, center-crops it to
+// the variants' aspect ratio and encodes every variant as a metadata-free JPEG
+func process(data []byte, variants []Variant) ([]Encoded, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnsupportedImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds the pixel limit", errUnsupportedImage, cfg.Width, cfg.Height)
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnsupportedImage, err)
+	}
+	if format == "jpeg" {
+		src = orient(src, jpegOrientation(data))
+	}
+
+	encoded := make([]Encoded, 0, len(variants))
+	for _, v := range variants {
+		dst := image.NewRGBA(image.Rect(0, 0, v.Width, v.Height))
+		// JPEG has no alpha channel, so transparent areas are flattened onto white
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, cropRect(src.Bounds(), v.Width, v.Height), draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s variant: %w", v.Name, err)
+		}
+		encoded = append(encoded, Encoded{Variant: v, Data: buf.Bytes()})
+	}
+	return encoded, nil
+}
+
+// cropRect returns the largest centered rectangle of bounds with the aspect ratio w:h
+func cropRect(bounds image.Rectangle, w, h int) image.Rectangle {
+	bw, bh := bounds.Dx(), bounds.Dy()
+	cw, ch := bw, bw*h/w
+	if ch > bh {
+		cw, ch = bh*w/h, bh
+	}
+
+	x := bounds.Min.X + (bw-cw)/2
+	y := bounds.Min.Y + (bh-ch)/2
+	return image.Rect(x, y, x+cw, y+ch)
+}
+
+// orient returns src transformed so that EXIF orientation o displays upright
+func orient(src image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// Orientations 5-8 swap width and height
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
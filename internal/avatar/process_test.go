@@ -0,0 +1,134 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestCropRect(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds image.Rectangle
+		w, h   int
+		want   image.Rectangle
+	}{
+		{name: "landscape to square", bounds: image.Rect(0, 0, 400, 200), w: 1, h: 1, want: image.Rect(100, 0, 300, 200)},
+		{name: "portrait to square", bounds: image.Rect(0, 0, 200, 400), w: 1, h: 1, want: image.Rect(0, 100, 200, 300)},
+		{name: "same aspect", bounds: image.Rect(0, 0, 300, 150), w: 2, h: 1, want: image.Rect(0, 0, 300, 150)},
+		{name: "square to wide", bounds: image.Rect(0, 0, 100, 100), w: 2, h: 1, want: image.Rect(0, 25, 100, 75)},
+		{name: "offset bounds", bounds: image.Rect(10, 10, 30, 20), w: 1, h: 1, want: image.Rect(15, 10, 25, 20)},
+		{name: "tiny square", bounds: image.Rect(0, 0, 1, 1), w: 1, h: 1, want: image.Rect(0, 0, 1, 1)},
+		{name: "tiny landscape", bounds: image.Rect(0, 0, 3, 1), w: 1, h: 1, want: image.Rect(1, 0, 2, 1)},
+		{name: "tiny portrait", bounds: image.Rect(0, 0, 1, 3), w: 1, h: 1, want: image.Rect(0, 1, 1, 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cropRect(tt.bounds, tt.w, tt.h); got != tt.want {
+				t.Errorf("cropRect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// oversizePNG returns a PNG whose header declares w x h pixels
+func oversizePNG(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	data := buf.Bytes()
+	// IHDR follows the 8 byte signature: length, type, width, height, ..., CRC
+	binary.BigEndian.PutUint32(data[16:20], w)
+	binary.BigEndian.PutUint32(data[20:24], h)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestProcessRejects(t *testing.T) {
+	jpg := testJPEG(t, 16, 16)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not an image", data: []byte("definitely not an image")},
+		{name: "empty", data: nil},
+		{name: "truncated", data: jpg[:len(jpg)/2]},
+		{name: "over the pixel limit", data: oversizePNG(t, 10_000, 10_000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := process(tt.data, []Variant{{Name: "small", Width: 8, Height: 8}})
+			if !errors.Is(err, errUnsupportedImage) {
+				t.Errorf("process() error = %v, want %v", err, errUnsupportedImage)
+			}
+		})
+	}
+}
+
+// isRed and isBlue tell the halves of testJPEG apart after lossy re-encoding
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xC000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000
+}
+
+func TestProcess(t *testing.T) {
+	// The 80x40 source has a red left and blue right half, so read upright the portrait
+	// variant shows red over blue for orientation 6 and blue over red for 8
+	tests := []struct {
+		name        string
+		orientation uint16
+		top, bottom func(color.Color) bool
+	}{
+		{name: "rotated clockwise", orientation: 6, top: isRed, bottom: isBlue},
+		{name: "rotated counter-clockwise", orientation: 8, top: isBlue, bottom: isRed},
+	}
+
+	variants := []Variant{{Name: "portrait", Width: 20, Height: 40}, {Name: "thumb", Width: 8, Height: 8}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := withSegment(testJPEG(t, 80, 40), exifSegment(binary.BigEndian, tt.orientation))
+
+			encoded, err := process(data, variants)
+			if err != nil {
+				t.Fatalf("process() error = %v", err)
+			}
+			if len(encoded) != len(variants) {
+				t.Fatalf("process() returned %d variants, want %d", len(encoded), len(variants))
+			}
+
+			for i, e := range encoded {
+				if bytes.Contains(e.Data, []byte("Exif")) || bytes.Contains(e.Data, []byte("SecretCam")) {
+					t.Errorf("%s variant kept the EXIF metadata", e.Variant.Name)
+				}
+				img, err := jpeg.Decode(bytes.NewReader(e.Data))
+				if err != nil {
+					t.Fatalf("%s variant does not decode: %v", e.Variant.Name, err)
+				}
+				if size := img.Bounds().Size(); size != image.Pt(variants[i].Width, variants[i].Height) {
+					t.Errorf("%s variant is %v, want %dx%d", e.Variant.Name, size, variants[i].Width, variants[i].Height)
+				}
+			}
+
+			portrait, _ := jpeg.Decode(bytes.NewReader(encoded[0].Data))
+			if !tt.top(portrait.At(10, 5)) || !tt.bottom(portrait.At(10, 35)) {
+				t.Errorf("portrait variant top %v, bottom %v; orientation not applied", portrait.At(10, 5), portrait.At(10, 35))
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// errUserNotFound is returned when the user does not exist or is inactive
+var errUserNotFound = errors.New("user not found")
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for profile images
+type RepositoryInterface interface {
+	SetImageURL(ctx context.Context, userID int, kind string, url *string) (*string, error)
+}
+
+// NewRepository creates a new profile image repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// columns maps an image kind to its users column; only these names reach SQL
+var columns = map[string]string{
+	KindAvatar: "avatar_url",
+	KindBanner: "banner_url",
+}
+
+// SetImageURL stores the user's image URL for kind and returns the previous one
+func (r *Repository) SetImageURL(ctx context.Context, userID int, kind string, url *string) (*string, error) {
+	column, ok := columns[kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid image kind %q", kind)
+	}
+
+	query := fmt.Sprintf(`
+        UPDATE users u SET %[1]s = $2, updated_at = $3
+        FROM (SELECT id, %[1]s FROM users WHERE id = $1 AND active = true FOR UPDATE) old
+        WHERE u.id = old.id
+        RETURNING old.%[1]s
+    `, column)
+
+	var previous *string
+	err := r.db.Querier(ctx).QueryRow(ctx, query, userID, url, time.Now()).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update %s: %w", column, err)
+	}
+	return previous, nil
+}
@@ -0,0 +1,25 @@
+package avatar
+
+import (
+	"learning/internal/database"
+	"learning/internal/media"
+	"runtime"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes.
+// Processing runs on one worker per CPU; the returned service must be closed on shutdown.
+func Register(r *mux.Router, db *database.DataBase, store media.BlobStore, maxBytes int64) *Service {
+	workers := runtime.NumCPU()
+	repo := NewRepository(db)
+	svc := NewService(repo, store, NewPool(workers, 2*workers), maxBytes)
+	h := NewHandler(svc, maxBytes)
+	h.RegisterRoutes(r)
+	return svc
+}
@@ -0,0 +1,205 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/media"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Profile image kinds
+const (
+	KindAvatar = "avatar"
+	KindBanner = "banner"
+)
+
+// urlPrefix is prepended to storage keys to form public URLs
+const urlPrefix = "/api/v1/"
+
+// variants lists the sizes generated for each kind; all sizes of a kind share one aspect ratio
+var variants = map[string][]Variant{
+	KindAvatar: {
+		{Name: "48", Width: 48, Height: 48},
+		{Name: "96", Width: 96, Height: 96},
+		{Name: "200", Width: 200, Height: 200},
+		{Name: "400", Width: 400, Height: 400},
+	},
+	KindBanner: {
+		{Name: "600x200", Width: 600, Height: 200},
+		{Name: "1500x500", Width: 1500, Height: 500},
+	},
+}
+
+// defaultVariant is the size whose URL is stored on the user
+var defaultVariant = map[string]string{
+	KindAvatar: "200",
+	KindBanner: "1500x500",
+}
+
+// ImageResponse represents a processed profile image returned in API responses
+type ImageResponse struct {
+	URL      string            `json:"url"`
+	Variants map[string]string `json:"variants"`
+}
+
+// ServiceInterface defines business operations for profile images
+type ServiceInterface interface {
+	SetImage(ctx context.Context, userID int, kind string, r io.Reader) (*ImageResponse, error)
+	ClearImage(ctx context.Context, userID int, kind string) error
+	OpenImage(ctx context.Context, kind string, userID int, digest, size string) (io.ReadCloser, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	store      media.BlobStore
+	pool       *Pool
+	maxBytes   int64
+}
+
+// NewService creates a new profile image service accepting source images up to maxBytes
+func NewService(repository RepositoryInterface, store media.BlobStore, pool *Pool, maxBytes int64) *Service {
+	return &Service{
+		repository: repository,
+		store:      store,
+		pool:       pool,
+		maxBytes:   maxBytes,
+	}
+}
+
+// SetImage processes an uploaded image into every variant of kind, stores them and
+// points the user's profile at the new image
+func (s *Service) SetImage(ctx context.Context, userID int, kind string, r io.Reader) (*ImageResponse, error) {
+	sizes, ok := variants[kind]
+	if !ok {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("invalid image kind %q", kind), http.StatusBadRequest, "invalid image kind")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("image exceeds %d bytes", s.maxBytes), http.StatusRequestEntityTooLarge, "image too large")
+	}
+
+	var encoded []Encoded
+	err = s.pool.Do(ctx, func() error {
+		var err error
+		encoded, err = process(data, sizes)
+		return err
+	})
+	switch {
+	case errors.Is(err, errUnsupportedImage):
+		return nil, apperrors.WrapWithMessage(err, http.StatusUnsupportedMediaType, "unsupported or invalid image")
+	case errors.Is(err, errPoolBusy), errors.Is(err, errPoolClosed):
+		return nil, apperrors.WrapWithMessage(err, http.StatusServiceUnavailable, "image processing is busy, try again shortly")
+	case err != nil:
+		return nil, fmt.Errorf("error while processing image %w", err)
+	}
+
+	// Keys include a digest of the source so URLs change with the image and can be cached forever
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:16])
+
+	resp := &ImageResponse{Variants: make(map[string]string, len(encoded))}
+	for _, e := range encoded {
+		key := imageKey(kind, userID, digest, e.Variant.Name)
+		if err := s.store.Put(ctx, key, bytes.NewReader(e.Data), int64(len(e.Data)), "image/jpeg"); err != nil {
+			return nil, fmt.Errorf("error while storing image %w", err)
+		}
+		resp.Variants[e.Variant.Name] = urlPrefix + key
+	}
+	resp.URL = resp.Variants[defaultVariant[kind]]
+
+	previous, err := s.repository.SetImageURL(ctx, userID, kind, &resp.URL)
+	if err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return nil, apperrors.WrapWithMessage(err, http.StatusNotFound, "user not found")
+		}
+		return nil, fmt.Errorf("error while updating profile image %w", err)
+	}
+
+	if previous != nil && *previous != resp.URL {
+		s.deleteVariants(ctx, kind, *previous)
+	}
+	return resp, nil
+}
+
+// ClearImage removes the user's image of kind
+func (s *Service) ClearImage(ctx context.Context, userID int, kind string) error {
+	if _, ok := variants[kind]; !ok {
+		return apperrors.WrapWithMessage(fmt.Errorf("invalid image kind %q", kind), http.StatusBadRequest, "invalid image kind")
+	}
+
+	previous, err := s.repository.SetImageURL(ctx, userID, kind, nil)
+	if err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return apperrors.WrapWithMessage(err, http.StatusNotFound, "user not found")
+		}
+		return fmt.Errorf("error while clearing profile image %w", err)
+	}
+
+	if previous != nil {
+		s.deleteVariants(ctx, kind, *previous)
+	}
+	return nil
+}
+
+// OpenImage returns a reader for one stored variant
+func (s *Service) OpenImage(ctx context.Context, kind string, userID int, digest, size string) (io.ReadCloser, error) {
+	if !hasVariant(kind, size) {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("unknown variant %s/%s", kind, size), http.StatusNotFound, "image not found")
+	}
+
+	content, err := s.store.Get(ctx, imageKey(kind, userID, digest, size))
+	if err != nil {
+		if errors.Is(err, media.ErrBlobNotFound) {
+			return nil, apperrors.WrapWithMessage(err, http.StatusNotFound, "image not found")
+		}
+		return nil, fmt.Errorf("error while opening image %w", err)
+	}
+	return content, nil
+}
+
+// Close waits for in-flight processing to finish
+func (s *Service) Close() {
+	s.pool.Close()
+}
+
+// deleteVariants removes every stored size of a replaced image, logging failures
+func (s *Service) deleteVariants(ctx context.Context, kind, url string) {
+	dir := path.Dir(strings.TrimPrefix(url, urlPrefix))
+	for _, v := range variants[kind] {
+		key := dir + "/" + v.Name + ".jpg"
+		if err := s.store.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Error("failed to delete replaced image", "key", key, "error", err)
+		}
+	}
+}
+
+// imageKey returns the storage key of a variant
+func imageKey(kind string, userID int, digest, size string) string {
+	return fmt.Sprintf("images/%s/%d/%s/%s.jpg", kind, userID, digest, size)
+}
+
+// hasVariant reports whether size is a generated variant of kind
+func hasVariant(kind, size string) bool {
+	for _, v := range variants[kind] {
+		if v.Name == size {
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,60 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
-
-	"github.com/joho/godotenv"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	ServerPort string
-	DataBase   DataBaseConfig
+	ServerPort        string
+	AdminPort         string // serves /metrics; keep it off the public network
+	PublicURL         string // externally visible base URL, e.g. https://example.com
+	RealtimeBroker    string
+	ReservedUsernames []string // extra patterns added to the built-in reserved usernames
+	DataBase          DataBaseConfig
+	Media             MediaConfig
+	Signup            SignupConfig
+	Log               LogConfig
+	Tracing           TracingConfig
+	RateLimit         RateLimitConfig
+	CORS              CORSConfig
+
+	sources map[string]string // where each setting's value came from, keyed by setting key
+}
+
+// CORSConfig holds the default cross-origin policy for the API
+type CORSConfig struct {
+	AllowedOrigins   []string // exact origins, https://*.example.com wildcards, ^regexps or *; empty allows none
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // how long browsers may cache preflight results
+}
+
+// RateLimitConfig holds the request rate limiting configuration
+type RateLimitConfig struct {
+	Store     string // memory, or postgres to share limits across instances
+	PerMinute int    // default limit per client across the API; 0 disables it
+}
+
+// TracingConfig holds the OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter    string // none, stdout or otlp
+	ServiceName string
+	SampleRatio float64 // fraction of new traces recorded; sampled parents are always followed
+}
+
+// LogConfig holds the structured logging configuration
+type LogConfig struct {
+	Level  string // debug, info, warn or error
+	Format string // json or text
 }
 
 // DataBaseConfig holds the database configuration
@@ -24,88 +67,215 @@ type DataBaseConfig struct {
 	SSLMode  string
 	MaxConn  int32
 	MinConn  int32
+
+	Replicas             []string      // read replica host[:port] addresses; credentials are the primary's
+	ReplicaStrategy      string        // round_robin or latency
+	ReplicaMaxLag        time.Duration // replicas lagging further behind serve no reads
+	ReplicaCheckInterval time.Duration
+
+	AutoMigrate bool // apply pending migrations at startup
 }
 
-// Load loads configuration from environment variables
-func Load() (*Config, error) {
-	// Load environment variables
-	err := godotenv.Load()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load .env file: %w", err)
+// MediaConfig holds the media upload and blob storage configuration
+type MediaConfig struct {
+	Store         string // "local" or "s3"
+	LocalDir      string
+	TempDir       string
+	MaxImageBytes int64
+	MaxVideoBytes int64
+	UploadTTL     time.Duration
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3UseSSL      bool
+	S3PathStyle   bool
+}
+
+// SignupConfig holds the registration risk scoring configuration
+type SignupConfig struct {
+	Window                time.Duration // period over which registrations are counted
+	MaxPerIP              int
+	MaxPerSubnet          int    // per IPv4 /24 or IPv6 /64
+	DisposableDomainsFile string // one domain per line; empty disables the check
+	VerifyScore           int    // scores at or above this require email verification
+	RejectScore           int    // scores at or above this are rejected
+}
+
+// splitList splits a comma-separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	// Parse connection limits with defaults
-	maxConn, err := strconv.ParseInt(os.Getenv("DB_MAX_CONNS"), 10, 32)
-	if err != nil {
-		maxConn = 5 // Default value
+// Validate checks if configuration is valid, reporting every problem rather than the first
+func (c *Config) Validate() error {
+	var errs []error
+	if c.ServerPort == "" {
+		errs = append(errs, fmt.Errorf("server port is required"))
 	}
+	if c.AdminPort == "" || c.AdminPort == c.ServerPort {
+		errs = append(errs, fmt.Errorf("admin port is required and must differ from the server port"))
+	}
+	if c.RealtimeBroker != "memory" && c.RealtimeBroker != "postgres" {
+		errs = append(errs, fmt.Errorf("realtime broker must be memory or postgres"))
+	}
+	errs = append(errs,
+		c.DataBase.Validate(),
+		c.Media.Validate(),
+		c.Signup.Validate(),
+		c.Log.Validate(),
+		c.Tracing.Validate(),
+		c.RateLimit.Validate(),
+		c.CORS.Validate(),
+	)
+	return errors.Join(errs...)
+}
 
-	minConn, err := strconv.ParseInt(os.Getenv("DB_MIN_CONNS"), 10, 32)
-	if err != nil {
-		minConn = 1 // Default value
+// Validate checks if logging configuration is valid
+func (c *LogConfig) Validate() error {
+	var errs []error
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log level must be debug, info, warn or error"))
+	}
+	if c.Format != "json" && c.Format != "text" {
+		errs = append(errs, fmt.Errorf("log format must be json or text"))
 	}
+	return errors.Join(errs...)
+}
 
-	config := &Config{
-		ServerPort: getEnvWithDefault("PORT", "8080"),
-		DataBase: DataBaseConfig{
-			Host:     getEnvWithDefault("DB_HOST", "localhost"),
-			Port:     getEnvWithDefault("DB_PORT", "5432"),
-			User:     getEnvWithDefault("DB_USER", "postgres"),
-			Password: getEnvWithDefault("DB_PASSWORD", "password"),
-			DBname:   getEnvWithDefault("DB_NAME", "testdb"),
-			SSLMode:  getEnvWithDefault("DB_SSLMODE", "disable"),
-			MaxConn:  int32(maxConn),
-			MinConn:  int32(minConn),
-		},
+// Validate checks if tracing configuration is valid
+func (c *TracingConfig) Validate() error {
+	var errs []error
+	switch c.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing exporter must be none, stdout or otlp"))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing sample ratio must be between 0 and 1"))
 	}
+	return errors.Join(errs...)
+}
 
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+// Validate checks if CORS configuration is valid
+func (c *CORSConfig) Validate() error {
+	var errs []error
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" && c.AllowCredentials {
+			errs = append(errs, fmt.Errorf("CORS credentials cannot be allowed for any origin"))
+		}
+		if strings.HasPrefix(origin, "^") {
+			if _, err := regexp.Compile(origin); err != nil {
+				errs = append(errs, fmt.Errorf("invalid CORS origin pattern %q: %w", origin, err))
+			}
+		}
 	}
+	if len(c.AllowedMethods) == 0 {
+		errs = append(errs, fmt.Errorf("CORS allowed methods are required"))
+	}
+	return errors.Join(errs...)
+}
 
-	return config, nil
+// Validate checks if rate limiting configuration is valid
+func (c *RateLimitConfig) Validate() error {
+	var errs []error
+	if c.Store != "memory" && c.Store != "postgres" {
+		errs = append(errs, fmt.Errorf("rate limit store must be memory or postgres"))
+	}
+	if c.PerMinute < 0 {
+		errs = append(errs, fmt.Errorf("rate limit per minute cannot be negative"))
+	}
+	return errors.Join(errs...)
 }
 
-// getEnvWithDefault returns environment variable value or default if not set
-func getEnvWithDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Validate checks if signup configuration is valid
+func (c *SignupConfig) Validate() error {
+	var errs []error
+	if c.Window <= 0 || c.MaxPerIP <= 0 || c.MaxPerSubnet <= 0 {
+		errs = append(errs, fmt.Errorf("signup window and limits must be positive"))
+	}
+	if c.VerifyScore <= 0 || c.RejectScore < c.VerifyScore {
+		errs = append(errs, fmt.Errorf("signup verify score must be positive and not above the reject score"))
 	}
-	return defaultValue
+	return errors.Join(errs...)
 }
 
-// Validate checks if configuration is valid
-func (c *Config) Validate() error {
-	if c.ServerPort == "" {
-		return fmt.Errorf("server port is required")
+// Validate checks if media configuration is valid
+func (c *MediaConfig) Validate() error {
+	var errs []error
+	if c.MaxImageBytes <= 0 || c.MaxVideoBytes <= 0 {
+		errs = append(errs, fmt.Errorf("media size limits must be positive"))
+	}
+	switch c.Store {
+	case "local":
+		if c.LocalDir == "" {
+			errs = append(errs, fmt.Errorf("media local directory is required"))
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			errs = append(errs, fmt.Errorf("S3 endpoint and bucket are required for the s3 media store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("media store must be local or s3"))
 	}
-	return c.DataBase.Validate()
+	return errors.Join(errs...)
 }
 
 // Validate checks if database configuration is valid
 func (c *DataBaseConfig) Validate() error {
+	var errs []error
 	if c.Host == "" {
-		return fmt.Errorf("database host is required")
+		errs = append(errs, fmt.Errorf("database host is required"))
 	}
 	if c.Port == "" {
-		return fmt.Errorf("database port is required")
+		errs = append(errs, fmt.Errorf("database port is required"))
 	}
 	if c.User == "" {
-		return fmt.Errorf("database user is required")
+		errs = append(errs, fmt.Errorf("database user is required"))
 	}
 	if c.DBname == "" {
-		return fmt.Errorf("database name is required")
+		errs = append(errs, fmt.Errorf("database name is required"))
+	}
+	if c.MaxConn <= 0 || c.MinConn < 0 {
+		errs = append(errs, fmt.Errorf("database connection limits must be positive"))
 	}
 	if c.MaxConn < c.MinConn {
-		return fmt.Errorf("max connections cannot be less than min connections")
+		errs = append(errs, fmt.Errorf("max connections cannot be less than min connections"))
 	}
-	return nil
+	if c.ReplicaStrategy != "round_robin" && c.ReplicaStrategy != "latency" {
+		errs = append(errs, fmt.Errorf("database replica strategy must be round_robin or latency"))
+	}
+	if len(c.Replicas) > 0 && (c.ReplicaMaxLag <= 0 || c.ReplicaCheckInterval <= 0) {
+		errs = append(errs, fmt.Errorf("database replica max lag and check interval must be positive"))
+	}
+	return errors.Join(errs...)
 }
 
 func (c *DataBaseConfig) GetDSN() string {
+	return c.dsn(c.Host, c.Port)
+}
+
+// ReplicaDSN returns the connection string for a replica address, host or host:port,
+// using the primary's port when none is given
+func (c *DataBaseConfig) ReplicaDSN(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, c.Port
+	}
+	return c.dsn(host, port)
+}
+
+func (c *DataBaseConfig) dsn(host, port string) string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.DBname, c.SSLMode,
+		host, port, c.User, c.Password, c.DBname, c.SSLMode,
 	)
 }
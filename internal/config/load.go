@@ -0,0 +1,355 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources recorded for each setting, shown by Print
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceDotenv  = ".env"
+	sourceFlag    = "flag"
+)
+
+// setting maps one configuration key onto Config. The key names it in configuration
+// files and is also its flag name; env is its environment variable, which may instead
+// be given as env+"_FILE" naming a file holding the value.
+type setting struct {
+	key    string
+	env    string
+	def    string
+	usage  string
+	secret bool
+	set    func(c *Config, value string) error
+	get    func(c *Config) any
+	copy   func(dst, src *Config)
+}
+
+// field builds a setting that parses its value into the Config field returned by ptr
+func field[T any](key, env, def, usage string, parse func(string) (T, error), ptr func(*Config) *T) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		def:   def,
+		usage: usage,
+		set: func(c *Config, value string) error {
+			parsed, err := parse(value)
+			if err != nil {
+				return err
+			}
+			*ptr(c) = parsed
+			return nil
+		},
+		get:  func(c *Config) any { return *ptr(c) },
+		copy: func(dst, src *Config) { *ptr(dst) = *ptr(src) },
+	}
+}
+
+// secret marks a setting whose value is masked when printed redacted
+func secret(s setting) setting {
+	s.secret = true
+	return s
+}
+
+func parseString(value string) (string, error) { return value, nil }
+
+func parseList(value string) ([]string, error) { return splitList(value), nil }
+
+func parseInt32(value string) (int32, error) {
+	n, err := strconv.ParseInt(value, 10, 32)
+	return int32(n), err
+}
+
+func parseInt64(value string) (int64, error) { return strconv.ParseInt(value, 10, 64) }
+
+func parseFloat(value string) (float64, error) { return strconv.ParseFloat(value, 64) }
+
+// settings lists every configuration key with its environment variable and default
+var settings = []setting{
+	field("server.port", "PORT", "8080", "HTTP port for the API", parseString, func(c *Config) *string { return &c.ServerPort }),
+	field("server.admin_port", "ADMIN_PORT", "9090", "HTTP port for /metrics and /config; keep it off the public network", parseString, func(c *Config) *string { return &c.AdminPort }),
+	field("server.public_url", "PUBLIC_URL", "", "externally visible base URL (default http://localhost:<port>)", parseString, func(c *Config) *string { return &c.PublicURL }),
+	field("realtime.broker", "REALTIME_BROKER", "memory", "realtime fan-out: memory or postgres", parseString, func(c *Config) *string { return &c.RealtimeBroker }),
+	field("users.reserved_usernames", "RESERVED_USERNAMES", "", "extra reserved username patterns, comma separated", parseList, func(c *Config) *[]string { return &c.ReservedUsernames }),
+
+	field("database.host", "DB_HOST", "localhost", "database host", parseString, func(c *Config) *string { return &c.DataBase.Host }),
+	field("database.port", "DB_PORT", "5432", "database port", parseString, func(c *Config) *string { return &c.DataBase.Port }),
+	field("database.user", "DB_USER", "postgres", "database user", parseString, func(c *Config) *string { return &c.DataBase.User }),
+	secret(field("database.password", "DB_PASSWORD", "password", "database password", parseString, func(c *Config) *string { return &c.DataBase.Password })),
+	field("database.name", "DB_NAME", "testdb", "database name", parseString, func(c *Config) *string { return &c.DataBase.DBname }),
+	field("database.sslmode", "DB_SSLMODE", "disable", "database SSL mode", parseString, func(c *Config) *string { return &c.DataBase.SSLMode }),
+	field("database.max_conns", "DB_MAX_CONNS", "5", "maximum pool connections", parseInt32, func(c *Config) *int32 { return &c.DataBase.MaxConn }),
+	field("database.min_conns", "DB_MIN_CONNS", "1", "minimum pool connections", parseInt32, func(c *Config) *int32 { return &c.DataBase.MinConn }),
+	field("database.replicas", "DB_REPLICAS", "", "read replica host[:port] addresses using the primary's credentials, comma separated", parseList, func(c *Config) *[]string { return &c.DataBase.Replicas }),
+	field("database.replica_strategy", "DB_REPLICA_STRATEGY", "round_robin", "how reads pick a replica: round_robin or latency", parseString, func(c *Config) *string { return &c.DataBase.ReplicaStrategy }),
+	field("database.replica_max_lag", "DB_REPLICA_MAX_LAG", "10s", "replication lag beyond which a replica is skipped", time.ParseDuration, func(c *Config) *time.Duration { return &c.DataBase.ReplicaMaxLag }),
+	field("database.replica_check_interval", "DB_REPLICA_CHECK_INTERVAL", "5s", "how often replica health and lag are checked", time.ParseDuration, func(c *Config) *time.Duration { return &c.DataBase.ReplicaCheckInterval }),
+	field("database.auto_migrate", "DB_AUTO_MIGRATE", "false", "apply pending migrations at startup", strconv.ParseBool, func(c *Config) *bool { return &c.DataBase.AutoMigrate }),
+
+	field("media.store", "MEDIA_STORE", "local", "blob storage: local or s3", parseString, func(c *Config) *string { return &c.Media.Store }),
+	field("media.local_dir", "MEDIA_LOCAL_DIR", "data/media", "directory for the local blob store", parseString, func(c *Config) *string { return &c.Media.LocalDir }),
+	field("media.temp_dir", "MEDIA_TEMP_DIR", os.TempDir(), "directory for uploads in progress", parseString, func(c *Config) *string { return &c.Media.TempDir }),
+	field("media.max_image_bytes", "MEDIA_MAX_IMAGE_BYTES", "10485760", "maximum image upload size", parseInt64, func(c *Config) *int64 { return &c.Media.MaxImageBytes }),
+	field("media.max_video_bytes", "MEDIA_MAX_VIDEO_BYTES", "104857600", "maximum video upload size", parseInt64, func(c *Config) *int64 { return &c.Media.MaxVideoBytes }),
+	field("media.s3.endpoint", "S3_ENDPOINT", "", "S3 endpoint", parseString, func(c *Config) *string { return &c.Media.S3Endpoint }),
+	field("media.s3.region", "S3_REGION", "us-east-1", "S3 region", parseString, func(c *Config) *string { return &c.Media.S3Region }),
+	field("media.s3.bucket", "S3_BUCKET", "", "S3 bucket", parseString, func(c *Config) *string { return &c.Media.S3Bucket }),
+	secret(field("media.s3.access_key", "S3_ACCESS_KEY", "", "S3 access key", parseString, func(c *Config) *string { return &c.Media.S3AccessKey })),
+	secret(field("media.s3.secret_key", "S3_SECRET_KEY", "", "S3 secret key", parseString, func(c *Config) *string { return &c.Media.S3SecretKey })),
+	field("media.s3.use_ssl", "S3_USE_SSL", "true", "connect to S3 over TLS", strconv.ParseBool, func(c *Config) *bool { return &c.Media.S3UseSSL }),
+	field("media.s3.path_style", "S3_PATH_STYLE", "false", "use path-style S3 URLs", strconv.ParseBool, func(c *Config) *bool { return &c.Media.S3PathStyle }),
+
+	field("signup.window", "SIGNUP_WINDOW", "1h", "period over which registrations are counted", time.ParseDuration, func(c *Config) *time.Duration { return &c.Signup.Window }),
+	field("signup.max_per_ip", "SIGNUP_MAX_PER_IP", "3", "registrations allowed per IP per window", strconv.Atoi, func(c *Config) *int { return &c.Signup.MaxPerIP }),
+	field("signup.max_per_subnet", "SIGNUP_MAX_PER_SUBNET", "10", "registrations allowed per IPv4 /24 or IPv6 /64 per window", strconv.Atoi, func(c *Config) *int { return &c.Signup.MaxPerSubnet }),
+	field("signup.disposable_domains_file", "SIGNUP_DISPOSABLE_DOMAINS_FILE", "", "file listing disposable email domains", parseString, func(c *Config) *string { return &c.Signup.DisposableDomainsFile }),
+	field("signup.verify_score", "SIGNUP_VERIFY_SCORE", "50", "risk score requiring email verification", strconv.Atoi, func(c *Config) *int { return &c.Signup.VerifyScore }),
+	field("signup.reject_score", "SIGNUP_REJECT_SCORE", "100", "risk score rejecting a registration", strconv.Atoi, func(c *Config) *int { return &c.Signup.RejectScore }),
+
+	field("log.level", "LOG_LEVEL", "info", "log level: debug, info, warn or error", parseString, func(c *Config) *string { return &c.Log.Level }),
+	field("log.format", "LOG_FORMAT", "json", "log format: json or text", parseString, func(c *Config) *string { return &c.Log.Format }),
+
+	field("tracing.exporter", "TRACING_EXPORTER", "none", "trace exporter: none, stdout or otlp", parseString, func(c *Config) *string { return &c.Tracing.Exporter }),
+	field("tracing.service_name", "TRACING_SERVICE_NAME", "api", "service name reported with traces", parseString, func(c *Config) *string { return &c.Tracing.ServiceName }),
+	field("tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "1", "fraction of new traces recorded", parseFloat, func(c *Config) *float64 { return &c.Tracing.SampleRatio }),
+
+	field("rate_limit.store", "RATE_LIMIT_STORE", "memory", "rate limit store: memory or postgres", parseString, func(c *Config) *string { return &c.RateLimit.Store }),
+	field("rate_limit.per_minute", "RATE_LIMIT_PER_MINUTE", "300", "default requests per minute per client; 0 disables", strconv.Atoi, func(c *Config) *int { return &c.RateLimit.PerMinute }),
+
+	field("cors.allowed_origins", "CORS_ALLOWED_ORIGINS", "", "origins allowed cross-origin access, comma separated", parseList, func(c *Config) *[]string { return &c.CORS.AllowedOrigins }),
+	field("cors.allowed_methods", "CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE", "methods allowed cross-origin", parseList, func(c *Config) *[]string { return &c.CORS.AllowedMethods }),
+	field("cors.allowed_headers", "CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-Request-ID", "request headers allowed cross-origin", parseList, func(c *Config) *[]string { return &c.CORS.AllowedHeaders }),
+	field("cors.exposed_headers", "CORS_EXPOSED_HEADERS", "X-Request-ID,X-Trace-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After", "response headers readable cross-origin", parseList, func(c *Config) *[]string { return &c.CORS.ExposedHeaders }),
+	field("cors.allow_credentials", "CORS_ALLOW_CREDENTIALS", "false", "allow cookies and credentials cross-origin", strconv.ParseBool, func(c *Config) *bool { return &c.CORS.AllowCredentials }),
+	field("cors.max_age", "CORS_MAX_AGE", "1h", "how long browsers may cache preflight results", time.ParseDuration, func(c *Config) *time.Duration { return &c.CORS.MaxAge }),
+}
+
+// Load builds the configuration from, in increasing precedence: built-in defaults, an
+// optional YAML or TOML file named by -config or CONFIG_FILE, a .env file, if present,
+// environment variables and command-line flags. A flag is added to flags for every setting
+// before args are parsed. Every invalid value is reported, not just the first.
+func Load(flags *flag.FlagSet, args []string) (*Config, error) {
+	// The .env file is read rather than loaded into the process environment, so that a
+	// reload sees its current contents instead of the values set by the first load. A
+	// missing file is fine; variables may be injected directly.
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .env file: %w", err)
+	}
+	env := environment{dotenv: dotenv}
+
+	configFile := flags.String("config", env.get("CONFIG_FILE"), "YAML or TOML configuration file")
+	for _, s := range settings {
+		flags.String(s.key, "", s.usage+" (env "+s.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(settings))
+	sources := make(map[string]string, len(settings))
+	for _, s := range settings {
+		values[s.key], sources[s.key] = s.def, sourceDefault
+	}
+
+	var errs []error
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.key] = true
+	}
+
+	if *configFile != "" {
+		fileValues, err := readFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range slices.Sorted(maps.Keys(fileValues)) {
+			if !known[key] {
+				errs = append(errs, fmt.Errorf("%s: unknown key %q", *configFile, key))
+				continue
+			}
+			values[key], sources[key] = fileValues[key], sourceFile
+		}
+	}
+
+	for _, s := range settings {
+		value, source, ok, err := env.lookup(s.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			values[s.key], sources[s.key] = value, source
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if known[f.Name] {
+			values[f.Name], sources[f.Name] = f.Value.String(), sourceFlag
+		}
+	})
+
+	config := &Config{
+		Media:   MediaConfig{UploadTTL: 24 * time.Hour},
+		sources: sources,
+	}
+	for _, s := range settings {
+		if err := s.set(config, values[s.key]); err != nil {
+			if s.secret {
+				errs = append(errs, fmt.Errorf("%s (from %s): invalid value", s.key, sources[s.key]))
+			} else {
+				errs = append(errs, fmt.Errorf("%s (from %s): invalid value %q", s.key, sources[s.key], values[s.key]))
+			}
+			// Validate the default instead so the bad value isn't reported twice
+			_ = s.set(config, s.def)
+		}
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = "http://localhost:" + config.ServerPort
+	}
+
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("configuration validation failed:\n%w", errors.Join(errs...))
+	}
+
+	return config, nil
+}
+
+// environment is the process environment layered over the variables of a .env file
+type environment struct {
+	dotenv map[string]string
+}
+
+// get returns the value of a variable, taken from the .env file when the process
+// environment leaves it empty
+func (e environment) get(name string) string {
+	value, _ := e.source(name)
+	return value
+}
+
+// source returns the value of a variable and where it came from
+func (e environment) source(name string) (value, source string) {
+	if value := os.Getenv(name); value != "" {
+		return value, sourceEnv
+	}
+	if value := e.dotenv[name]; value != "" {
+		return value, sourceDotenv
+	}
+	return "", ""
+}
+
+// lookup returns the value of a variable or, for Docker and Kubernetes secrets, the
+// contents of the file named by name+"_FILE". Empty variables are unset.
+func (e environment) lookup(name string) (value, source string, ok bool, err error) {
+	value, valueSource := e.source(name)
+	path, pathSource := e.source(name + "_FILE")
+	switch {
+	case path != "" && value != "":
+		return "", "", false, fmt.Errorf("%s and %s_FILE cannot both be set", name, name)
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", false, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), pathSource + " " + name + "_FILE", true, nil
+	case value != "":
+		return value, valueSource + " " + name, true, nil
+	}
+	return "", "", false, nil
+}
+
+// readFile reads a YAML or TOML configuration file into dotted keys, e.g. database.host
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return values, nil
+}
+
+// flatten writes nested tables as dotted keys. Lists are joined with commas, matching
+// the environment variable format.
+func flatten(prefix string, raw map[string]any, values map[string]string) {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(key, v, values)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// Changed returns the keys of the settings whose values differ between a and b
+func Changed(a, b *Config) []string {
+	var keys []string
+	for _, s := range settings {
+		if !reflect.DeepEqual(s.get(a), s.get(b)) {
+			keys = append(keys, s.key)
+		}
+	}
+	return keys
+}
+
+// With returns a copy of c with the settings named by keys taken from other
+func (c *Config) With(other *Config, keys []string) *Config {
+	merged := *c
+	merged.sources = maps.Clone(c.sources)
+	for _, s := range settings {
+		for _, key := range keys {
+			if s.key == key {
+				s.copy(&merged, other)
+				merged.sources[key] = other.sources[key]
+			}
+		}
+	}
+	return &merged
+}
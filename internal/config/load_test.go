@@ -0,0 +1,178 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// load runs Load in an empty working directory, so no .env file is picked up
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	return Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+// writeFile writes content to name in a new temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", `
+server:
+  port: "7000"
+  admin_port: "7001"
+database:
+  host: file-host
+  name: file-db
+  max_conns: 20
+log:
+  level: warn
+`)
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("ADMIN_PORT", "8001")
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("DB_NAME", "env-db")
+
+	config, err := load(t, "-database.name", "flag-db")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		key, got, want, source string
+	}{
+		{"server.port", config.ServerPort, "7000", sourceFile},
+		{"server.admin_port", config.AdminPort, "8001", "env ADMIN_PORT"},
+		{"database.host", config.DataBase.Host, "env-host", "env DB_HOST"},
+		{"database.name", config.DataBase.DBname, "flag-db", sourceFlag},
+		{"database.user", config.DataBase.User, "postgres", sourceDefault},
+		{"log.level", config.Log.Level, "warn", sourceFile},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want || config.sources[tt.key] != tt.source {
+			t.Errorf("%s = %q from %q, want %q from %q", tt.key, tt.got, config.sources[tt.key], tt.want, tt.source)
+		}
+	}
+	if config.DataBase.MaxConn != 20 {
+		t.Errorf("database.max_conns = %d, want 20", config.DataBase.MaxConn)
+	}
+	if config.PublicURL != "http://localhost:7000" {
+		t.Errorf("server.public_url = %q, want the default for port 7000", config.PublicURL)
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "password", "s3cret\n"))
+
+	config, err := load(t)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if config.DataBase.Password != "s3cret" {
+		t.Errorf("database.password = %q, want the file contents without the newline", config.DataBase.Password)
+	}
+	if source := config.sources["database.password"]; source != "env DB_PASSWORD_FILE" {
+		t.Errorf("database.password source = %q, want env DB_PASSWORD_FILE", source)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "variable and file both set",
+			env:     map[string]string{"DB_PASSWORD": "a", "DB_PASSWORD_FILE": "/run/secrets/db"},
+			wantErr: "DB_PASSWORD and DB_PASSWORD_FILE cannot both be set",
+		},
+		{
+			name:    "missing secret file",
+			env:     map[string]string{"DB_PASSWORD_FILE": filepath.Join(os.TempDir(), "does-not-exist")},
+			wantErr: "DB_PASSWORD_FILE:",
+		},
+		{
+			name:    "invalid env value",
+			env:     map[string]string{"DB_MAX_CONNS": "many"},
+			wantErr: `database.max_conns (from env DB_MAX_CONNS): invalid value "many"`,
+		},
+		{
+			name:    "invalid flag value",
+			args:    []string{"-signup.window", "soon"},
+			wantErr: `signup.window (from flag): invalid value "soon"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := load(t, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownFileKey(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.toml", "[database]\nhots = \"db\"\n"))
+
+	_, err := load(t)
+	if err == nil || !strings.Contains(err.Error(), `unknown key "database.hots"`) {
+		t.Fatalf("Load() error = %v, want an unknown key error", err)
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	dotenv := filepath.Join(dir, ".env")
+	write := func(content string) {
+		if err := os.WriteFile(dotenv, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() *Config {
+		config, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return config
+	}
+
+	t.Setenv("DB_NAME", "env-db")
+	write("DB_HOST=first-host\nDB_NAME=dotenv-db\nLOG_LEVEL=debug\n")
+	config := load()
+	if config.DataBase.Host != "first-host" || config.sources["database.host"] != ".env DB_HOST" {
+		t.Errorf("database.host = %q from %q, want first-host from .env", config.DataBase.Host, config.sources["database.host"])
+	}
+	if config.DataBase.DBname != "env-db" {
+		t.Errorf("database.name = %q, want the environment to take precedence over .env", config.DataBase.DBname)
+	}
+	if _, set := os.LookupEnv("DB_HOST"); set {
+		t.Error(".env was loaded into the process environment")
+	}
+
+	// A reload sees the file as it is now
+	write("DB_HOST=second-host\n")
+	config = load()
+	if config.DataBase.Host != "second-host" {
+		t.Errorf("database.host after edit = %q, want second-host", config.DataBase.Host)
+	}
+	if config.Log.Level != "info" {
+		t.Errorf("log.level after removal from .env = %q, want the default", config.Log.Level)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secrets in redacted output
+const redactedValue = "REDACTED"
+
+// Value is the effective value of one setting and where it came from
+type Value struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Values lists every setting in declaration order. Durations are formatted as strings
+// and secrets are masked when redacted is set.
+func (c *Config) Values(redacted bool) []Value {
+	values := make([]Value, 0, len(settings))
+	for _, s := range settings {
+		value := s.get(c)
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case []string:
+			if v == nil {
+				value = []string{}
+			}
+		}
+		if redacted && s.secret && value != "" {
+			value = redactedValue
+		}
+		values = append(values, Value{Key: s.key, Value: value, Source: c.sources[s.key]})
+	}
+	return values
+}
+
+// Print writes the effective configuration as YAML that Load accepts as a config file,
+// commenting each value with where it came from. Secrets are masked when redacted is set.
+func (c *Config) Print(w io.Writer, redacted bool) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, v := range c.Values(redacted) {
+		var node yaml.Node
+		if err := node.Encode(v.Value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", v.Key, err)
+		}
+		if node.Kind == yaml.SequenceNode {
+			node.Style = yaml.FlowStyle
+		}
+		node.LineComment = v.Source
+
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: v.Key}, &node)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return encoder.Close()
+}
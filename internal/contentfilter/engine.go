@@ -0,0 +1,192 @@
+package contentfilter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Engine matches text against a fixed set of compiled rules. It is immutable, so a
+// reload swaps in a new Engine rather than changing one in use.
+type Engine struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	re      *regexp.Regexp
+	bounded bool // matches must start and end on word boundaries
+	scopes  map[string]bool
+}
+
+// NewEngine compiles the enabled rules. Rules that fail to compile are skipped and returned as errors.
+func NewEngine(rules []Rule) (*Engine, []error) {
+	var errs []error
+	engine := &Engine{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		compiled, err := compile(rule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", rule.ID, err))
+			continue
+		}
+		engine.rules = append(engine.rules, *compiled)
+	}
+	return engine, errs
+}
+
+// compile builds the matcher for a rule. Words and phrases are folded like the text they
+// are matched against; whitespace inside a phrase matches any run of whitespace.
+func compile(rule Rule) (*compiledRule, error) {
+	compiled := &compiledRule{Rule: rule, scopes: make(map[string]bool, len(rule.Scopes))}
+	for _, scope := range rule.Scopes {
+		compiled.scopes[scope] = true
+	}
+
+	var expr string
+	switch rule.MatchType {
+	case MatchWord, MatchPhrase:
+		folded, _, _ := fold(rule.Pattern)
+		words := strings.Fields(folded)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty pattern")
+		}
+		if rule.MatchType == MatchWord && len(words) > 1 {
+			return nil, fmt.Errorf("word rules must be a single word; use a phrase rule")
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		expr = strings.Join(words, `\s+`)
+		compiled.bounded = true
+	case MatchRegex:
+		expr = rule.Pattern
+	default:
+		return nil, fmt.Errorf("unknown match type %q", rule.MatchType)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	compiled.re = re
+	return compiled, nil
+}
+
+// Check matches text against the rules for scope. The most severe matching action wins;
+// masked terms are replaced in Verdict.Text whatever the final action.
+func (e *Engine) Check(scope, text string) Verdict {
+	verdict := Verdict{Action: ActionAllow, Text: text, RuleIDs: []int{}}
+	if e == nil || text == "" {
+		return verdict
+	}
+
+	folded, starts, ends := fold(text)
+	var masks [][2]int
+	for _, rule := range e.rules {
+		if !rule.scopes[scope] {
+			continue
+		}
+
+		matched := false
+		for _, loc := range rule.re.FindAllStringIndex(folded, -1) {
+			if loc[0] == loc[1] || rule.bounded && !onWordBoundaries(folded, loc[0], loc[1]) {
+				continue
+			}
+			matched = true
+			if rule.Action == ActionMask {
+				masks = append(masks, [2]int{starts[loc[0]], ends[loc[1]-1]})
+			}
+		}
+
+		if matched {
+			verdict.RuleIDs = append(verdict.RuleIDs, rule.ID)
+			if severity[rule.Action] > severity[verdict.Action] {
+				verdict.Action = rule.Action
+			}
+		}
+	}
+
+	if len(masks) > 0 {
+		verdict.Text = mask(text, masks)
+	}
+	return verdict
+}
+
+// fold returns text with compatibility characters unified, diacritics removed and case
+// folded. starts[i] and ends[i] are the byte range of the original rune that produced
+// byte i of the folded text, so matches can be mapped back for masking.
+func fold(text string) (folded string, starts, ends []int) {
+	caser := cases.Fold()
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i, r := range text {
+		var base strings.Builder
+		for _, d := range norm.NFKD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, d) {
+				base.WriteRune(d)
+			}
+		}
+		f := caser.String(base.String())
+
+		end := i + utf8.RuneLen(r)
+		if r == utf8.RuneError {
+			end = i + 1
+		}
+		for j := 0; j < len(f); j++ {
+			starts = append(starts, i)
+			ends = append(ends, end)
+		}
+		b.WriteString(f)
+	}
+	return b.String(), starts, ends
+}
+
+// onWordBoundaries reports whether the match [start, end) is not part of a longer word
+func onWordBoundaries(text string, start, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		if r, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// mask replaces every rune in the given byte ranges with an asterisk
+func mask(text string, ranges [][2]int) string {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	var b strings.Builder
+	b.Grow(len(text))
+	pos := 0
+	for _, rng := range ranges {
+		start, end := max(rng[0], pos), rng[1]
+		if start >= end {
+			continue
+		}
+		b.WriteString(text[pos:start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[start:end])))
+		pos = end
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
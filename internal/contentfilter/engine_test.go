@@ -0,0 +1,104 @@
+package contentfilter
+
+import (
+	"slices"
+	"testing"
+)
+
+func testEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	engine, errs := NewEngine([]Rule{
+		{ID: 1, Pattern: "darn", MatchType: MatchWord, Action: ActionMask, Scopes: []string{ScopePost, ScopeBio}, Enabled: true},
+		{ID: 2, Pattern: "Buy Now", MatchType: MatchPhrase, Action: ActionHold, Scopes: []string{ScopePost}, Enabled: true},
+		{ID: 3, Pattern: `free\s+crypto`, MatchType: MatchRegex, Action: ActionReject, Scopes: []string{ScopePost}, Enabled: true},
+		{ID: 4, Pattern: "heck", MatchType: MatchWord, Action: ActionMask, Scopes: []string{ScopeBio}, Enabled: true},
+		{ID: 5, Pattern: "bad", MatchType: MatchWord, Action: ActionReject, Scopes: []string{ScopePost}, Enabled: false},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("NewEngine() errors = %v", errs)
+	}
+	return engine
+}
+
+func TestEngineCheck(t *testing.T) {
+	engine := testEngine(t)
+
+	tests := []struct {
+		name       string
+		text       string
+		wantAction string
+		wantText   string
+		wantRules  []int
+	}{
+		{name: "no match", text: "hello there", wantAction: ActionAllow, wantText: "hello there", wantRules: []int{}},
+		{name: "empty", text: "", wantAction: ActionAllow, wantText: "", wantRules: []int{}},
+		{name: "masked word", text: "Darn it", wantAction: ActionMask, wantText: "**** it", wantRules: []int{1}},
+		{name: "every occurrence masked", text: "darn, darn!", wantAction: ActionMask, wantText: "****, ****!", wantRules: []int{1}},
+		{name: "diacritics folded", text: "d\u00e1rn", wantAction: ActionMask, wantText: "****", wantRules: []int{1}},
+		{name: "combining marks folded", text: "da\u0301rn it", wantAction: ActionMask, wantText: "***** it", wantRules: []int{1}},
+		{name: "fullwidth folded", text: "ｄａｒｎ", wantAction: ActionMask, wantText: "****", wantRules: []int{1}},
+		{name: "inside a longer word", text: "darned", wantAction: ActionAllow, wantText: "darned", wantRules: []int{}},
+		{name: "phrase across whitespace", text: "BUY\n  now!", wantAction: ActionHold, wantText: "BUY\n  now!", wantRules: []int{2}},
+		{name: "most severe wins and masks still apply", text: "darn, buy now", wantAction: ActionHold, wantText: "****, buy now", wantRules: []int{1, 2}},
+		{name: "regex reject", text: "free  crypto darn", wantAction: ActionReject, wantText: "free  crypto ****", wantRules: []int{1, 3}},
+		{name: "rule for another scope", text: "heck", wantAction: ActionAllow, wantText: "heck", wantRules: []int{}},
+		{name: "disabled rule", text: "bad", wantAction: ActionAllow, wantText: "bad", wantRules: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Check(ScopePost, tt.text)
+			if got.Action != tt.wantAction || got.Text != tt.wantText || !slices.Equal(got.RuleIDs, tt.wantRules) {
+				t.Errorf("Check(%q) = %+v, want {Action:%s Text:%s RuleIDs:%v}", tt.text, got, tt.wantAction, tt.wantText, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestNilEngineAllows(t *testing.T) {
+	var engine *Engine
+	if got := engine.Check(ScopePost, "anything"); got.Action != ActionAllow || got.Text != "anything" {
+		t.Errorf("Check() on nil engine = %+v", got)
+	}
+}
+
+func TestNewEngineSkipsInvalidRules(t *testing.T) {
+	engine, errs := NewEngine([]Rule{
+		{ID: 1, Pattern: "ok", MatchType: MatchWord, Action: ActionReject, Scopes: []string{ScopePost}, Enabled: true},
+		{ID: 2, Pattern: "two words", MatchType: MatchWord, Action: ActionReject, Scopes: []string{ScopePost}, Enabled: true},
+		{ID: 3, Pattern: "   ", MatchType: MatchPhrase, Action: ActionReject, Scopes: []string{ScopePost}, Enabled: true},
+		{ID: 4, Pattern: "(", MatchType: MatchRegex, Action: ActionReject, Scopes: []string{ScopePost}, Enabled: true},
+		{ID: 5, Pattern: "x", MatchType: "glob", Action: ActionReject, Scopes: []string{ScopePost}, Enabled: true},
+	})
+	if len(errs) != 4 {
+		t.Errorf("NewEngine() returned %d errors, want 4: %v", len(errs), errs)
+	}
+	if got := engine.Check(ScopePost, "ok"); got.Action != ActionReject {
+		t.Errorf("valid rule was not kept: %+v", got)
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		ranges [][2]int
+		want   string
+	}{
+		{name: "single", text: "abcdef", ranges: [][2]int{{1, 3}}, want: "a**def"},
+		{name: "unsorted", text: "abcdef", ranges: [][2]int{{3, 5}, {0, 2}}, want: "**c**f"},
+		{name: "overlapping", text: "abcdef", ranges: [][2]int{{0, 3}, {1, 4}}, want: "****ef"},
+		{name: "contained", text: "abcdef", ranges: [][2]int{{0, 4}, {1, 2}}, want: "****ef"},
+		{name: "one asterisk per rune", text: "héllo world", ranges: [][2]int{{0, 6}}, want: "***** world"},
+		{name: "whole text", text: "日本", ranges: [][2]int{{0, 6}}, want: "**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mask(tt.text, tt.ranges); got != tt.want {
+				t.Errorf("mask(%q, %v) = %q, want %q", tt.text, tt.ranges, got, tt.want)
+			}
+		})
+	}
+}
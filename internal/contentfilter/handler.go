@@ -0,0 +1,201 @@
+package contentfilter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles content filter administration HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new content filter handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers rule and review queue routes for administrators
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	ar := r.PathPrefix("/admin/content-filter").Subrouter()
+	ar.Use(middleware.RequireAdmin)
+
+	ar.HandleFunc("/rules", h.ListRules).Methods(http.MethodGet)
+	ar.HandleFunc("/rules", h.CreateRule).Methods(http.MethodPost)
+	ar.HandleFunc("/rules/{id:[0-9]+}", h.UpdateRule).Methods(http.MethodPut)
+	ar.HandleFunc("/rules/{id:[0-9]+}", h.DeleteRule).Methods(http.MethodDelete)
+	ar.HandleFunc("/test", h.Test).Methods(http.MethodPost)
+	ar.HandleFunc("/holds", h.ListHolds).Methods(http.MethodGet)
+	ar.HandleFunc("/holds/{id:[0-9]+}/approve", h.ApproveHold).Methods(http.MethodPost)
+	ar.HandleFunc("/holds/{id:[0-9]+}/reject", h.RejectHold).Methods(http.MethodPost)
+}
+
+// ListRules handles listing every rule
+func (h *Handler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.ListRules(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, rules)
+}
+
+// CreateRule handles adding a rule
+func (h *Handler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	rule, err := h.service.CreateRule(r.Context(), adminID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, rule)
+}
+
+// UpdateRule handles replacing a rule
+func (h *Handler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	rule, err := h.service.UpdateRule(r.Context(), id, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, rule)
+}
+
+// DeleteRule handles removing a rule
+func (h *Handler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	if err := h.service.DeleteRule(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "rule deleted")
+}
+
+// Test handles checking sample text against the active rules
+func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
+	var req TestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	verdict, err := h.service.Test(&req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, verdict)
+}
+
+// ListHolds handles listing held content, pending by default
+func (h *Handler) ListHolds(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = HoldPending
+	case HoldPending, HoldApproved, HoldRejected, HoldSuperseded:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	holds, err := h.service.ListHolds(r.Context(), status, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, holds)
+}
+
+// ApproveHold handles publishing held content
+func (h *Handler) ApproveHold(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.ApproveHold)
+}
+
+// RejectHold handles discarding held content
+func (h *Handler) RejectHold(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.RejectHold)
+}
+
+// review parses the hold ID and records the reviewer's decision
+func (h *Handler) review(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, reviewerID int, id int64) (*Hold, error)) {
+	reviewerID, _ := middleware.UserIDFromContext(r.Context())
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid hold id")
+		return
+	}
+
+	hold, err := decide(r.Context(), reviewerID, id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, hold)
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Handle validation errors
+	var validationErr validator.ValidationErrors
+	if errors.As(err, &validationErr) {
+		utils.WriteError(w, http.StatusBadRequest, "validation failed: "+validationErr.Error())
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,94 @@
+package contentfilter
+
+import "time"
+
+// Scopes are the kinds of user-written text rules can apply to
+const (
+	ScopePost    = "post"
+	ScopeComment = "comment"
+	ScopeBio     = "bio"
+)
+
+// Match types
+const (
+	MatchWord   = "word"
+	MatchPhrase = "phrase"
+	MatchRegex  = "regex"
+)
+
+// Actions, from least to most severe
+const (
+	ActionAllow  = "allow"
+	ActionMask   = "mask"
+	ActionHold   = "hold"
+	ActionReject = "reject"
+)
+
+// severity orders actions so the most severe matching rule wins
+var severity = map[string]int{
+	ActionAllow:  0,
+	ActionMask:   1,
+	ActionHold:   2,
+	ActionReject: 3,
+}
+
+// Hold statuses
+const (
+	HoldPending    = "pending"
+	HoldApproved   = "approved"
+	HoldRejected   = "rejected"
+	HoldSuperseded = "superseded"
+)
+
+// Rule is a filtering rule managed by administrators
+type Rule struct {
+	ID        int       `json:"id" db:"id"`
+	Pattern   string    `json:"pattern" db:"pattern"`
+	MatchType string    `json:"match_type" db:"match_type"`
+	Action    string    `json:"action" db:"action"`
+	Scopes    []string  `json:"scopes" db:"scopes"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	Note      *string   `json:"note,omitempty" db:"note"`
+	CreatedBy *int      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RuleRequest represents the payload for creating or replacing a rule.
+// Regex patterns are matched against folded text, so they should be written in
+// lowercase without diacritics.
+type RuleRequest struct {
+	Pattern   string   `json:"pattern" validate:"required,max=500"`
+	MatchType string   `json:"match_type" validate:"required,oneof=word phrase regex"`
+	Action    string   `json:"action" validate:"required,oneof=reject hold mask"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,oneof=post comment bio"`
+	Enabled   *bool    `json:"enabled,omitempty"`
+	Note      *string  `json:"note,omitempty" validate:"omitempty,max=255"`
+}
+
+// TestRequest represents the payload for trying the active rules against some text
+type TestRequest struct {
+	Scope string `json:"scope" validate:"required,oneof=post comment bio"`
+	Text  string `json:"text" validate:"required"`
+}
+
+// Verdict is the outcome of checking text against the active rules
+type Verdict struct {
+	Action  string `json:"action"`
+	Text    string `json:"text"` // the input with masked terms replaced
+	RuleIDs []int  `json:"rule_ids"`
+}
+
+// Hold is content queued for review by a hold rule
+type Hold struct {
+	ID         int64      `json:"id" db:"id"`
+	Scope      string     `json:"scope" db:"scope"`
+	SubjectID  int64      `json:"subject_id" db:"subject_id"`
+	AuthorID   int        `json:"author_id" db:"author_id"`
+	Content    string     `json:"content" db:"content"`
+	RuleIDs    []int      `json:"rule_ids" db:"rule_ids"`
+	Status     string     `json:"status" db:"status"`
+	ReviewedBy *int       `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,258 @@
+package contentfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Sentinel errors returned by the repository
+var (
+	errRuleNotFound = errors.New("content filter rule not found")
+	errHoldNotFound = errors.New("held content not found")
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for filter rules and held content
+type RepositoryInterface interface {
+	ListRules(ctx context.Context) ([]Rule, error)
+	CreateRule(ctx context.Context, rule *Rule) (*Rule, error)
+	UpdateRule(ctx context.Context, rule *Rule) (*Rule, error)
+	DeleteRule(ctx context.Context, id int) error
+	CreateHold(ctx context.Context, hold *Hold) (*Hold, error)
+	SupersedeHolds(ctx context.Context, scope string, subjectID int64) error
+	ListHolds(ctx context.Context, status string, limit, offset int) ([]Hold, error)
+	ReviewHold(ctx context.Context, id int64, reviewerID int, status string, apply func(*Hold) error) (*Hold, error)
+}
+
+// NewRepository creates a new content filter repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+const ruleColumns = `id, pattern, match_type, action, scopes, enabled, note, created_by, created_at, updated_at`
+
+const holdColumns = `id, scope, subject_id, author_id, content, rule_ids, status, reviewed_by, reviewed_at, created_at`
+
+// scanRule scans a database row into a Rule model
+func scanRule(row pgx.Row) (*Rule, error) {
+	var rule Rule
+	err := row.Scan(
+		&rule.ID,
+		&rule.Pattern,
+		&rule.MatchType,
+		&rule.Action,
+		&rule.Scopes,
+		&rule.Enabled,
+		&rule.Note,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to scan content filter rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// scanHold scans a database row into a Hold model
+func scanHold(row pgx.Row) (*Hold, error) {
+	var hold Hold
+	err := row.Scan(
+		&hold.ID,
+		&hold.Scope,
+		&hold.SubjectID,
+		&hold.AuthorID,
+		&hold.Content,
+		&hold.RuleIDs,
+		&hold.Status,
+		&hold.ReviewedBy,
+		&hold.ReviewedAt,
+		&hold.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to scan held content: %w", err)
+	}
+	return &hold, nil
+}
+
+// ListRules returns every rule, enabled or not
+func (r *Repository) ListRules(ctx context.Context) ([]Rule, error) {
+	rows, err := r.db.Querier(ctx).Query(ctx, `SELECT `+ruleColumns+` FROM content_filter_rules ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list content filter rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate content filter rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRule stores a new rule
+func (r *Repository) CreateRule(ctx context.Context, rule *Rule) (*Rule, error) {
+	now := time.Now()
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        INSERT INTO content_filter_rules (pattern, match_type, action, scopes, enabled, note, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+        RETURNING `+ruleColumns,
+		rule.Pattern, rule.MatchType, rule.Action, rule.Scopes, rule.Enabled, rule.Note, rule.CreatedBy, now)
+	return scanRule(row)
+}
+
+// UpdateRule replaces a rule's definition
+func (r *Repository) UpdateRule(ctx context.Context, rule *Rule) (*Rule, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        UPDATE content_filter_rules
+        SET pattern = $2, match_type = $3, action = $4, scopes = $5, enabled = $6, note = $7, updated_at = $8
+        WHERE id = $1
+        RETURNING `+ruleColumns,
+		rule.ID, rule.Pattern, rule.MatchType, rule.Action, rule.Scopes, rule.Enabled, rule.Note, time.Now())
+	return scanRule(row)
+}
+
+// DeleteRule removes a rule
+func (r *Repository) DeleteRule(ctx context.Context, id int) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM content_filter_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete content filter rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errRuleNotFound
+	}
+	return nil
+}
+
+// CreateHold queues content for review, superseding earlier pending holds for the same subject
+func (r *Repository) CreateHold(ctx context.Context, hold *Hold) (*Hold, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	_, err = tx.Exec(ctx, `
+        UPDATE content_filter_holds SET status = $3
+        WHERE scope = $1 AND subject_id = $2 AND status = $4
+    `, hold.Scope, hold.SubjectID, HoldSuperseded, HoldPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to supersede held content: %w", err)
+	}
+
+	row := tx.QueryRow(ctx, `
+        INSERT INTO content_filter_holds (scope, subject_id, author_id, content, rule_ids, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING `+holdColumns,
+		hold.Scope, hold.SubjectID, hold.AuthorID, hold.Content, hold.RuleIDs, HoldPending, time.Now())
+	created, err := scanHold(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit held content: %w", err)
+	}
+	return created, nil
+}
+
+// SupersedeHolds withdraws pending holds for a subject whose content was replaced without review
+func (r *Repository) SupersedeHolds(ctx context.Context, scope string, subjectID int64) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        UPDATE content_filter_holds SET status = $3
+        WHERE scope = $1 AND subject_id = $2 AND status = $4
+    `, scope, subjectID, HoldSuperseded, HoldPending)
+	if err != nil {
+		return fmt.Errorf("failed to supersede held content: %w", err)
+	}
+	return nil
+}
+
+// ListHolds returns held content with the given status, oldest first
+func (r *Repository) ListHolds(ctx context.Context, status string, limit, offset int) ([]Hold, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, `
+        SELECT `+holdColumns+`
+        FROM content_filter_holds
+        WHERE status = $1
+        ORDER BY created_at, id
+        LIMIT $2 OFFSET $3
+    `, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held content: %w", err)
+	}
+	defer rows.Close()
+
+	holds := []Hold{}
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, *hold)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate held content: %w", err)
+	}
+	return holds, nil
+}
+
+// ReviewHold locks a pending hold, runs apply (if any) and records the decision. The
+// decision is only stored if apply succeeds.
+func (r *Repository) ReviewHold(ctx context.Context, id int64, reviewerID int, status string, apply func(*Hold) error) (*Hold, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	hold, err := scanHold(tx.QueryRow(ctx, `
+        SELECT `+holdColumns+` FROM content_filter_holds WHERE id = $1 AND status = $2 FOR UPDATE
+    `, id, HoldPending))
+	if err != nil {
+		return nil, err
+	}
+
+	if apply != nil {
+		if err := apply(hold); err != nil {
+			return nil, err
+		}
+	}
+
+	reviewed, err := scanHold(tx.QueryRow(ctx, `
+        UPDATE content_filter_holds SET status = $2, reviewed_by = $3, reviewed_at = $4
+        WHERE id = $1
+        RETURNING `+holdColumns,
+		id, status, reviewerID, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit review: %w", err)
+	}
+	return reviewed, nil
+}
@@ -0,0 +1,22 @@
+package contentfilter
+
+import (
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes. The returned
+// service has no rules loaded until its Run loop starts.
+func Register(r *mux.Router, db *database.DataBase) *Service {
+	repo := NewRepository(db)
+	svc := NewService(repo, database.NewTxManager(db))
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc
+}
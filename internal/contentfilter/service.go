@@ -0,0 +1,277 @@
+package contentfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// reloadInterval is how often rules are reloaded, so changes made through another
+// instance's admin API are picked up
+const reloadInterval = 30 * time.Second
+
+// ReleaseFunc publishes held content once a moderator approves it. It runs in the approval's
+// transaction, which repositories called with ctx join.
+type ReleaseFunc func(ctx context.Context, hold Hold) error
+
+// ServiceInterface defines business operations for the content filter
+type ServiceInterface interface {
+	Check(scope, text string) Verdict
+	Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict Verdict) error
+	Withdraw(ctx context.Context, scope string, subjectID int64) error
+	ListRules(ctx context.Context) ([]Rule, error)
+	CreateRule(ctx context.Context, adminID int, req *RuleRequest) (*Rule, error)
+	UpdateRule(ctx context.Context, id int, req *RuleRequest) (*Rule, error)
+	DeleteRule(ctx context.Context, id int) error
+	Test(req *TestRequest) (*Verdict, error)
+	ListHolds(ctx context.Context, status string, limit, offset int) ([]Hold, error)
+	ApproveHold(ctx context.Context, reviewerID int, id int64) (*Hold, error)
+	RejectHold(ctx context.Context, reviewerID int, id int64) (*Hold, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	transactor database.Transactor
+	validator  *validator.Validate
+	engine     atomic.Pointer[Engine]
+
+	mu        sync.RWMutex
+	releasers map[string]ReleaseFunc
+}
+
+// NewService creates a new content filter service. No rules apply until Reload or Run loads them.
+func NewService(repository RepositoryInterface, transactor database.Transactor) *Service {
+	return &Service{
+		repository: repository,
+		transactor: transactor,
+		validator:  validator.New(),
+		releasers:  make(map[string]ReleaseFunc),
+	}
+}
+
+// OnRelease registers how approved held content of scope is published
+func (s *Service) OnRelease(scope string, release ReleaseFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releasers[scope] = release
+}
+
+// Check matches text against the active rules for scope
+func (s *Service) Check(scope, text string) Verdict {
+	return s.engine.Load().Check(scope, text)
+}
+
+// Hold queues content that matched a hold rule for review
+func (s *Service) Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict Verdict) error {
+	_, err := s.repository.CreateHold(ctx, &Hold{
+		Scope:     scope,
+		SubjectID: subjectID,
+		AuthorID:  authorID,
+		Content:   text,
+		RuleIDs:   verdict.RuleIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("error while holding content %w", err)
+	}
+	return nil
+}
+
+// Withdraw drops pending holds for a subject, so a later approval cannot overwrite
+// content that was replaced in the meantime
+func (s *Service) Withdraw(ctx context.Context, scope string, subjectID int64) error {
+	if err := s.repository.SupersedeHolds(ctx, scope, subjectID); err != nil {
+		return fmt.Errorf("error while withdrawing held content %w", err)
+	}
+	return nil
+}
+
+// Reload compiles the stored rules and swaps them in. Rules that fail to compile are logged and skipped.
+func (s *Service) Reload(ctx context.Context) error {
+	rules, err := s.repository.ListRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reload content filter rules: %w", err)
+	}
+
+	engine, errs := NewEngine(rules)
+	for _, err := range errs {
+		logging.FromContext(ctx).Warn("skipping content filter rule", "error", err)
+	}
+	s.engine.Store(engine)
+	return nil
+}
+
+// Run reloads rules on every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("content filter reload failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListRules returns every stored rule
+func (s *Service) ListRules(ctx context.Context) ([]Rule, error) {
+	rules, err := s.repository.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing content filter rules %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRule validates and stores a rule; it applies immediately
+func (s *Service) CreateRule(ctx context.Context, adminID int, req *RuleRequest) (*Rule, error) {
+	rule, err := s.buildRule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.CreatedBy = &adminID
+
+	created, err := s.repository.CreateRule(ctx, rule)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating content filter rule %w", err)
+	}
+
+	s.reloadNow(ctx)
+	return created, nil
+}
+
+// UpdateRule replaces a rule's definition; the change applies immediately
+func (s *Service) UpdateRule(ctx context.Context, id int, req *RuleRequest) (*Rule, error) {
+	rule, err := s.buildRule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+
+	updated, err := s.repository.UpdateRule(ctx, rule)
+	if err != nil {
+		return nil, mapError(err, "error while updating content filter rule")
+	}
+
+	s.reloadNow(ctx)
+	return updated, nil
+}
+
+// DeleteRule removes a rule; the change applies immediately
+func (s *Service) DeleteRule(ctx context.Context, id int) error {
+	if err := s.repository.DeleteRule(ctx, id); err != nil {
+		return mapError(err, "error while deleting content filter rule")
+	}
+
+	s.reloadNow(ctx)
+	return nil
+}
+
+// Test runs the active rules against sample text without storing anything
+func (s *Service) Test(req *TestRequest) (*Verdict, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	verdict := s.Check(req.Scope, req.Text)
+	return &verdict, nil
+}
+
+// ListHolds returns held content with the given status
+func (s *Service) ListHolds(ctx context.Context, status string, limit, offset int) ([]Hold, error) {
+	holds, err := s.repository.ListHolds(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing held content %w", err)
+	}
+	return holds, nil
+}
+
+// ApproveHold publishes held content through the release function of its scope. The
+// decision and the published content are committed together.
+func (s *Service) ApproveHold(ctx context.Context, reviewerID int, id int64) (*Hold, error) {
+	var hold *Hold
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		hold, err = s.repository.ReviewHold(ctx, id, reviewerID, HoldApproved, func(hold *Hold) error {
+			s.mu.RLock()
+			release, ok := s.releasers[hold.Scope]
+			s.mu.RUnlock()
+			if !ok {
+				return apperrors.WrapWithMessage(fmt.Errorf("no release function for scope %q", hold.Scope), http.StatusConflict,
+					"held "+hold.Scope+" content cannot be published")
+			}
+			return release(ctx, *hold)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, mapError(err, "error while approving held content")
+	}
+	return hold, nil
+}
+
+// RejectHold discards held content
+func (s *Service) RejectHold(ctx context.Context, reviewerID int, id int64) (*Hold, error) {
+	hold, err := s.repository.ReviewHold(ctx, id, reviewerID, HoldRejected, nil)
+	if err != nil {
+		return nil, mapError(err, "error while rejecting held content")
+	}
+	return hold, nil
+}
+
+// buildRule validates a rule request and checks that it compiles
+func (s *Service) buildRule(req *RuleRequest) (*Rule, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	rule := &Rule{
+		Pattern:   req.Pattern,
+		MatchType: req.MatchType,
+		Action:    req.Action,
+		Scopes:    req.Scopes,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		Note:      req.Note,
+	}
+	if _, err := compile(*rule); err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, err.Error())
+	}
+	return rule, nil
+}
+
+// reloadNow applies a rule change on this instance; other instances pick it up on their next reload
+func (s *Service) reloadNow(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		logging.FromContext(ctx).Error("content filter reload failed", "error", err)
+	}
+}
+
+// mapError converts repository sentinel errors into client errors and wraps anything else
+func mapError(err error, message string) error {
+	var appErr *apperrors.AppError
+	switch {
+	case errors.As(err, &appErr):
+		return err
+	case errors.Is(err, errRuleNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "rule not found")
+	case errors.Is(err, errHoldNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "pending held content not found")
+	}
+	return fmt.Errorf("%s %w", message, err)
+}
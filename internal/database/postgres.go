@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"learning/internal/config"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -12,7 +12,8 @@ import (
 
 // DataBase represents a database connection pool
 type DataBase struct {
-	Pool *pgxpool.Pool
+	Pool     *pgxpool.Pool
+	replicas *replicaSet // nil without replicas
 }
 
 // New creates a new database connection pool
@@ -31,6 +32,7 @@ func New(cfg *config.Config) (*DataBase, error) {
 	poolConfig.MinConns = cfg.DataBase.MinConn
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = time.Minute * 30
+	poolConfig.ConnConfig.Tracer = NewQueryTracer()
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
@@ -46,13 +48,25 @@ func New(cfg *config.Config) (*DataBase, error) {
 		return nil, fmt.Errorf("database connection test failed: %w", err)
 	}
 
-	log.Println("Database connected successfully")
+	slog.Info("database connected")
 
-	return &DataBase{Pool: pool}, nil
+	db := &DataBase{Pool: pool}
+	if len(cfg.DataBase.Replicas) > 0 {
+		if db.replicas, err = newReplicaSet(&cfg.DataBase, poolConfig); err != nil {
+			pool.Close()
+			return nil, err
+		}
+		slog.Info("database replicas connected", "count", len(db.replicas.replicas), "strategy", db.replicas.strategy)
+	}
+
+	return db, nil
 }
 
 // Close closes the database connection pool
 func (db *DataBase) Close() {
+	if db.replicas != nil {
+		db.replicas.close()
+	}
 	db.Pool.Close()
-	log.Println("Database connection closed")
+	slog.Info("database connection closed")
 }
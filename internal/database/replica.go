@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/config"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Replica selection strategies
+const (
+	StrategyRoundRobin = "round_robin"
+	StrategyLatency    = "latency"
+)
+
+// replicaCheckTimeout bounds a single replica health check
+const replicaCheckTimeout = 2 * time.Second
+
+// lagQuery reports whether a replica's WAL receiver is streaming from the primary and how
+// far its replay is behind in seconds. A replica that has replayed everything it received
+// is not behind, however long ago the last write was, but only while it is still receiving:
+// once disconnected it has no way of knowing what it is missing. The receiver's status is
+// NULL for roles without pg_read_all_stats, so a running receiver then counts as streaming.
+const lagQuery = `
+	SELECT
+		EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE COALESCE(status, 'streaming') = 'streaming'),
+		CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END::float8`
+
+// replica is a read-only pool and the result of its latest health check
+type replica struct {
+	addr    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+	latency atomic.Int64 // nanoseconds
+}
+
+// replicaSet routes reads across replicas that passed their latest health check
+type replicaSet struct {
+	replicas []*replica
+	strategy string
+	maxLag   time.Duration
+	interval time.Duration
+	next     atomic.Uint64
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of ctx whose reads go to the primary. Use it after a mutation
+// so the caller reads its own writes rather than a replica that may not have them yet.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usePrimary reports whether ctx was marked by WithPrimary
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// Reader returns where read-only statements for ctx should run: the transaction carried by
+// ctx, the primary when ctx was marked by WithPrimary or no replica is usable, and a
+// healthy replica otherwise. Only use it for reads that tolerate replication lag.
+func (db *DataBase) Reader(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	if db.replicas == nil || usePrimary(ctx) {
+		return db.Pool
+	}
+	if r := db.replicas.pick(); r != nil {
+		return r.pool
+	}
+	return db.Pool
+}
+
+// ReplicaPools returns the connection pool of every configured replica, keyed by address
+func (db *DataBase) ReplicaPools() map[string]*pgxpool.Pool {
+	pools := make(map[string]*pgxpool.Pool)
+	if db.replicas == nil {
+		return pools
+	}
+	for _, r := range db.replicas.replicas {
+		pools[r.addr] = r.pool
+	}
+	return pools
+}
+
+// newReplicaSet connects to the configured replicas. A replica that cannot be reached is
+// kept and serves no reads until a health check passes.
+func newReplicaSet(cfg *config.DataBaseConfig, poolConfig *pgxpool.Config) (*replicaSet, error) {
+	set := &replicaSet{
+		strategy: cfg.ReplicaStrategy,
+		maxLag:   cfg.ReplicaMaxLag,
+		interval: cfg.ReplicaCheckInterval,
+	}
+	for _, addr := range cfg.Replicas {
+		replicaConfig, err := pgxpool.ParseConfig(cfg.ReplicaDSN(addr))
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("failed to parse replica %s configuration: %w", addr, err)
+		}
+		replicaConfig.MaxConns = poolConfig.MaxConns
+		replicaConfig.MinConns = poolConfig.MinConns
+		replicaConfig.MaxConnLifetime = poolConfig.MaxConnLifetime
+		replicaConfig.MaxConnIdleTime = poolConfig.MaxConnIdleTime
+		replicaConfig.ConnConfig.Tracer = poolConfig.ConnConfig.Tracer
+
+		pool, err := pgxpool.NewWithConfig(context.Background(), replicaConfig)
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("failed to create replica %s connection pool: %w", addr, err)
+		}
+		r := &replica{addr: addr, pool: pool}
+		r.healthy.Store(true) // so the first check logs a replica that is down
+		set.replicas = append(set.replicas, r)
+	}
+
+	set.checkAll(context.Background())
+	return set, nil
+}
+
+// pick returns a healthy replica according to the strategy, or nil when none is healthy
+func (s *replicaSet) pick() *replica {
+	var best *replica
+	switch s.strategy {
+	case StrategyLatency:
+		for _, r := range s.replicas {
+			if r.healthy.Load() && (best == nil || r.latency.Load() < best.latency.Load()) {
+				best = r
+			}
+		}
+	default:
+		// Rotate over the healthy replicas so one being down doesn't double its neighbour's share
+		healthy := make([]*replica, 0, len(s.replicas))
+		for _, r := range s.replicas {
+			if r.healthy.Load() {
+				healthy = append(healthy, r)
+			}
+		}
+		if len(healthy) > 0 {
+			best = healthy[s.next.Add(1)%uint64(len(healthy))]
+		}
+	}
+	return best
+}
+
+// checkAll checks every replica concurrently
+func (s *replicaSet) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range s.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.check(ctx, r)
+		}()
+	}
+	wg.Wait()
+}
+
+// check measures a replica's round trip and replication lag and records the result
+func (s *replicaSet) check(ctx context.Context, r *replica) {
+	checkCtx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var streaming bool
+	var lagSeconds float64
+	err := r.pool.QueryRow(checkCtx, lagQuery).Scan(&streaming, &lagSeconds)
+	latency := time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		return // shutting down
+	}
+
+	s.record(r, err, streaming, time.Duration(lagSeconds*float64(time.Second)), latency)
+}
+
+// record stores the result of a health check, taking the replica out of rotation when it
+// is unreachable, not streaming from the primary or lags more than maxLag and back in once
+// it recovers
+func (s *replicaSet) record(r *replica, err error, streaming bool, lag, latency time.Duration) {
+	healthy := err == nil && streaming && lag <= s.maxLag
+	r.latency.Store(int64(latency))
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	switch {
+	case err != nil:
+		slog.Warn("database replica unavailable", "replica", r.addr, "error", err)
+	case !streaming:
+		slog.Warn("database replica not streaming from the primary", "replica", r.addr)
+	case !healthy:
+		slog.Warn("database replica lagging", "replica", r.addr, "lag", lag, "max_lag", s.maxLag)
+	default:
+		slog.Info("database replica available", "replica", r.addr, "latency", latency, "lag", lag)
+	}
+}
+
+// Run checks replica health and lag on every interval until ctx is cancelled. It returns
+// immediately when no replicas are configured.
+func (db *DataBase) Run(ctx context.Context) {
+	if db.replicas == nil {
+		return
+	}
+
+	ticker := time.NewTicker(db.replicas.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			db.replicas.checkAll(ctx)
+		}
+	}
+}
+
+// close closes every replica pool
+func (s *replicaSet) close() {
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
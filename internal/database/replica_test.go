@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestReplicas returns a database whose replicas are unconnected pools, healthy unless
+// listed in down
+func newTestReplicas(strategy string, n int, down ...int) *DataBase {
+	set := &replicaSet{strategy: strategy, maxLag: time.Second}
+	for i := range n {
+		r := &replica{addr: string(rune('a' + i)), pool: &pgxpool.Pool{}}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+	for _, i := range down {
+		set.replicas[i].healthy.Store(false)
+	}
+	return &DataBase{Pool: &pgxpool.Pool{}, replicas: set}
+}
+
+func TestReader(t *testing.T) {
+	tx := &fakeTx{}
+
+	tests := []struct {
+		name        string
+		db          *DataBase
+		ctx         context.Context
+		wantPrimary bool
+		wantTx      bool
+	}{
+		{name: "replica", db: newTestReplicas(StrategyRoundRobin, 2), ctx: context.Background()},
+		{name: "no replicas configured", db: &DataBase{Pool: &pgxpool.Pool{}}, ctx: context.Background(), wantPrimary: true},
+		{name: "primary requested", db: newTestReplicas(StrategyRoundRobin, 2), ctx: WithPrimary(context.Background()), wantPrimary: true},
+		{name: "no replica healthy", db: newTestReplicas(StrategyRoundRobin, 2, 0, 1), ctx: context.Background(), wantPrimary: true},
+		{name: "no replica healthy by latency", db: newTestReplicas(StrategyLatency, 2, 0, 1), ctx: context.Background(), wantPrimary: true},
+		{name: "transaction", db: newTestReplicas(StrategyRoundRobin, 2), ctx: withTx(context.Background(), tx), wantTx: true},
+		{name: "transaction wins over primary", db: newTestReplicas(StrategyRoundRobin, 2), ctx: WithPrimary(withTx(context.Background(), tx)), wantTx: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.db.Reader(tt.ctx)
+			switch {
+			case tt.wantTx:
+				if q != Querier(tx) {
+					t.Errorf("Reader() = %v, want the transaction", q)
+				}
+			case tt.wantPrimary:
+				if q != Querier(tt.db.Pool) {
+					t.Errorf("Reader() = %v, want the primary", q)
+				}
+			default:
+				if q == Querier(tt.db.Pool) {
+					t.Error("Reader() = the primary, want a replica")
+				}
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  string
+		down      []int
+		latencies []time.Duration
+		want      []string // replicas picked by consecutive calls
+	}{
+		{name: "round robin", strategy: StrategyRoundRobin, want: []string{"b", "c", "a", "b"}},
+		{name: "round robin skips unhealthy", strategy: StrategyRoundRobin, down: []int{1}, want: []string{"c", "a", "c", "a"}},
+		{name: "lowest latency", strategy: StrategyLatency, latencies: []time.Duration{3, 1, 2}, want: []string{"b", "b"}},
+		{name: "lowest healthy latency", strategy: StrategyLatency, down: []int{1}, latencies: []time.Duration{3, 1, 2}, want: []string{"c", "c"}},
+		{name: "none healthy", strategy: StrategyRoundRobin, down: []int{0, 1, 2}, want: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newTestReplicas(tt.strategy, 3, tt.down...).replicas
+			for i, latency := range tt.latencies {
+				set.replicas[i].latency.Store(int64(latency))
+			}
+
+			for i, want := range tt.want {
+				got := ""
+				if r := set.pick(); r != nil {
+					got = r.addr
+				}
+				if got != want {
+					t.Errorf("pick %d = %q, want %q", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		err       error
+		streaming bool
+		lag       time.Duration
+		want      bool
+	}{
+		{name: "streaming within lag", streaming: true, lag: time.Second, want: true},
+		{name: "lagging", streaming: true, lag: 2 * time.Second},
+		{name: "not streaming", lag: 0},
+		{name: "unreachable", err: errDown, streaming: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestReplicas(StrategyRoundRobin, 1)
+			set, r := db.replicas, db.replicas.replicas[0]
+
+			set.record(r, tt.err, tt.streaming, tt.lag, time.Millisecond)
+			if r.healthy.Load() != tt.want {
+				t.Fatalf("healthy = %v, want %v", r.healthy.Load(), tt.want)
+			}
+			if inRotation := db.Reader(context.Background()) == Querier(r.pool); inRotation != tt.want {
+				t.Errorf("replica in rotation = %v, want %v", inRotation, tt.want)
+			}
+
+			set.record(r, nil, true, 0, time.Millisecond)
+			if !r.healthy.Load() || db.Reader(context.Background()) != Querier(r.pool) {
+				t.Error("recovered replica was not put back in rotation")
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/logging"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// serializationFailure is the SQLSTATE of a transaction that must be retried
+const serializationFailure = "40001"
+
+// defaultTxAttempts is how many times a transaction is run before a serialization failure
+// is returned to the caller
+const defaultTxAttempts = 5
+
+// Querier runs statements. Both the pool and transactions implement it; Begin on a
+// transaction starts a savepoint.
+type Querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// Ensure the pool and transactions implement Querier
+var (
+	_ Querier = (*pgxpool.Pool)(nil)
+	_ Querier = (pgx.Tx)(nil)
+)
+
+type txKey struct{}
+
+// withTx returns a copy of ctx carrying tx
+func withTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction started by TxManager that ctx carries, if any
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// Querier returns the transaction carried by ctx or, outside one, the primary pool.
+// Repositories use it, or Reader for lag-tolerant reads, for every statement so they join
+// a caller's transaction transparently.
+func (db *DataBase) Querier(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db.Pool
+}
+
+// Transactor runs a unit of work in a transaction. Services depend on it rather than on
+// TxManager so they can be used without a database.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Ensure TxManager implements Transactor
+var _ Transactor = (*TxManager)(nil)
+
+// txBeginner starts outermost transactions. The pool implements it.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxManager runs units of work spanning several repository calls in one transaction
+type TxManager struct {
+	pool        txBeginner
+	maxAttempts int
+}
+
+// NewTxManager creates a transaction manager for db
+func NewTxManager(db *DataBase) *TxManager {
+	return &TxManager{pool: db.Pool, maxAttempts: defaultTxAttempts}
+}
+
+// WithinTx runs fn in a read committed transaction. See WithinTxOptions.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithinTxOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// WithinSerializableTx runs fn in a serializable transaction. See WithinTxOptions.
+func (m *TxManager) WithinSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithinTxOptions(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn)
+}
+
+// WithinTxOptions runs fn with a context carrying a transaction, committing if fn returns
+// nil and rolling back otherwise, including when fn panics.
+//
+// Called inside another transaction, fn runs in a savepoint instead: an error rolls back
+// only fn's work and opts are ignored. The outermost transaction is retried from the start
+// on serialization failures (SQLSTATE 40001), so fn must be safe to run more than once and
+// should not have effects outside the database.
+func (m *TxManager) WithinTxOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		return run(ctx, savepoint, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		tx, err := m.pool.BeginTx(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		err = run(ctx, tx, fn)
+		if err == nil || !IsSerializationFailure(err) || attempt == m.maxAttempts {
+			return err
+		}
+
+		logging.FromContext(ctx).Warn("retrying transaction after serialization failure", "attempt", attempt)
+		backoff := time.Duration(attempt) * time.Duration(5+rand.IntN(20)) * time.Millisecond
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// run calls fn in tx and commits or rolls back
+func run(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			Rollback(ctx, tx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(withTx(ctx, tx)); err != nil {
+		Rollback(ctx, tx)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback rolls back tx and is meant to be deferred right after Begin. Failures are
+// logged through the context logger rather than returned: rolling back a transaction that
+// was committed, or whose context was cancelled, is expected and not logged.
+func Rollback(ctx context.Context, tx pgx.Tx) {
+	err := tx.Rollback(ctx)
+	if err == nil || errors.Is(err, pgx.ErrTxClosed) || ctx.Err() != nil {
+		return
+	}
+	logging.FromContext(ctx).Error("failed to roll back transaction", "error", err)
+}
+
+// IsSerializationFailure reports whether err is a serialization failure that the whole
+// transaction can be retried after
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == serializationFailure
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fakeTx records how a transaction or savepoint ended; Begin starts a savepoint. Methods
+// the tests don't reach panic through the nil embedded interface.
+type fakeTx struct {
+	pgx.Tx
+	savepoints []*fakeTx
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	savepoint := &fakeTx{}
+	tx.savepoints = append(tx.savepoints, savepoint)
+	return savepoint, nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if tx.committed || tx.rolledBack {
+		return pgx.ErrTxClosed
+	}
+	tx.rolledBack = true
+	return nil
+}
+
+// fakePool records every outermost transaction it begins
+type fakePool struct {
+	txs []*fakeTx
+}
+
+func (p *fakePool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	p.txs = append(p.txs, tx)
+	return tx, nil
+}
+
+func TestWithinTxNestsSavepoints(t *testing.T) {
+	pool := &fakePool{}
+	m := &TxManager{pool: pool, maxAttempts: defaultTxAttempts}
+	errInner := errors.New("inner failed")
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := m.WithinTx(ctx, func(ctx context.Context) error { return nil }); err != nil {
+			t.Errorf("first savepoint error = %v", err)
+		}
+		if err := m.WithinTx(ctx, func(ctx context.Context) error { return errInner }); !errors.Is(err, errInner) {
+			t.Errorf("second savepoint error = %v, want %v", err, errInner)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+
+	if len(pool.txs) != 1 {
+		t.Fatalf("began %d transactions, want 1 with savepoints inside", len(pool.txs))
+	}
+	outer := pool.txs[0]
+	if !outer.committed || outer.rolledBack {
+		t.Errorf("outer transaction committed = %v, rolled back = %v; want committed", outer.committed, outer.rolledBack)
+	}
+	if len(outer.savepoints) != 2 {
+		t.Fatalf("created %d savepoints, want 2", len(outer.savepoints))
+	}
+	if sp := outer.savepoints[0]; !sp.committed || sp.rolledBack {
+		t.Errorf("successful savepoint committed = %v, rolled back = %v; want released", sp.committed, sp.rolledBack)
+	}
+	if sp := outer.savepoints[1]; sp.committed || !sp.rolledBack {
+		t.Errorf("failed savepoint committed = %v, rolled back = %v; want rolled back", sp.committed, sp.rolledBack)
+	}
+}
+
+func TestWithinTxRetriesSerializationFailures(t *testing.T) {
+	serialization := &pgconn.PgError{Code: serializationFailure}
+	other := errors.New("other failure")
+
+	tests := []struct {
+		name         string
+		failures     int // attempts failing before one succeeds
+		err          error
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "succeeds first time", wantAttempts: 1},
+		{name: "retried until it succeeds", failures: 2, err: serialization, wantAttempts: 3},
+		{name: "gives up at the limit", failures: 5, err: serialization, wantAttempts: 3, wantErr: serialization},
+		{name: "other errors are not retried", failures: 5, err: other, wantAttempts: 1, wantErr: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &fakePool{}
+			m := &TxManager{pool: pool, maxAttempts: 3}
+
+			attempts := 0
+			err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithinTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts || len(pool.txs) != tt.wantAttempts {
+				t.Errorf("ran %d times in %d transactions, want %d", attempts, len(pool.txs), tt.wantAttempts)
+			}
+			for i, tx := range pool.txs {
+				wantCommitted := tt.wantErr == nil && i == len(pool.txs)-1
+				if tx.committed != wantCommitted || tx.rolledBack == wantCommitted {
+					t.Errorf("attempt %d committed = %v, rolled back = %v; want committed = %v", i+1, tx.committed, tx.rolledBack, wantCommitted)
+				}
+			}
+		})
+	}
+}
+
+func TestWithinTxRollsBackOnPanic(t *testing.T) {
+	pool := &fakePool{}
+	m := &TxManager{pool: pool, maxAttempts: defaultTxAttempts}
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the panic to propagate", p)
+			}
+		}()
+		_ = m.WithinTx(context.Background(), func(ctx context.Context) error {
+			return m.WithinTx(ctx, func(ctx context.Context) error {
+				panic("boom")
+			})
+		})
+	}()
+
+	if len(pool.txs) != 1 || len(pool.txs[0].savepoints) != 1 {
+		t.Fatalf("began %d transactions, want 1 with a savepoint", len(pool.txs))
+	}
+	for name, tx := range map[string]*fakeTx{"transaction": pool.txs[0], "savepoint": pool.txs[0].savepoints[0]} {
+		if tx.committed || !tx.rolledBack {
+			t.Errorf("%s committed = %v, rolled back = %v; want rolled back", name, tx.committed, tx.rolledBack)
+		}
+	}
+}
+
+func TestQuerier(t *testing.T) {
+	db := &DataBase{Pool: &pgxpool.Pool{}}
+	m := &TxManager{pool: &fakePool{}, maxAttempts: defaultTxAttempts}
+
+	if q := db.Querier(context.Background()); q != Querier(db.Pool) {
+		t.Errorf("Querier() outside a transaction = %v, want the pool", q)
+	}
+	_ = m.WithinTx(context.Background(), func(ctx context.Context) error {
+		tx, _ := TxFromContext(ctx)
+		if q := db.Querier(ctx); q != Querier(tx) {
+			t.Errorf("Querier() in a transaction = %v, want the transaction", q)
+		}
+		return m.WithinTx(ctx, func(ctx context.Context) error {
+			savepoint, _ := TxFromContext(ctx)
+			if savepoint == tx {
+				t.Error("nested WithinTx() reused the outer transaction, want a savepoint")
+			}
+			if q := db.Querier(ctx); q != Querier(savepoint) {
+				t.Errorf("Querier() in a savepoint = %v, want the savepoint", q)
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package hashtag
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTagLength is the longest tag (in runes) that will be indexed
+const MaxTagLength = 100
+
+// Extract returns the distinct, normalized hashtags found in text in order of appearance
+func Extract(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	prev := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '#' && r != '＃' || isTagRune(prev) {
+			prev = r
+			i += size
+			continue
+		}
+
+		// Consume the tag body following the hash sign
+		start := i + size
+		end := start
+		for end < len(text) {
+			tr, tsize := utf8.DecodeRuneInString(text[end:])
+			if !isTagRune(tr) {
+				break
+			}
+			end += tsize
+		}
+
+		if tag, ok := Normalize(text[start:end]); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+
+		prev = r
+		if end > start {
+			prev, _ = utf8.DecodeLastRuneInString(text[start:end])
+		}
+		i = end
+	}
+
+	return tags
+}
+
+// Normalize converts a raw tag (without the leading hash) to its canonical form.
+// It reports false when the tag is empty, too long or contains no letters.
+func Normalize(raw string) (string, bool) {
+	tag := strings.TrimPrefix(strings.TrimPrefix(raw, "#"), "＃")
+	tag = strings.ToLower(norm.NFKC.String(tag))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", false
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return "", false
+	}
+
+	return tag, true
+}
+
+// isTagRune reports whether r may appear inside a hashtag
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r)
+}
@@ -0,0 +1,69 @@
+package hashtag
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "none", text: "no tags here", want: nil},
+		{name: "single", text: "hello #golang", want: []string{"golang"}},
+		{name: "order of appearance", text: "#b then #a", want: []string{"b", "a"}},
+		{name: "lowercased and deduplicated", text: "#Go #GO #go", want: []string{"go"}},
+		{name: "punctuation ends tag", text: "#go, #rust! (#zig)", want: []string{"go", "rust", "zig"}},
+		{name: "underscores and digits", text: "#web_3 #go1", want: []string{"web_3", "go1"}},
+		{name: "mid-word hash", text: "issue#42 c#sharp", want: nil},
+		{name: "adjacent hashes", text: "#a#b", want: []string{"a"}},
+		{name: "digits only", text: "#2024 #1st", want: []string{"1st"}},
+		{name: "bare hash", text: "# #", want: nil},
+		{name: "fullwidth hash", text: "＃日本", want: []string{"日本"}},
+		{name: "fullwidth letters folded", text: "#ＧＯ", want: []string{"go"}},
+		{name: "composed and decomposed accents", text: "#caf\u00e9 #cafe\u0301", want: []string{"caf\u00e9"}},
+		{name: "too long", text: "#" + strings.Repeat("a", MaxTagLength+1), want: nil},
+		{name: "longest allowed", text: "#" + strings.Repeat("a", MaxTagLength), want: []string{strings.Repeat("a", MaxTagLength)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Extract(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{raw: "Golang", want: "golang", wantOK: true},
+		{raw: "#Golang", want: "golang", wantOK: true},
+		{raw: "＃Golang", want: "golang", wantOK: true},
+		{raw: "ｆｕｌｌ", want: "full", wantOK: true},
+		{raw: "cafe\u0301", want: "caf\u00e9", wantOK: true},
+		{raw: "ﬁle", want: "file", wantOK: true},
+		{raw: "go_1", want: "go_1", wantOK: true},
+		{raw: ""},
+		{raw: "#"},
+		{raw: "123"},
+		{raw: "go-lang"},
+		{raw: "go lang"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := Normalize(tt.raw)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.raw, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
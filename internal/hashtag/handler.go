@@ -0,0 +1,69 @@
+package hashtag
+
+import (
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles hashtag-related HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new hashtag handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers hashtag-related routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/tags/trending", h.Trending).Methods(http.MethodGet)
+	r.HandleFunc("/tags/{tag}/posts", h.PostsByTag).Methods(http.MethodGet)
+}
+
+// PostsByTag handles listing posts that reference a tag
+func (h *Handler) PostsByTag(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	posts, err := h.service.GetPostsByTag(r.Context(), mux.Vars(r)["tag"], limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if posts == nil {
+		posts = []TaggedPost{}
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, posts)
+}
+
+// Trending handles retrieval of the cached trending tags
+func (h *Handler) Trending(w http.ResponseWriter, r *http.Request) {
+	trending, err := h.service.GetTrending(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, trending)
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,30 @@
+package hashtag
+
+import "time"
+
+// Hashtag represents a normalized tag stored in the system
+type Hashtag struct {
+	ID        int       `json:"id" db:"id"`
+	Tag       string    `json:"tag" db:"tag"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TaggedPost represents a post that references a hashtag
+type TaggedPost struct {
+	PostID    int64     `json:"post_id" db:"post_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TrendingTag represents a hashtag with its time-decayed trending score
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Uses  int     `json:"uses"`
+	Score float64 `json:"score"`
+}
+
+// TrendingResponse represents the cached trending list returned in API responses
+type TrendingResponse struct {
+	Tags        []TrendingTag `json:"tags"`
+	ComputedAt  time.Time     `json:"computed_at"`
+	WindowHours float64       `json:"window_hours"`
+}
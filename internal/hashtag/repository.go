@@ -0,0 +1,127 @@
+package hashtag
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for hashtags
+type RepositoryInterface interface {
+	ReplacePostTags(ctx context.Context, postID int64, tags []string, createdAt time.Time) error
+	ListPostsByTag(ctx context.Context, tag string, limit, offset int) ([]TaggedPost, error)
+	TrendingSince(ctx context.Context, since time.Time, halfLife time.Duration, limit int) ([]TrendingTag, error)
+}
+
+// NewRepository creates a new hashtag repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// ReplacePostTags stores tags for a post, replacing any previously indexed tags
+func (r *Repository) ReplacePostTags(ctx context.Context, postID int64, tags []string, createdAt time.Time) error {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM post_hashtags WHERE post_id = $1`, postID); err != nil {
+		return fmt.Errorf("failed to clear post hashtags: %w", err)
+	}
+
+	for _, tag := range tags {
+		var hashtagID int
+		err := tx.QueryRow(ctx, `
+            INSERT INTO hashtags (tag) VALUES ($1)
+            ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+            RETURNING id
+        `, tag).Scan(&hashtagID)
+		if err != nil {
+			return fmt.Errorf("failed to upsert hashtag %q: %w", tag, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO post_hashtags (post_id, hashtag_id, created_at)
+            VALUES ($1, $2, $3)
+            ON CONFLICT DO NOTHING
+        `, postID, hashtagID, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to link hashtag %q: %w", tag, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit post hashtags: %w", err)
+	}
+	return nil
+}
+
+// ListPostsByTag returns the most recent posts referencing tag
+func (r *Repository) ListPostsByTag(ctx context.Context, tag string, limit, offset int) ([]TaggedPost, error) {
+	query := `
+        SELECT ph.post_id, ph.created_at
+        FROM post_hashtags ph
+        JOIN hashtags h ON h.id = ph.hashtag_id
+        WHERE h.tag = $1
+        ORDER BY ph.created_at DESC, ph.post_id DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, tag, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts by tag: %w", err)
+	}
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (TaggedPost, error) {
+		var p TaggedPost
+		err := row.Scan(&p.PostID, &p.CreatedAt)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan tagged posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// TrendingSince scores tags used since the given time, decaying each use exponentially by its age
+func (r *Repository) TrendingSince(ctx context.Context, since time.Time, halfLife time.Duration, limit int) ([]TrendingTag, error) {
+	query := `
+        SELECT h.tag,
+               COUNT(*) AS uses,
+               SUM(EXP(-LN(2) * EXTRACT(EPOCH FROM (NOW() - ph.created_at)) / $2)) AS score
+        FROM post_hashtags ph
+        JOIN hashtags h ON h.id = ph.hashtag_id
+        WHERE ph.created_at >= $1
+        GROUP BY h.tag
+        ORDER BY score DESC, uses DESC, h.tag
+        LIMIT $3
+    `
+
+	rows, err := r.db.Querier(ctx).Query(ctx, query, since, halfLife.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute trending tags: %w", err)
+	}
+
+	tags, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (TrendingTag, error) {
+		var t TrendingTag
+		err := row.Scan(&t.Tag, &t.Uses, &t.Score)
+		return t, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan trending tags: %w", err)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,24 @@
+package hashtag
+
+import (
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes.
+// The returned service is used by post producers to index hashtags, and its
+// trending job must be started by the caller.
+func Register(r *mux.Router, db *database.DataBase) (*Service, *Trending) {
+	repo := NewRepository(db)
+	trending := NewTrending(repo, DefaultTrendingConfig())
+	svc := NewService(repo, trending)
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc, trending
+}
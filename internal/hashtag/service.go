@@ -0,0 +1,75 @@
+package hashtag
+
+import (
+	"context"
+	"fmt"
+	apperrors "learning/internal/errors"
+	"net/http"
+	"time"
+)
+
+// ServiceInterface defines business operations for hashtags
+type ServiceInterface interface {
+	IndexPost(ctx context.Context, postID int64, body string, createdAt time.Time) ([]string, error)
+	GetPostsByTag(ctx context.Context, tag string, limit, offset int) ([]TaggedPost, error)
+	GetTrending(ctx context.Context) (*TrendingResponse, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	trending   *Trending
+}
+
+// NewService creates a new hashtag service
+func NewService(repository RepositoryInterface, trending *Trending) *Service {
+	return &Service{
+		repository: repository,
+		trending:   trending,
+	}
+}
+
+// IndexPost extracts hashtags from a post body and stores them, returning the indexed tags.
+// It should be called whenever a post is created or its body is edited.
+func (s *Service) IndexPost(ctx context.Context, postID int64, body string, createdAt time.Time) ([]string, error) {
+	if postID <= 0 {
+		return nil, fmt.Errorf("invalid post id %d", postID)
+	}
+
+	tags := Extract(body)
+	if err := s.repository.ReplacePostTags(ctx, postID, tags, createdAt); err != nil {
+		return nil, fmt.Errorf("failed to index post %d: %w", postID, err)
+	}
+	return tags, nil
+}
+
+// GetPostsByTag returns the posts referencing the given tag, newest first
+func (s *Service) GetPostsByTag(ctx context.Context, tag string, limit, offset int) ([]TaggedPost, error) {
+	normalized, ok := Normalize(tag)
+	if !ok {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("invalid tag %q", tag), http.StatusBadRequest, "invalid tag")
+	}
+
+	posts, err := s.repository.ListPostsByTag(ctx, normalized, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting posts by tag %w", err)
+	}
+	return posts, nil
+}
+
+// GetTrending returns the most recently computed trending tags
+func (s *Service) GetTrending(ctx context.Context) (*TrendingResponse, error) {
+	result, ok := s.trending.Current()
+	if ok {
+		return result, nil
+	}
+
+	// Nothing cached yet (job has not completed its first run), compute on demand
+	if err := s.trending.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("error while computing trending tags %w", err)
+	}
+	result, _ = s.trending.Current()
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package hashtag
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/logging"
+	"sync"
+	"time"
+)
+
+// TrendingConfig controls how trending scores are computed
+type TrendingConfig struct {
+	Window          time.Duration // only uses newer than this are considered
+	HalfLife        time.Duration // age at which a use counts for half its weight
+	RefreshInterval time.Duration // how often the periodic job recomputes scores
+	Limit           int           // number of tags kept in the cache
+}
+
+// DefaultTrendingConfig returns the default trending configuration
+func DefaultTrendingConfig() TrendingConfig {
+	return TrendingConfig{
+		Window:          24 * time.Hour,
+		HalfLife:        6 * time.Hour,
+		RefreshInterval: 5 * time.Minute,
+		Limit:           20,
+	}
+}
+
+// Trending computes trending tags periodically and caches the latest result
+type Trending struct {
+	repository RepositoryInterface
+	cfg        TrendingConfig
+
+	mu     sync.RWMutex
+	cached *TrendingResponse
+}
+
+// NewTrending creates a new trending job
+func NewTrending(repository RepositoryInterface, cfg TrendingConfig) *Trending {
+	return &Trending{
+		repository: repository,
+		cfg:        cfg,
+	}
+}
+
+// Current returns the cached trending result, if one has been computed
+func (t *Trending) Current() (*TrendingResponse, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.cached, t.cached != nil
+}
+
+// Refresh recomputes trending scores over the sliding window and replaces the cache
+func (t *Trending) Refresh(ctx context.Context) error {
+	now := time.Now()
+	tags, err := t.repository.TrendingSince(ctx, now.Add(-t.cfg.Window), t.cfg.HalfLife, t.cfg.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to refresh trending tags: %w", err)
+	}
+	if tags == nil {
+		tags = []TrendingTag{}
+	}
+
+	t.mu.Lock()
+	t.cached = &TrendingResponse{
+		Tags:        tags,
+		ComputedAt:  now,
+		WindowHours: t.cfg.Window.Hours(),
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the trending cache on every interval until ctx is cancelled
+func (t *Trending) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("trending refresh failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by a BlobStore when the key does not exist
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores immutable content-addressed blobs
+type BlobStore interface {
+	// Put stores size bytes from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether a blob is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the blob stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// blobKey returns the storage key for a SHA-256 digest, sharded by its first bytes
+// so no single directory or prefix grows unbounded
+func blobKey(sha string) string {
+	return sha[0:2] + "/" + sha[2:4] + "/" + sha
+}
@@ -0,0 +1,239 @@
+package media
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// uploadTimeout replaces the server-wide read/write timeouts on upload routes
+const uploadTimeout = 10 * time.Minute
+
+// Names of the public routes, so policies such as CORS can be applied to them
+const (
+	RouteMedia   = "media.get"
+	RouteContent = "media.content"
+)
+
+// Handler handles media-related HTTP requests
+type Handler struct {
+	service  ServiceInterface
+	maxBytes int64
+}
+
+// NewHandler creates a new media handler
+func NewHandler(service ServiceInterface, maxBytes int64) *Handler {
+	return &Handler{service: service, maxBytes: maxBytes}
+}
+
+// RegisterRoutes registers media routes. Uploading requires an authenticated user;
+// media content is public so it can be embedded anywhere.
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/media/{id:[0-9]+}", h.Get).Methods(http.MethodGet).Name(RouteMedia)
+	r.HandleFunc("/media/{id:[0-9]+}/content", h.Content).Methods(http.MethodGet).Name(RouteContent)
+
+	ur := r.PathPrefix("/media").Subrouter()
+	ur.Use(middleware.RequireAuth)
+
+	ur.HandleFunc("", h.Upload).Methods(http.MethodPost)
+	ur.HandleFunc("/uploads", h.CreateUpload).Methods(http.MethodPost)
+	ur.HandleFunc("/uploads/{uploadId}", h.GetUpload).Methods(http.MethodGet)
+	ur.HandleFunc("/uploads/{uploadId}", h.WriteChunk).Methods(http.MethodPut)
+	ur.HandleFunc("/uploads/{uploadId}/complete", h.CompleteUpload).Methods(http.MethodPost)
+}
+
+// Upload handles a single-request multipart upload with the file in the "file" field
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	extendDeadlines(w)
+
+	// Allow some room for multipart headers around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes+64<<10)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "expected multipart/form-data body")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			utils.WriteError(w, http.StatusBadRequest, "missing file field")
+			return
+		}
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		var filename *string
+		if name := part.FileName(); name != "" {
+			filename = &name
+		}
+
+		media, err := h.service.Upload(r.Context(), userID, filename, part)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+
+		utils.WriteSuccess(w, http.StatusCreated, media)
+		return
+	}
+}
+
+// CreateUpload handles starting a resumable chunked upload
+func (h *Handler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req CreateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	upload, err := h.service.CreateUpload(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, upload)
+}
+
+// GetUpload handles querying how much of a chunked upload has been received
+func (h *Handler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	upload, err := h.service.GetUpload(r.Context(), userID, mux.Vars(r)["uploadId"])
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, upload)
+}
+
+// WriteChunk handles a chunk sent with a "Content-Range: bytes start-end/total" header
+func (h *Handler) WriteChunk(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	extendDeadlines(w)
+
+	var start, end, total int64
+	if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil || start < 0 || end < start || total <= end {
+		utils.WriteError(w, http.StatusBadRequest, "invalid Content-Range header")
+		return
+	}
+	length := end - start + 1
+
+	upload, err := h.service.WriteChunk(r.Context(), userID, mux.Vars(r)["uploadId"], start, total, r.Body, length)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, upload)
+}
+
+// CompleteUpload handles finishing a chunked upload
+func (h *Handler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	extendDeadlines(w)
+
+	media, err := h.service.CompleteUpload(r.Context(), userID, mux.Vars(r)["uploadId"])
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, media)
+}
+
+// Get handles retrieval of media metadata
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid media id")
+		return
+	}
+
+	media, err := h.service.GetMedia(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, media)
+}
+
+// Content streams media bytes. Content is addressed by digest and never changes,
+// so it is served with a long-lived immutable cache policy.
+func (h *Handler) Content(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid media id")
+		return
+	}
+
+	media, content, err := h.service.OpenMedia(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	etag := `"` + media.SHA256 + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", media.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(media.Size, 10))
+	extendDeadlines(w)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to stream media", "media_id", id, "error", err)
+	}
+}
+
+// extendDeadlines gives large transfers more time than the server-wide timeouts allow
+func extendDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(uploadTimeout)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, "file too large")
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,80 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorePath(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "media")
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "ab/cd/abcdef", want: filepath.Join(root, "ab", "cd", "abcdef")},
+		{key: "ab/../cd/file", want: filepath.Join(root, "cd", "file")},
+		{key: "../outside", wantErr: true},
+		{key: "ab/../../outside", wantErr: true},
+		{key: "../media-other/file", wantErr: true},
+		{key: "/etc/passwd", want: filepath.Join(root, "etc", "passwd")},
+		{key: "", wantErr: true},
+		{key: ".", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := store.path(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("path(%q) = %q, want an error", tt.key, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("path(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "media"))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+	data := []byte("secret")
+
+	if err := store.Put(ctx, "../escaped", bytes.NewReader(data), int64(len(data)), "text/plain"); err == nil {
+		t.Error("Put() outside the root succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); !os.IsNotExist(err) {
+		t.Errorf("blob written outside the root: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "outside"), data, 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if rc, err := store.Get(ctx, "../outside"); err == nil {
+		got, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		t.Errorf("Get() outside the root read %q", got)
+	}
+	if err := store.Delete(ctx, "../outside"); err == nil {
+		t.Error("Delete() outside the root succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside")); err != nil {
+		t.Errorf("file outside the root was removed: %v", err)
+	}
+}
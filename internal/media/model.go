@@ -0,0 +1,73 @@
+package media
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of media accepted for upload
+const (
+	KindImage = "image"
+	KindVideo = "video"
+)
+
+// MaxAttachments is the number of media items that can be attached to a post
+const MaxAttachments = 4
+
+// allowedTypes maps each accepted sniffed content type to its media kind
+var allowedTypes = map[string]string{
+	"image/jpeg": KindImage,
+	"image/png":  KindImage,
+	"image/gif":  KindImage,
+	"image/webp": KindImage,
+	"video/mp4":  KindVideo,
+	"video/webm": KindVideo,
+}
+
+// Media represents an uploaded file owned by a user
+type Media struct {
+	ID          int64     `json:"id" db:"id"`
+	OwnerID     int       `json:"owner_id" db:"owner_id"`
+	SHA256      string    `json:"sha256" db:"sha256"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	Filename    *string   `json:"filename,omitempty" db:"filename"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Blob represents deduplicated stored content shared by media with the same digest
+type Blob struct {
+	SHA256      string
+	Size        int64
+	ContentType string
+	StorageKey  string
+}
+
+// Upload represents a resumable chunked upload in progress
+type Upload struct {
+	ID        string    `json:"id" db:"id"`
+	OwnerID   int       `json:"-" db:"owner_id"`
+	Filename  *string   `json:"filename,omitempty" db:"filename"`
+	TotalSize int64     `json:"total_size" db:"total_size"`
+	Received  int64     `json:"received" db:"received"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// CreateUploadRequest represents the request payload for starting a chunked upload
+type CreateUploadRequest struct {
+	Filename *string `json:"filename,omitempty" validate:"omitempty,max=255"`
+	Size     int64   `json:"size" validate:"required,gt=0"`
+}
+
+// AttachRequest represents the request payload for attaching media to a post
+type AttachRequest struct {
+	MediaIDs []int64 `json:"media_ids" validate:"max=4,dive,gt=0"`
+}
+
+// contentURL returns the API path that serves a media item's content
+func contentURL(id int64) string {
+	return fmt.Sprintf("/api/v1/media/%d/content", id)
+}
@@ -0,0 +1,246 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// errNotFound is returned when a media item or upload does not exist
+var errNotFound = errors.New("not found")
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for media
+type RepositoryInterface interface {
+	GetBlob(ctx context.Context, sha string) (*Blob, error)
+	CreateBlob(ctx context.Context, blob *Blob) error
+	CreateMedia(ctx context.Context, ownerID int, sha string, filename *string) (*Media, error)
+	GetMedia(ctx context.Context, id int64) (*Media, error)
+	CountOwnedMedia(ctx context.Context, ownerID int, ids []int64) (int, error)
+	ReplacePostMedia(ctx context.Context, postID int64, mediaIDs []int64) error
+	ListPostMedia(ctx context.Context, postID int64) ([]Media, error)
+	CreateUpload(ctx context.Context, upload *Upload) error
+	GetUpload(ctx context.Context, id string, ownerID int) (*Upload, error)
+	AdvanceUpload(ctx context.Context, id string, from, to int64) (bool, error)
+	DeleteUpload(ctx context.Context, id string) error
+	DeleteExpiredUploads(ctx context.Context, now time.Time) ([]string, error)
+}
+
+// NewRepository creates a new media repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+const mediaSelect = `
+        SELECT m.id, m.owner_id, m.sha256, b.content_type, b.size, m.filename, b.storage_key, m.created_at
+        FROM media m
+        JOIN media_blobs b ON b.sha256 = m.sha256
+`
+
+// scanMediaFromRow scans a database row into a Media model
+func scanMediaFromRow(row pgx.Row) (*Media, error) {
+	var m Media
+
+	err := row.Scan(
+		&m.ID,
+		&m.OwnerID,
+		&m.SHA256,
+		&m.ContentType,
+		&m.Size,
+		&m.Filename,
+		&m.StorageKey,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to scan media: %w", err)
+	}
+
+	m.URL = contentURL(m.ID)
+	return &m, nil
+}
+
+// GetBlob returns the stored blob with the given digest
+func (r *Repository) GetBlob(ctx context.Context, sha string) (*Blob, error) {
+	var b Blob
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT sha256, size, content_type, storage_key FROM media_blobs WHERE sha256 = $1
+    `, sha).Scan(&b.SHA256, &b.Size, &b.ContentType, &b.StorageKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	return &b, nil
+}
+
+// CreateBlob records a stored blob; recording the same digest twice is a no-op
+func (r *Repository) CreateBlob(ctx context.Context, blob *Blob) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        INSERT INTO media_blobs (sha256, size, content_type, storage_key)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (sha256) DO NOTHING
+    `, blob.SHA256, blob.Size, blob.ContentType, blob.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	return nil
+}
+
+// CreateMedia creates a media item referencing an existing blob
+func (r *Repository) CreateMedia(ctx context.Context, ownerID int, sha string, filename *string) (*Media, error) {
+	var id int64
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        INSERT INTO media (owner_id, sha256, filename, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, ownerID, sha, filename, time.Now()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create media: %w", err)
+	}
+	return r.GetMedia(ctx, id)
+}
+
+// GetMedia returns a media item with its blob metadata
+func (r *Repository) GetMedia(ctx context.Context, id int64) (*Media, error) {
+	return scanMediaFromRow(r.db.Querier(ctx).QueryRow(ctx, mediaSelect+` WHERE m.id = $1`, id))
+}
+
+// CountOwnedMedia returns how many of the given media IDs belong to the owner
+func (r *Repository) CountOwnedMedia(ctx context.Context, ownerID int, ids []int64) (int, error) {
+	var count int
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FROM media WHERE owner_id = $1 AND id = ANY($2)
+    `, ownerID, ids).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count media: %w", err)
+	}
+	return count, nil
+}
+
+// ReplacePostMedia sets the ordered media attachments of a post
+func (r *Repository) ReplacePostMedia(ctx context.Context, postID int64, mediaIDs []int64) error {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM post_media WHERE post_id = $1`, postID); err != nil {
+		return fmt.Errorf("failed to clear post media: %w", err)
+	}
+	for i, mediaID := range mediaIDs {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO post_media (post_id, media_id, position) VALUES ($1, $2, $3)
+        `, postID, mediaID, i)
+		if err != nil {
+			return fmt.Errorf("failed to attach media: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit post media: %w", err)
+	}
+	return nil
+}
+
+// ListPostMedia returns the media attached to a post in display order
+func (r *Repository) ListPostMedia(ctx context.Context, postID int64) ([]Media, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, mediaSelect+`
+        JOIN post_media pm ON pm.media_id = m.id
+        WHERE pm.post_id = $1
+        ORDER BY pm.position
+    `, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list post media: %w", err)
+	}
+
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Media, error) {
+		m, err := scanMediaFromRow(row)
+		if err != nil {
+			return Media{}, err
+		}
+		return *m, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan post media: %w", err)
+	}
+
+	return items, nil
+}
+
+// CreateUpload records a new chunked upload session
+func (r *Repository) CreateUpload(ctx context.Context, upload *Upload) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        INSERT INTO media_uploads (id, owner_id, filename, total_size, received, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, 0, $5, $6)
+    `, upload.ID, upload.OwnerID, upload.Filename, upload.TotalSize, upload.CreatedAt, upload.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+	return nil
+}
+
+// GetUpload returns an unexpired upload session belonging to the owner
+func (r *Repository) GetUpload(ctx context.Context, id string, ownerID int) (*Upload, error) {
+	var u Upload
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT id, owner_id, filename, total_size, received, created_at, expires_at
+        FROM media_uploads
+        WHERE id = $1 AND owner_id = $2 AND expires_at > $3
+    `, id, ownerID, time.Now()).Scan(&u.ID, &u.OwnerID, &u.Filename, &u.TotalSize, &u.Received, &u.CreatedAt, &u.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+	return &u, nil
+}
+
+// AdvanceUpload moves the received offset from one value to another. It reports
+// false if another chunk advanced the upload first.
+func (r *Repository) AdvanceUpload(ctx context.Context, id string, from, to int64) (bool, error) {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `
+        UPDATE media_uploads SET received = $3 WHERE id = $1 AND received = $2
+    `, id, from, to)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance upload: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteUpload removes an upload session
+func (r *Repository) DeleteUpload(ctx context.Context, id string) error {
+	if _, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM media_uploads WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredUploads removes expired upload sessions and returns their IDs
+func (r *Repository) DeleteExpiredUploads(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.db.Querier(ctx).Query(ctx, `DELETE FROM media_uploads WHERE expires_at <= $1 RETURNING id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired uploads: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan expired uploads: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,54 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/config"
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// NewBlobStore builds the blob store selected in the configuration
+func NewBlobStore(ctx context.Context, cfg config.MediaConfig) (BlobStore, error) {
+	switch cfg.Store {
+	case "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(ctx, S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown media store %q", cfg.Store)
+	}
+}
+
+// Register composes repository -> service -> handler and registers routes.
+// The returned service attaches media to posts and runs upload cleanup.
+func Register(r *mux.Router, db *database.DataBase, store BlobStore, cfg config.MediaConfig) (*Service, error) {
+	repo := NewRepository(db)
+	svc, err := NewService(repo, store, Config{
+		TempDir:       cfg.TempDir,
+		MaxImageBytes: cfg.MaxImageBytes,
+		MaxVideoBytes: cfg.MaxVideoBytes,
+		UploadTTL:     cfg.UploadTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewHandler(svc, svc.cfg.maxBytes())
+	h.RegisterRoutes(r)
+	return svc, nil
+}
@@ -0,0 +1,227 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3Stub is a minimal path-style S3 server holding objects of a single bucket in memory
+type s3Stub struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]s3Object
+	deny    bool // refuse every object request
+}
+
+type s3Object struct {
+	body         []byte
+	contentType  string
+	cacheControl string
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		s.writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" && r.Method == http.MethodHead {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deny {
+		s.writeError(w, r, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readPayload(r)
+		if err != nil {
+			s.writeError(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.objects[key] = s3Object{body: body, contentType: r.Header.Get("Content-Type"), cacheControl: r.Header.Get("Cache-Control")}
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := s.objects[key]
+		if !ok {
+			s.writeError(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.body)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.body)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// writeError answers with an S3 error document; HEAD responses carry only the status
+func (s *s3Stub) writeError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
+
+// readPayload returns the object bytes of a PUT, decoding the aws-chunked encoding
+// the client uses for signed uploads over plain HTTP
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		header, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body.Bytes(), nil
+		}
+		if _, err := io.CopyN(&body, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil { // chunk-terminating CRLF
+			return nil, err
+		}
+	}
+}
+
+// newTestS3Store starts a stub server and returns a store connected to it
+func newTestS3Store(t *testing.T) (*S3Store, *s3Stub) {
+	t.Helper()
+
+	stub := &s3Stub{bucket: "media", objects: make(map[string]s3Object)}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	store, err := NewS3Store(context.Background(), s3TestConfig(srv, stub.bucket))
+	if err != nil {
+		t.Fatalf("NewS3Store() error = %v", err)
+	}
+	return store, stub
+}
+
+func s3TestConfig(srv *httptest.Server, bucket string) S3Config {
+	u, _ := url.Parse(srv.URL)
+	return S3Config{
+		Endpoint:  u.Host,
+		Region:    "us-east-1",
+		Bucket:    bucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	}
+}
+
+func TestNewS3StoreRequiresBucket(t *testing.T) {
+	srv := httptest.NewServer(&s3Stub{bucket: "media", objects: make(map[string]s3Object)})
+	defer srv.Close()
+
+	if _, err := NewS3Store(context.Background(), s3TestConfig(srv, "missing")); err == nil {
+		t.Fatal("NewS3Store() with a missing bucket succeeded")
+	}
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, stub := newTestS3Store(t)
+	key := blobKey("abcdef0123456789")
+	content := strings.Repeat("blob content ", 1000)
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	obj := stub.objects[key]
+	if string(obj.body) != content {
+		t.Fatalf("stored %d bytes, want %d", len(obj.body), len(content))
+	}
+	if obj.contentType != "image/png" || !strings.Contains(obj.cacheControl, "immutable") {
+		t.Errorf("stored Content-Type %q, Cache-Control %q", obj.contentType, obj.cacheControl)
+	}
+
+	exists, err := store.Exists(ctx, key)
+	if err != nil || !exists {
+		t.Fatalf("Exists() = %v, %v, want true", exists, err)
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil || string(got) != content {
+		t.Fatalf("Get() read %d bytes, %v, want %d bytes", len(got), err, len(content))
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, err := store.Exists(ctx, key); err != nil || exists {
+		t.Fatalf("Exists() after Delete = %v, %v, want false", exists, err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() of a missing object error = %v", err)
+	}
+}
+
+func TestS3StoreMissingObject(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestS3Store(t)
+
+	if _, err := store.Get(ctx, "no/such/key"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get() error = %v, want ErrBlobNotFound", err)
+	}
+	if exists, err := store.Exists(ctx, "no/such/key"); err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func TestS3StoreAccessDenied(t *testing.T) {
+	ctx := context.Background()
+	store, stub := newTestS3Store(t)
+	stub.deny = true
+
+	// Failures other than a missing key must not be mistaken for missing objects
+	if _, err := store.Get(ctx, "some/key"); err == nil || errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get() error = %v, want an access error", err)
+	}
+	if _, err := store.Exists(ctx, "some/key"); err == nil {
+		t.Error("Exists() succeeded against a refusing server")
+	}
+}
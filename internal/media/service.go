@@ -0,0 +1,389 @@
+package media
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// sniffLength is how many leading bytes are inspected to detect the content type
+const sniffLength = 512
+
+// Config holds upload limits and scratch storage for the media service
+type Config struct {
+	TempDir       string        // where uploads are spooled before being stored
+	MaxImageBytes int64         // size limit for image uploads
+	MaxVideoBytes int64         // size limit for video uploads
+	UploadTTL     time.Duration // how long a chunked upload may stay incomplete
+}
+
+// maxBytes returns the largest upload accepted of any kind
+func (c Config) maxBytes() int64 {
+	return max(c.MaxImageBytes, c.MaxVideoBytes)
+}
+
+// ServiceInterface defines business operations for media
+type ServiceInterface interface {
+	Upload(ctx context.Context, ownerID int, filename *string, r io.Reader) (*Media, error)
+	CreateUpload(ctx context.Context, ownerID int, req *CreateUploadRequest) (*Upload, error)
+	GetUpload(ctx context.Context, ownerID int, id string) (*Upload, error)
+	WriteChunk(ctx context.Context, ownerID int, id string, start, total int64, r io.Reader, length int64) (*Upload, error)
+	CompleteUpload(ctx context.Context, ownerID int, id string) (*Media, error)
+	GetMedia(ctx context.Context, id int64) (*Media, error)
+	OpenMedia(ctx context.Context, id int64) (*Media, io.ReadCloser, error)
+	AttachToPost(ctx context.Context, ownerID int, postID int64, req *AttachRequest) error
+	GetPostMedia(ctx context.Context, postID int64) ([]Media, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	store      BlobStore
+	cfg        Config
+	validator  *validator.Validate
+}
+
+// NewService creates a new media service
+func NewService(repository RepositoryInterface, store BlobStore, cfg Config) (*Service, error) {
+	if err := os.MkdirAll(cfg.TempDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create media temp directory: %w", err)
+	}
+	return &Service{
+		repository: repository,
+		store:      store,
+		cfg:        cfg,
+		validator:  validator.New(),
+	}, nil
+}
+
+// Upload stores a complete file sent in a single request
+func (s *Service) Upload(ctx context.Context, ownerID int, filename *string, r io.Reader) (*Media, error) {
+	tmp, err := os.CreateTemp(s.cfg.TempDir, "media-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	// Read one byte past the limit so oversized uploads are detected without buffering them
+	written, err := io.Copy(tmp, io.LimitReader(r, s.cfg.maxBytes()+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to spool upload: %w", err)
+	}
+	if written > s.cfg.maxBytes() {
+		return nil, tooLarge(s.cfg.maxBytes())
+	}
+
+	return s.ingest(ctx, ownerID, filename, tmp, written)
+}
+
+// CreateUpload starts a resumable chunked upload
+func (s *Service) CreateUpload(ctx context.Context, ownerID int, req *CreateUploadRequest) (*Upload, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if req.Size > s.cfg.maxBytes() {
+		return nil, tooLarge(s.cfg.maxBytes())
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload := &Upload{
+		ID:        id,
+		OwnerID:   ownerID,
+		Filename:  req.Filename,
+		TotalSize: req.Size,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.UploadTTL),
+	}
+
+	f, err := os.Create(s.chunkPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	_ = f.Close()
+
+	if err := s.repository.CreateUpload(ctx, upload); err != nil {
+		_ = os.Remove(s.chunkPath(id))
+		return nil, fmt.Errorf("error while creating upload %w", err)
+	}
+	return upload, nil
+}
+
+// GetUpload returns the progress of a chunked upload so clients can resume it
+func (s *Service) GetUpload(ctx context.Context, ownerID int, id string) (*Upload, error) {
+	upload, err := s.repository.GetUpload(ctx, id, ownerID)
+	if err != nil {
+		return nil, mapNotFound(err, "upload not found")
+	}
+	return upload, nil
+}
+
+// WriteChunk appends a chunk at offset start. Chunks must arrive in order; a chunk
+// that does not start at the received offset is rejected with 409 so the client
+// can query the offset and resume from there.
+func (s *Service) WriteChunk(ctx context.Context, ownerID int, id string, start, total int64, r io.Reader, length int64) (*Upload, error) {
+	upload, err := s.GetUpload(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if total != upload.TotalSize {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("total %d does not match %d", total, upload.TotalSize), http.StatusBadRequest, "upload size mismatch")
+	}
+	if start != upload.Received {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("chunk at %d, expected %d", start, upload.Received), http.StatusConflict, fmt.Sprintf("expected chunk at offset %d", upload.Received))
+	}
+	if length <= 0 || start+length > upload.TotalSize {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("chunk exceeds upload size"), http.StatusBadRequest, "chunk exceeds upload size")
+	}
+
+	f, err := os.OpenFile(s.chunkPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	written, err := io.Copy(io.NewOffsetWriter(f, start), io.LimitReader(r, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if written != length {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("short chunk: %d of %d bytes", written, length), http.StatusBadRequest, "incomplete chunk")
+	}
+
+	advanced, err := s.repository.AdvanceUpload(ctx, id, start, start+length)
+	if err != nil {
+		return nil, fmt.Errorf("error while recording chunk %w", err)
+	}
+	if !advanced {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("concurrent chunk for upload %s", id), http.StatusConflict, "upload advanced concurrently")
+	}
+
+	upload.Received = start + length
+	return upload, nil
+}
+
+// CompleteUpload stores a fully received chunked upload as media
+func (s *Service) CompleteUpload(ctx context.Context, ownerID int, id string) (*Media, error) {
+	upload, err := s.GetUpload(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Received != upload.TotalSize {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("upload %s incomplete", id), http.StatusConflict, fmt.Sprintf("upload incomplete: %d of %d bytes received", upload.Received, upload.TotalSize))
+	}
+
+	f, err := os.Open(s.chunkPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	media, err := s.ingest(ctx, ownerID, upload.Filename, f, upload.TotalSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.DeleteUpload(ctx, id); err != nil {
+		logging.FromContext(ctx).Error("failed to delete completed upload", "upload_id", id, "error", err)
+	}
+	_ = os.Remove(s.chunkPath(id))
+	return media, nil
+}
+
+// GetMedia returns a media item's metadata
+func (s *Service) GetMedia(ctx context.Context, id int64) (*Media, error) {
+	media, err := s.repository.GetMedia(ctx, id)
+	if err != nil {
+		return nil, mapNotFound(err, "media not found")
+	}
+	return media, nil
+}
+
+// OpenMedia returns a media item together with a reader for its content
+func (s *Service) OpenMedia(ctx context.Context, id int64) (*Media, io.ReadCloser, error) {
+	media, err := s.GetMedia(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.store.Get(ctx, media.StorageKey)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil, nil, apperrors.WrapWithMessage(err, http.StatusNotFound, "media not found")
+		}
+		return nil, nil, fmt.Errorf("error while opening media %w", err)
+	}
+	return media, content, nil
+}
+
+// AttachToPost sets the media shown on a post, in order. Every item must belong to
+// the post's author. Post services call this when a post is created or edited.
+func (s *Service) AttachToPost(ctx context.Context, ownerID int, postID int64, req *AttachRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	seen := make(map[int64]bool, len(req.MediaIDs))
+	for _, id := range req.MediaIDs {
+		if seen[id] {
+			return apperrors.WrapWithMessage(fmt.Errorf("duplicate media %d", id), http.StatusBadRequest, "duplicate media id")
+		}
+		seen[id] = true
+	}
+
+	if len(req.MediaIDs) > 0 {
+		owned, err := s.repository.CountOwnedMedia(ctx, ownerID, req.MediaIDs)
+		if err != nil {
+			return fmt.Errorf("error while checking media ownership %w", err)
+		}
+		if owned != len(req.MediaIDs) {
+			return apperrors.WrapWithMessage(fmt.Errorf("media not owned by user %d", ownerID), http.StatusBadRequest, "unknown media id")
+		}
+	}
+
+	if err := s.repository.ReplacePostMedia(ctx, postID, req.MediaIDs); err != nil {
+		return fmt.Errorf("error while attaching media %w", err)
+	}
+	return nil
+}
+
+// GetPostMedia returns the media attached to a post in display order
+func (s *Service) GetPostMedia(ctx context.Context, postID int64) ([]Media, error) {
+	items, err := s.repository.ListPostMedia(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting post media %w", err)
+	}
+	if items == nil {
+		items = []Media{}
+	}
+	return items, nil
+}
+
+// Run removes expired chunked uploads and their scratch files until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ids, err := s.repository.DeleteExpiredUploads(ctx, now)
+			if err != nil {
+				logging.FromContext(ctx).Error("expired upload cleanup failed", "error", err)
+				continue
+			}
+			for _, id := range ids {
+				_ = os.Remove(s.chunkPath(id))
+			}
+		}
+	}
+}
+
+// ingest sniffs, validates, hashes and stores spooled content, reusing an existing
+// blob when the same bytes were uploaded before
+func (s *Service) ingest(ctx context.Context, ownerID int, filename *string, f *os.File, size int64) (*Media, error) {
+	if size == 0 {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("empty upload"), http.StatusBadRequest, "file is empty")
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	// Trust the bytes, not the client-supplied Content-Type or file extension
+	contentType := http.DetectContentType(head[:n])
+	kind, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("content type %s not allowed", contentType), http.StatusUnsupportedMediaType, "unsupported media type")
+	}
+
+	limit := s.cfg.MaxImageBytes
+	if kind == KindVideo {
+		limit = s.cfg.MaxVideoBytes
+	}
+	if size > limit {
+		return nil, tooLarge(limit)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(f, 0, size)); err != nil {
+		return nil, fmt.Errorf("failed to hash upload: %w", err)
+	}
+	sha := hex.EncodeToString(hash.Sum(nil))
+
+	if _, err := s.repository.GetBlob(ctx, sha); errors.Is(err, errNotFound) {
+		blob := &Blob{SHA256: sha, Size: size, ContentType: contentType, StorageKey: blobKey(sha)}
+		if err := s.store.Put(ctx, blob.StorageKey, io.NewSectionReader(f, 0, size), size, contentType); err != nil {
+			return nil, fmt.Errorf("error while storing media %w", err)
+		}
+		if err := s.repository.CreateBlob(ctx, blob); err != nil {
+			return nil, fmt.Errorf("error while recording media %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("error while checking for duplicate media %w", err)
+	}
+
+	if filename != nil {
+		base := filepath.Base(*filename)
+		filename = &base
+	}
+
+	media, err := s.repository.CreateMedia(ctx, ownerID, sha, filename)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating media %w", err)
+	}
+	return media, nil
+}
+
+// chunkPath returns the scratch file for a chunked upload
+func (s *Service) chunkPath(id string) string {
+	return filepath.Join(s.cfg.TempDir, "upload-"+id)
+}
+
+// newUploadID returns a random 32 character hex identifier
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate upload id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// tooLarge returns the error for uploads over a size limit
+func tooLarge(limit int64) error {
+	return apperrors.WrapWithMessage(fmt.Errorf("upload exceeds %d bytes", limit), http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d byte limit", limit))
+}
+
+// mapNotFound converts the repository's not-found error into a 404
+func mapNotFound(err error, message string) error {
+	if errors.Is(err, errNotFound) {
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, message)
+	}
+	return fmt.Errorf("media operation failed %w", err)
+}
@@ -0,0 +1,323 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	apperrors "learning/internal/errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubRepository keeps blobs, media and uploads in memory; methods the tests don't reach
+// panic through the nil embedded interface
+type stubRepository struct {
+	RepositoryInterface
+	blobs   map[string]*Blob
+	media   []*Media
+	uploads map[string]*Upload
+}
+
+func newStubRepository() *stubRepository {
+	return &stubRepository{blobs: map[string]*Blob{}, uploads: map[string]*Upload{}}
+}
+
+func (r *stubRepository) GetBlob(ctx context.Context, sha string) (*Blob, error) {
+	blob, ok := r.blobs[sha]
+	if !ok {
+		return nil, errNotFound
+	}
+	return blob, nil
+}
+
+func (r *stubRepository) CreateBlob(ctx context.Context, blob *Blob) error {
+	r.blobs[blob.SHA256] = blob
+	return nil
+}
+
+func (r *stubRepository) CreateMedia(ctx context.Context, ownerID int, sha string, filename *string) (*Media, error) {
+	blob := r.blobs[sha]
+	media := &Media{
+		ID:          int64(len(r.media) + 1),
+		OwnerID:     ownerID,
+		SHA256:      sha,
+		ContentType: blob.ContentType,
+		Size:        blob.Size,
+		Filename:    filename,
+		StorageKey:  blob.StorageKey,
+	}
+	r.media = append(r.media, media)
+	return media, nil
+}
+
+func (r *stubRepository) CreateUpload(ctx context.Context, upload *Upload) error {
+	stored := *upload
+	r.uploads[upload.ID] = &stored
+	return nil
+}
+
+func (r *stubRepository) GetUpload(ctx context.Context, id string, ownerID int) (*Upload, error) {
+	upload, ok := r.uploads[id]
+	if !ok || upload.OwnerID != ownerID {
+		return nil, errNotFound
+	}
+	stored := *upload
+	return &stored, nil
+}
+
+func (r *stubRepository) AdvanceUpload(ctx context.Context, id string, from, to int64) (bool, error) {
+	upload := r.uploads[id]
+	if upload.Received != from {
+		return false, nil
+	}
+	upload.Received = to
+	return true, nil
+}
+
+func (r *stubRepository) DeleteUpload(ctx context.Context, id string) error {
+	delete(r.uploads, id)
+	return nil
+}
+
+// countingStore is a LocalStore that counts the blobs written to it
+type countingStore struct {
+	*LocalStore
+	puts int
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	s.puts++
+	return s.LocalStore.Put(ctx, key, r, size, contentType)
+}
+
+func newTestService(t *testing.T) (*Service, *stubRepository, *countingStore) {
+	t.Helper()
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	store := &countingStore{LocalStore: local}
+	repo := newStubRepository()
+	svc, err := NewService(repo, store, Config{
+		TempDir:       t.TempDir(),
+		MaxImageBytes: 1 << 10,
+		MaxVideoBytes: 4 << 10,
+		UploadTTL:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	return svc, repo, store
+}
+
+// testPNG returns a PNG padded to at least size bytes
+func testPNG(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	if pad := size - buf.Len(); pad > 0 {
+		buf.Write(make([]byte, pad))
+	}
+	return buf.Bytes()
+}
+
+// testMP4 returns the start of an MP4 file padded to size bytes
+func testMP4(size int) []byte {
+	data := append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), make([]byte, size)...)
+	return data[:size]
+}
+
+// requireStatus fails unless err is an AppError with the given status
+func requireStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != status {
+		t.Fatalf("error = %v, want status %d", err, status)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		wantType string
+		status   int
+	}{
+		{name: "image", filename: "photo.png", data: testPNG(t, 100), wantType: "image/png"},
+		{name: "video", filename: "clip.mp4", data: testMP4(2 << 10), wantType: "video/mp4"},
+		{name: "type taken from the bytes", filename: "clip.mp4", data: testPNG(t, 100), wantType: "image/png"},
+		{name: "bytes not matching the name", filename: "photo.png", data: []byte("<html><script>alert(1)</script></html>"), status: http.StatusUnsupportedMediaType},
+		{name: "unsupported type", filename: "notes.txt", data: []byte("plain text"), status: http.StatusUnsupportedMediaType},
+		{name: "empty", filename: "photo.png", status: http.StatusBadRequest},
+		{name: "image over the image limit", filename: "photo.png", data: testPNG(t, 2<<10), status: http.StatusRequestEntityTooLarge},
+		{name: "video at the video limit", filename: "clip.mp4", data: testMP4(4 << 10), wantType: "video/mp4"},
+		{name: "over the largest limit", filename: "clip.mp4", data: testMP4(4<<10 + 1), status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _ := newTestService(t)
+			filename := "../../" + tt.filename
+
+			media, err := svc.Upload(context.Background(), 7, &filename, bytes.NewReader(tt.data))
+			if tt.status != 0 {
+				requireStatus(t, err, tt.status)
+				if len(repo.media) != 0 || len(repo.blobs) != 0 {
+					t.Errorf("rejected upload was stored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			if media.ContentType != tt.wantType || media.Size != int64(len(tt.data)) {
+				t.Errorf("stored %s of %d bytes, want %s of %d", media.ContentType, media.Size, tt.wantType, len(tt.data))
+			}
+			if media.Filename == nil || *media.Filename != tt.filename {
+				t.Errorf("filename = %v, want %q without directories", media.Filename, tt.filename)
+			}
+		})
+	}
+}
+
+func TestUploadDeduplicatesByHash(t *testing.T) {
+	svc, repo, store := newTestService(t)
+	data := testPNG(t, 100)
+
+	first, err := svc.Upload(context.Background(), 7, nil, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("first Upload() error = %v", err)
+	}
+	second, err := svc.Upload(context.Background(), 8, nil, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("second Upload() error = %v", err)
+	}
+	other, err := svc.Upload(context.Background(), 7, nil, bytes.NewReader(testPNG(t, 200)))
+	if err != nil {
+		t.Fatalf("third Upload() error = %v", err)
+	}
+
+	if first.ID == second.ID || second.OwnerID != 8 {
+		t.Errorf("duplicate upload did not get its own media item: %+v", second)
+	}
+	if first.StorageKey != second.StorageKey || first.StorageKey == other.StorageKey {
+		t.Errorf("storage keys %q, %q, %q; want identical bytes to share a blob", first.StorageKey, second.StorageKey, other.StorageKey)
+	}
+	if store.puts != 2 || len(repo.blobs) != 2 {
+		t.Errorf("stored %d blobs in %d writes, want 2", len(repo.blobs), store.puts)
+	}
+
+	rc, err := store.Get(context.Background(), second.StorageKey)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+	if got, _ := io.ReadAll(rc); !bytes.Equal(got, data) {
+		t.Error("shared blob does not hold the uploaded bytes")
+	}
+}
+
+func TestWriteChunk(t *testing.T) {
+	data := testPNG(t, 300)
+
+	type chunk struct {
+		start, end int64
+		total      int64 // defaults to the upload's size
+		status     int
+	}
+	tests := []struct {
+		name   string
+		chunks []chunk
+	}{
+		{name: "in order", chunks: []chunk{{start: 0, end: 100}, {start: 100, end: 250}, {start: 250, end: 300}}},
+		{name: "skipping ahead", chunks: []chunk{{start: 0, end: 100}, {start: 200, end: 300, status: http.StatusConflict}, {start: 100, end: 300}}},
+		{name: "resent", chunks: []chunk{{start: 0, end: 100}, {start: 0, end: 100, status: http.StatusConflict}, {start: 100, end: 300}}},
+		{name: "past the end", chunks: []chunk{{start: 0, end: 200}, {start: 200, end: 301, status: http.StatusBadRequest}, {start: 200, end: 300}}},
+		{name: "different total", chunks: []chunk{{start: 0, end: 100, total: 301, status: http.StatusBadRequest}, {start: 0, end: 300}}},
+		{name: "empty chunk", chunks: []chunk{{start: 0, end: 0, status: http.StatusBadRequest}, {start: 0, end: 300}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newTestService(t)
+			ctx := context.Background()
+			upload, err := svc.CreateUpload(ctx, 7, &CreateUploadRequest{Size: int64(len(data))})
+			if err != nil {
+				t.Fatalf("CreateUpload() error = %v", err)
+			}
+
+			for _, c := range tt.chunks {
+				total := c.total
+				if total == 0 {
+					total = int64(len(data))
+				}
+				body := bytes.NewReader(data[c.start:min(c.end, int64(len(data)))])
+				got, err := svc.WriteChunk(ctx, 7, upload.ID, c.start, total, body, c.end-c.start)
+				if c.status != 0 {
+					requireStatus(t, err, c.status)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("WriteChunk(%d-%d) error = %v", c.start, c.end, err)
+				}
+				if got.Received != c.end {
+					t.Errorf("received %d after chunk %d-%d", got.Received, c.start, c.end)
+				}
+			}
+
+			media, err := svc.CompleteUpload(ctx, 7, upload.ID)
+			if err != nil {
+				t.Fatalf("CompleteUpload() error = %v", err)
+			}
+			rc, err := svc.store.Get(ctx, media.StorageKey)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer func() { _ = rc.Close() }()
+			if got, _ := io.ReadAll(rc); !bytes.Equal(got, data) {
+				t.Error("assembled upload does not match the chunks sent")
+			}
+		})
+	}
+}
+
+func TestChunkedUploadLimits(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.CreateUpload(ctx, 7, &CreateUploadRequest{Size: 4<<10 + 1})
+	requireStatus(t, err, http.StatusRequestEntityTooLarge)
+
+	// A chunked upload may be declared up to the video limit, so the image limit is
+	// only enforced once the bytes are known to be an image
+	data := testPNG(t, 2<<10)
+	upload, err := svc.CreateUpload(ctx, 7, &CreateUploadRequest{Size: int64(len(data))})
+	if err != nil {
+		t.Fatalf("CreateUpload() error = %v", err)
+	}
+	if _, err := svc.WriteChunk(ctx, 7, upload.ID, 0, int64(len(data)), bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("WriteChunk() error = %v", err)
+	}
+	_, err = svc.CompleteUpload(ctx, 7, upload.ID)
+	requireStatus(t, err, http.StatusRequestEntityTooLarge)
+
+	_, err = svc.CompleteUpload(ctx, 8, upload.ID)
+	requireStatus(t, err, http.StatusNotFound)
+
+	short, err := svc.CreateUpload(ctx, 7, &CreateUploadRequest{Size: 100})
+	if err != nil {
+		t.Fatalf("CreateUpload() error = %v", err)
+	}
+	_, err = svc.WriteChunk(ctx, 7, short.ID, 0, 100, strings.NewReader("only a few bytes"), 100)
+	requireStatus(t, err, http.StatusBadRequest)
+	_, err = svc.CompleteUpload(ctx, 7, short.ID)
+	requireStatus(t, err, http.StatusConflict)
+}
@@ -0,0 +1,28 @@
+package mention
+
+// Source types that can contain mentions
+const (
+	SourcePost    = "post"
+	SourceComment = "comment"
+)
+
+// EntityTypeMention identifies mention entities in rendered content
+const EntityTypeMention = "mention"
+
+// Entity represents a resolved mention that clients render as a profile link.
+// Start and End are character offsets into the content body; End is exclusive.
+type Entity struct {
+	Type     string `json:"type"`
+	UserID   int    `json:"user_id" db:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start" db:"start_offset"`
+	End      int    `json:"end" db:"end_offset"`
+}
+
+// Event describes a user being mentioned, delivered to the Notifier
+type Event struct {
+	SourceType string
+	SourceID   int64
+	AuthorID   int
+	UserID     int
+}
@@ -0,0 +1,25 @@
+package mention
+
+import (
+	"context"
+	"learning/internal/notification"
+)
+
+// EmitterNotifier delivers mention notifications through a notification.Emitter
+type EmitterNotifier struct {
+	Emitter notification.Emitter
+}
+
+// Ensure EmitterNotifier implements Notifier
+var _ Notifier = EmitterNotifier{}
+
+// NotifyMention emits a mention notification for the mentioned user
+func (n EmitterNotifier) NotifyMention(ctx context.Context, event Event) error {
+	return n.Emitter.Emit(ctx, notification.Event{
+		Type:        notification.TypeMention,
+		RecipientID: event.UserID,
+		ActorID:     event.AuthorID,
+		SubjectType: event.SourceType,
+		SubjectID:   event.SourceID,
+	})
+}
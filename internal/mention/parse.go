@@ -0,0 +1,82 @@
+package mention
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxUsernameLength mirrors the users.username column width
+const maxUsernameLength = 50
+
+// Candidate is an @username occurrence found in text before resolution.
+// Start and End are character (rune) offsets; End is exclusive and covers the @ sign.
+type Candidate struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// Parse returns every @username occurrence in text in order of appearance
+func Parse(text string) []Candidate {
+	var candidates []Candidate
+
+	prev := ' '
+	pos := 0 // rune offset of the current byte index
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '@' || isUsernameRune(prev) {
+			prev = r
+			i += size
+			pos++
+			continue
+		}
+
+		// Consume the username following the @ sign
+		start := i + size
+		end := start
+		runes := 0
+		for end < len(text) && runes < maxUsernameLength {
+			ur, usize := utf8.DecodeRuneInString(text[end:])
+			if !isUsernameRune(ur) {
+				break
+			}
+			end += usize
+			runes++
+		}
+
+		// A trailing period ends the sentence rather than the username
+		username := strings.TrimRight(text[start:end], ".")
+		runes -= len(text[start:end]) - len(username)
+		end = start + len(username)
+
+		if username != "" {
+			candidates = append(candidates, Candidate{
+				Username: username,
+				Start:    pos,
+				End:      pos + 1 + runes,
+			})
+			prev, _ = utf8.DecodeLastRuneInString(username)
+		} else {
+			prev = r
+		}
+
+		pos += 1 + runes
+		i = end
+	}
+
+	return candidates
+}
+
+// isUsernameRune reports whether r may appear inside a mentioned username
+func isUsernameRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// usernameKey returns the form mentioned usernames are matched by: NFKC like stored
+// usernames, and lowercased like the unique index on lower(username)
+func usernameKey(username string) string {
+	return strings.ToLower(norm.NFKC.String(username))
+}
@@ -0,0 +1,66 @@
+package mention
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	long := strings.Repeat("a", maxUsernameLength)
+
+	tests := []struct {
+		name string
+		text string
+		want []Candidate
+	}{
+		{name: "none", text: "hello there", want: nil},
+		{name: "start of text", text: "@bob hi", want: []Candidate{{"bob", 0, 4}}},
+		{name: "after space", text: "hi @bob", want: []Candidate{{"bob", 3, 7}}},
+		{name: "several", text: "@a and @b", want: []Candidate{{"a", 0, 2}, {"b", 7, 9}}},
+		{name: "punctuation around", text: "(@bob), @al!", want: []Candidate{{"bob", 1, 5}, {"al", 8, 11}}},
+		{name: "email address", text: "mail a@b.com", want: nil},
+		{name: "dots inside", text: "@a.b_c", want: []Candidate{{"a.b_c", 0, 6}}},
+		{name: "trailing period", text: "thanks @bob.", want: []Candidate{{"bob", 7, 11}}},
+		{name: "offsets after trimmed periods", text: "@bob.. @al", want: []Candidate{{"bob", 0, 4}, {"al", 7, 10}}},
+		{name: "bare at signs", text: "@ @@bob", want: []Candidate{{"bob", 3, 7}}},
+		{name: "multibyte before", text: "héllo @bob", want: []Candidate{{"bob", 6, 10}}},
+		{name: "emoji before", text: "👋 @bob", want: []Candidate{{"bob", 2, 6}}},
+		{name: "multibyte username", text: "@日本 @bob", want: []Candidate{{"日本", 0, 3}, {"bob", 4, 8}}},
+		{name: "longest username", text: "@" + long + " @bob", want: []Candidate{{long, 0, 51}, {"bob", 52, 56}}},
+		{name: "overlong username truncated", text: "@" + long + "xyz", want: []Candidate{{long, 0, 51}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+
+			// Clients slice the body by these offsets, so each span must cover the @username
+			runes := []rune(tt.text)
+			for _, c := range got {
+				if span := string(runes[c.Start:c.End]); span != "@"+c.Username {
+					t.Errorf("span [%d:%d] = %q, want %q", c.Start, c.End, span, "@"+c.Username)
+				}
+			}
+		})
+	}
+}
+
+func TestUsernameKey(t *testing.T) {
+	tests := map[string]string{
+		"bob":        "bob",
+		"Bob":        "bob",
+		"ＢＯＢ":        "bob",
+		"Cafe\u0301": "caf\u00e9",
+		"Дима":       "дима",
+	}
+
+	for username, want := range tests {
+		if got := usernameKey(username); got != want {
+			t.Errorf("usernameKey(%q) = %q, want %q", username, got, want)
+		}
+	}
+}
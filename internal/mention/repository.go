@@ -0,0 +1,134 @@
+package mention
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for mentions
+type RepositoryInterface interface {
+	ResolveUsernames(ctx context.Context, usernames []string) (map[string]int, error)
+	ReplaceMentions(ctx context.Context, sourceType string, sourceID int64, authorID int, entities []Entity) ([]int, error)
+	GetMentions(ctx context.Context, sourceType string, sourceID int64) ([]Entity, error)
+}
+
+// NewRepository creates a new mention repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// ResolveUsernames maps each username that belongs to an active user to its user ID.
+// Usernames match case-insensitively and the map is keyed by usernameKey.
+func (r *Repository) ResolveUsernames(ctx context.Context, usernames []string) (map[string]int, error) {
+	resolved := make(map[string]int, len(usernames))
+	if len(usernames) == 0 {
+		return resolved, nil
+	}
+
+	keys := make([]string, len(usernames))
+	for i, username := range usernames {
+		keys[i] = usernameKey(username)
+	}
+
+	// lower(username) = ANY lets the planner use ux_users_username_lower
+	query := `
+        SELECT id, username
+        FROM users
+        WHERE lower(username) = ANY($1) AND active = true
+    `
+
+	rows, err := r.db.Querier(ctx).Query(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve usernames: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, fmt.Errorf("failed to scan username: %w", err)
+		}
+		resolved[usernameKey(username)] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to resolve usernames: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// ReplaceMentions stores the entities for a piece of content, replacing earlier ones.
+// It returns the user IDs that were mentioned before the replacement.
+func (r *Repository) ReplaceMentions(ctx context.Context, sourceType string, sourceID int64, authorID int, entities []Entity) ([]int, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	rows, err := tx.Query(ctx, `
+        DELETE FROM mentions
+        WHERE source_type = $1 AND source_id = $2
+        RETURNING user_id
+    `, sourceType, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear mentions: %w", err)
+	}
+	previous, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan previous mentions: %w", err)
+	}
+
+	for _, e := range entities {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO mentions (source_type, source_id, author_id, user_id, start_offset, end_offset)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, sourceType, sourceID, authorID, e.UserID, e.Start, e.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store mention: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit mentions: %w", err)
+	}
+	return previous, nil
+}
+
+// GetMentions returns the stored entities for a piece of content ordered by position
+func (r *Repository) GetMentions(ctx context.Context, sourceType string, sourceID int64) ([]Entity, error) {
+	query := `
+        SELECT m.user_id, u.username, m.start_offset, m.end_offset
+        FROM mentions m
+        JOIN users u ON u.id = m.user_id
+        WHERE m.source_type = $1 AND m.source_id = $2
+        ORDER BY m.start_offset
+    `
+
+	rows, err := r.db.Querier(ctx).Query(ctx, query, sourceType, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mentions: %w", err)
+	}
+
+	entities, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Entity, error) {
+		e := Entity{Type: EntityTypeMention}
+		err := row.Scan(&e.UserID, &e.Username, &e.Start, &e.End)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan mentions: %w", err)
+	}
+
+	return entities, nil
+}
@@ -0,0 +1,10 @@
+package mention
+
+import "learning/internal/database"
+
+// New composes repository -> service for content producers.
+// Mentions have no routes of their own; entities are returned with the content.
+func New(db *database.DataBase, notifier Notifier, blocks BlockChecker) *Service {
+	repo := NewRepository(db)
+	return NewService(repo, notifier, blocks)
+}
@@ -0,0 +1,139 @@
+package mention
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/logging"
+)
+
+// Notifier delivers mention notifications to mentioned users
+type Notifier interface {
+	NotifyMention(ctx context.Context, event Event) error
+}
+
+// BlockChecker reports whether either user has blocked the other
+type BlockChecker interface {
+	IsBlocked(ctx context.Context, userID, otherID int) (bool, error)
+}
+
+// ServiceInterface defines business operations for mentions
+type ServiceInterface interface {
+	ProcessContent(ctx context.Context, sourceType string, sourceID int64, authorID int, body string) ([]Entity, error)
+	GetEntities(ctx context.Context, sourceType string, sourceID int64) ([]Entity, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	notifier   Notifier
+	blocks     BlockChecker
+}
+
+// NewService creates a new mention service. A nil notifier disables notifications;
+// blocks is required so blocked users are never linked or notified.
+func NewService(repository RepositoryInterface, notifier Notifier, blocks BlockChecker) *Service {
+	return &Service{
+		repository: repository,
+		notifier:   notifier,
+		blocks:     blocks,
+	}
+}
+
+// ProcessContent resolves the mentions in a post or comment body, stores them and
+// notifies newly mentioned users. Mentions of unknown, inactive or blocked users are
+// left out of the returned entities so clients render them as plain text.
+func (s *Service) ProcessContent(ctx context.Context, sourceType string, sourceID int64, authorID int, body string) ([]Entity, error) {
+	if sourceType != SourcePost && sourceType != SourceComment {
+		return nil, fmt.Errorf("invalid mention source type %q", sourceType)
+	}
+
+	candidates := Parse(body)
+	usernames := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		usernames = append(usernames, c.Username)
+	}
+
+	resolved, err := s.repository.ResolveUsernames(ctx, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("error while resolving mentions %w", err)
+	}
+
+	entities := []Entity{}
+	blocked := make(map[int]bool)
+	for _, c := range candidates {
+		userID, ok := resolved[usernameKey(c.Username)]
+		if !ok {
+			continue
+		}
+
+		isBlocked, checked := blocked[userID]
+		if !checked {
+			isBlocked, err = s.blocks.IsBlocked(ctx, authorID, userID)
+			if err != nil {
+				return nil, fmt.Errorf("error while checking blocks %w", err)
+			}
+			blocked[userID] = isBlocked
+		}
+		if isBlocked {
+			continue
+		}
+
+		entities = append(entities, Entity{
+			Type:     EntityTypeMention,
+			UserID:   userID,
+			Username: c.Username,
+			Start:    c.Start,
+			End:      c.End,
+		})
+	}
+
+	previous, err := s.repository.ReplaceMentions(ctx, sourceType, sourceID, authorID, entities)
+	if err != nil {
+		return nil, fmt.Errorf("error while storing mentions %w", err)
+	}
+
+	s.notify(ctx, sourceType, sourceID, authorID, entities, previous)
+	return entities, nil
+}
+
+// GetEntities returns the stored mention entities for a post or comment
+func (s *Service) GetEntities(ctx context.Context, sourceType string, sourceID int64) ([]Entity, error) {
+	entities, err := s.repository.GetMentions(ctx, sourceType, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting mentions %w", err)
+	}
+	return entities, nil
+}
+
+// notify sends one notification per user mentioned for the first time in this content.
+// Failures are logged rather than returned so they never fail the content write.
+func (s *Service) notify(ctx context.Context, sourceType string, sourceID int64, authorID int, entities []Entity, previous []int) {
+	if s.notifier == nil {
+		return
+	}
+
+	skip := make(map[int]bool, len(previous)+1)
+	skip[authorID] = true
+	for _, id := range previous {
+		skip[id] = true
+	}
+
+	for _, e := range entities {
+		if skip[e.UserID] {
+			continue
+		}
+		skip[e.UserID] = true
+
+		err := s.notifier.NotifyMention(ctx, Event{
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			AuthorID:   authorID,
+			UserID:     e.UserID,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to notify mention", "user_id", e.UserID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles messaging-related HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new messaging handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers messaging routes; all of them require an authenticated user
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	cr := r.PathPrefix("/conversations").Subrouter()
+	cr.Use(middleware.RequireAuth)
+
+	cr.HandleFunc("", h.CreateConversation).Methods(http.MethodPost)
+	cr.HandleFunc("", h.ListConversations).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}", h.GetConversation).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}/messages", h.ListMessages).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}/messages", h.SendMessage).Methods(http.MethodPost)
+	cr.HandleFunc("/{id}/messages/{messageId}", h.EditMessage).Methods(http.MethodPatch)
+	cr.HandleFunc("/{id}/messages/{messageId}", h.DeleteMessage).Methods(http.MethodDelete)
+	cr.HandleFunc("/{id}/read", h.MarkRead).Methods(http.MethodPost)
+
+	sr := r.PathPrefix("/messaging/settings").Subrouter()
+	sr.Use(middleware.RequireAuth)
+
+	sr.HandleFunc("", h.GetSettings).Methods(http.MethodGet)
+	sr.HandleFunc("", h.UpdateSettings).Methods(http.MethodPut)
+}
+
+// CreateConversation handles starting a direct or group conversation
+func (h *Handler) CreateConversation(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req CreateConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	conv, err := h.service.CreateConversation(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, conv)
+}
+
+// ListConversations handles listing the caller's conversations
+func (h *Handler) ListConversations(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summaries, err := h.service.ListConversations(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if summaries == nil {
+		summaries = []ConversationSummary{}
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, summaries)
+}
+
+// GetConversation handles retrieval of a single conversation
+func (h *Handler) GetConversation(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conversationID, ok := parseID(w, mux.Vars(r)["id"], "invalid conversation id")
+	if !ok {
+		return
+	}
+
+	conv, err := h.service.GetConversation(r.Context(), userID, conversationID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, conv)
+}
+
+// ListMessages handles cursor-paginated message history
+func (h *Handler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conversationID, ok := parseID(w, mux.Vars(r)["id"], "invalid conversation id")
+	if !ok {
+		return
+	}
+
+	limit, _, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var before int64
+	if v := r.URL.Query().Get("before"); v != "" {
+		if before, ok = parseID(w, v, "invalid cursor"); !ok {
+			return
+		}
+	}
+
+	page, err := h.service.ListMessages(r.Context(), userID, conversationID, before, limit)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, page)
+}
+
+// SendMessage handles posting a message to a conversation
+func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conversationID, ok := parseID(w, mux.Vars(r)["id"], "invalid conversation id")
+	if !ok {
+		return
+	}
+
+	var req SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	message, err := h.service.SendMessage(r.Context(), userID, conversationID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, message)
+}
+
+// EditMessage handles editing one of the caller's messages
+func (h *Handler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conversationID, messageID, ok := parseMessagePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	message, err := h.service.EditMessage(r.Context(), userID, conversationID, messageID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, message)
+}
+
+// DeleteMessage handles deleting one of the caller's messages for everyone
+func (h *Handler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conversationID, messageID, ok := parseMessagePath(w, r)
+	if !ok {
+		return
+	}
+
+	message, err := h.service.DeleteMessage(r.Context(), userID, conversationID, messageID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, message)
+}
+
+// MarkRead handles advancing the caller's read cursor
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conversationID, ok := parseID(w, mux.Vars(r)["id"], "invalid conversation id")
+	if !ok {
+		return
+	}
+
+	var req MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), userID, conversationID, &req); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "conversation marked as read")
+}
+
+// GetSettings handles retrieval of the caller's messaging settings
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	settings, err := h.service.GetSettings(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, settings)
+}
+
+// UpdateSettings handles changing the caller's messaging settings
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req SettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, settings)
+}
+
+// parseID parses a positive int64 ID, writing a 400 response when it is invalid
+func parseID(w http.ResponseWriter, value, message string) (int64, bool) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return id, true
+}
+
+// parseMessagePath parses the conversation and message IDs from the route
+func parseMessagePath(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	vars := mux.Vars(r)
+	conversationID, ok := parseID(w, vars["id"], "invalid conversation id")
+	if !ok {
+		return 0, 0, false
+	}
+	messageID, ok := parseID(w, vars["messageId"], "invalid message id")
+	if !ok {
+		return 0, 0, false
+	}
+	return conversationID, messageID, true
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
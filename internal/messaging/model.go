@@ -0,0 +1,104 @@
+package messaging
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Conversation kinds
+const (
+	KindDirect = "direct"
+	KindGroup  = "group"
+)
+
+// Member roles
+const (
+	RoleOwner  = "owner"
+	RoleMember = "member"
+)
+
+// Who may start a conversation with a user
+const (
+	AllowFromEveryone  = "everyone"
+	AllowFromFollowers = "followers"
+)
+
+// Conversation represents a 1:1 or group conversation
+type Conversation struct {
+	ID        int64     `json:"id" db:"id"`
+	Kind      string    `json:"kind" db:"kind"`
+	Title     *string   `json:"title,omitempty" db:"title"`
+	CreatedBy int       `json:"created_by" db:"created_by"`
+	Members   []Member  `json:"members"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Member represents a user's membership and read cursor in a conversation
+type Member struct {
+	UserID            int       `json:"user_id" db:"user_id"`
+	Username          string    `json:"username"`
+	Role              string    `json:"role" db:"role"`
+	LastReadMessageID int64     `json:"last_read_message_id" db:"last_read_message_id"`
+	JoinedAt          time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// ConversationSummary is a conversation as listed in a user's inbox
+type ConversationSummary struct {
+	Conversation
+	LastMessage *Message `json:"last_message,omitempty"`
+	UnreadCount int      `json:"unread_count"`
+}
+
+// Message represents a message in a conversation
+type Message struct {
+	ID             int64      `json:"id" db:"id"`
+	ConversationID int64      `json:"conversation_id" db:"conversation_id"`
+	SenderID       int        `json:"sender_id" db:"sender_id"`
+	Body           string     `json:"body" db:"body"`
+	Deleted        bool       `json:"deleted"`
+	EditedAt       *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MessagePage is a page of message history with the cursor for the next (older) page
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor *int64    `json:"next_cursor,omitempty"`
+}
+
+// CreateConversationRequest represents the request payload for starting a conversation.
+// A single member starts (or returns the existing) direct conversation.
+type CreateConversationRequest struct {
+	MemberIDs []int   `json:"member_ids" validate:"required,min=1,max=49,dive,gt=0"`
+	Title     *string `json:"title,omitempty" validate:"omitempty,max=100"`
+}
+
+// SendMessageRequest represents the request payload for sending or editing a message
+type SendMessageRequest struct {
+	Body string `json:"body" validate:"required,max=4000"`
+}
+
+// MarkReadRequest represents the request payload for advancing a read cursor
+type MarkReadRequest struct {
+	MessageID int64 `json:"message_id" validate:"required,gt=0"`
+}
+
+// SettingsRequest represents the request payload for updating messaging settings
+type SettingsRequest struct {
+	AllowFrom string `json:"allow_from" validate:"required,oneof=everyone followers"`
+}
+
+// Settings represents a user's messaging settings
+type Settings struct {
+	AllowFrom string `json:"allow_from"`
+}
+
+// directKey identifies the single direct conversation between two users
+func directKey(a, b int) string {
+	ids := []int{a, b}
+	sort.Ints(ids)
+	return fmt.Sprintf("%d:%d", ids[0], ids[1])
+}
@@ -0,0 +1,401 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// errNotFound is returned when a conversation, member or message does not exist
+var errNotFound = errors.New("not found")
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for messaging
+type RepositoryInterface interface {
+	CountActiveUsers(ctx context.Context, userIDs []int) (int, error)
+	FindDirectConversation(ctx context.Context, key string) (*Conversation, error)
+	CreateConversation(ctx context.Context, conv *Conversation, directKey *string, memberIDs []int) (*Conversation, error)
+	GetConversation(ctx context.Context, id int64) (*Conversation, error)
+	IsMember(ctx context.Context, conversationID int64, userID int) (bool, error)
+	ListConversations(ctx context.Context, userID, limit, offset int) ([]ConversationSummary, error)
+	ListMessages(ctx context.Context, conversationID, before int64, limit int) ([]Message, error)
+	GetMessage(ctx context.Context, conversationID, messageID int64) (*Message, error)
+	GetMessageByID(ctx context.Context, messageID int64) (*Message, error)
+	CreateMessage(ctx context.Context, conversationID int64, senderID int, body string) (*Message, error)
+	UpdateMessageBody(ctx context.Context, messageID int64, body string) (*Message, error)
+	DeleteMessage(ctx context.Context, messageID int64) (*Message, error)
+	MarkRead(ctx context.Context, conversationID int64, userID int, messageID int64) (int64, error)
+	GetAllowFrom(ctx context.Context, userID int) (string, error)
+	SetAllowFrom(ctx context.Context, userID int, allowFrom string) error
+}
+
+// NewRepository creates a new messaging repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+const messageColumns = `id, conversation_id, sender_id, body, edited_at, deleted_at, created_at`
+
+// scanMessageFromRow scans a database row into a Message model
+func scanMessageFromRow(row pgx.Row) (*Message, error) {
+	var m Message
+
+	err := row.Scan(
+		&m.ID,
+		&m.ConversationID,
+		&m.SenderID,
+		&m.Body,
+		&m.EditedAt,
+		&m.DeletedAt,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to scan message: %w", err)
+	}
+
+	m.Deleted = m.DeletedAt != nil
+	return &m, nil
+}
+
+// CountActiveUsers returns how many of the given user IDs belong to active users
+func (r *Repository) CountActiveUsers(ctx context.Context, userIDs []int) (int, error) {
+	var count int
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FROM users WHERE id = ANY($1) AND active = true
+    `, userIDs).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// FindDirectConversation returns the direct conversation with the given key
+func (r *Repository) FindDirectConversation(ctx context.Context, key string) (*Conversation, error) {
+	var id int64
+	err := r.db.Querier(ctx).QueryRow(ctx, `SELECT id FROM conversations WHERE direct_key = $1`, key).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to find direct conversation: %w", err)
+	}
+	return r.GetConversation(ctx, id)
+}
+
+// CreateConversation creates a conversation and its members. The creator becomes the owner.
+func (r *Repository) CreateConversation(ctx context.Context, conv *Conversation, directKey *string, memberIDs []int) (*Conversation, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	now := time.Now()
+	var id int64
+	err = tx.QueryRow(ctx, `
+        INSERT INTO conversations (kind, title, created_by, direct_key, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (direct_key) DO NOTHING
+        RETURNING id
+    `, conv.Kind, conv.Title, conv.CreatedBy, directKey, now).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) && directKey != nil {
+		// Another request created the same direct conversation concurrently
+		return r.FindDirectConversation(ctx, *directKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	for _, userID := range memberIDs {
+		role := RoleMember
+		if userID == conv.CreatedBy {
+			role = RoleOwner
+		}
+		_, err := tx.Exec(ctx, `
+            INSERT INTO conversation_members (conversation_id, user_id, role, joined_at)
+            VALUES ($1, $2, $3, $4)
+        `, id, userID, role, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add conversation member: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit conversation: %w", err)
+	}
+	return r.GetConversation(ctx, id)
+}
+
+// GetConversation returns a conversation with its members
+func (r *Repository) GetConversation(ctx context.Context, id int64) (*Conversation, error) {
+	var c Conversation
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT id, kind, title, created_by, created_at, updated_at
+        FROM conversations WHERE id = $1
+    `, id).Scan(&c.ID, &c.Kind, &c.Title, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	rows, err := r.db.Querier(ctx).Query(ctx, `
+        SELECT cm.user_id, u.username, cm.role, cm.last_read_message_id, cm.joined_at
+        FROM conversation_members cm
+        JOIN users u ON u.id = cm.user_id
+        WHERE cm.conversation_id = $1
+        ORDER BY cm.joined_at, cm.user_id
+    `, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation members: %w", err)
+	}
+
+	c.Members, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Member, error) {
+		var m Member
+		err := row.Scan(&m.UserID, &m.Username, &m.Role, &m.LastReadMessageID, &m.JoinedAt)
+		return m, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan conversation members: %w", err)
+	}
+
+	return &c, nil
+}
+
+// IsMember reports whether the user belongs to the conversation
+func (r *Repository) IsMember(ctx context.Context, conversationID int64, userID int) (bool, error) {
+	var exists bool
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM conversation_members WHERE conversation_id = $1 AND user_id = $2
+        )
+    `, conversationID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check conversation membership: %w", err)
+	}
+	return exists, nil
+}
+
+// ListConversations returns the user's conversations with last message and unread count,
+// most recently active first
+func (r *Repository) ListConversations(ctx context.Context, userID, limit, offset int) ([]ConversationSummary, error) {
+	query := `
+        SELECT c.id, c.kind, c.title, c.created_by, c.created_at, c.updated_at,
+               (SELECT COUNT(*) FROM messages m
+                WHERE m.conversation_id = c.id AND m.id > cm.last_read_message_id
+                  AND m.sender_id <> $1 AND m.deleted_at IS NULL) AS unread,
+               lm.id, lm.conversation_id, lm.sender_id, lm.body, lm.edited_at, lm.deleted_at, lm.created_at
+        FROM conversation_members cm
+        JOIN conversations c ON c.id = cm.conversation_id
+        LEFT JOIN LATERAL (
+            SELECT ` + messageColumns + ` FROM messages
+            WHERE conversation_id = c.id
+            ORDER BY id DESC LIMIT 1
+        ) lm ON true
+        WHERE cm.user_id = $1
+        ORDER BY c.updated_at DESC, c.id DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	summaries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ConversationSummary, error) {
+		var s ConversationSummary
+		var lastID, lastConversationID *int64
+		var lastSenderID *int
+		var lastBody *string
+		var lastCreatedAt *time.Time
+		var lastEditedAt, lastDeletedAt *time.Time
+
+		err := row.Scan(
+			&s.ID, &s.Kind, &s.Title, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+			&s.UnreadCount,
+			&lastID, &lastConversationID, &lastSenderID, &lastBody, &lastEditedAt, &lastDeletedAt, &lastCreatedAt,
+		)
+		if err != nil {
+			return s, err
+		}
+
+		if lastID != nil {
+			s.LastMessage = &Message{
+				ID:             *lastID,
+				ConversationID: *lastConversationID,
+				SenderID:       *lastSenderID,
+				Body:           *lastBody,
+				Deleted:        lastDeletedAt != nil,
+				EditedAt:       lastEditedAt,
+				DeletedAt:      lastDeletedAt,
+				CreatedAt:      *lastCreatedAt,
+			}
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan conversations: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// ListMessages returns up to limit messages older than the before cursor, newest first.
+// A zero cursor starts from the latest message.
+func (r *Repository) ListMessages(ctx context.Context, conversationID, before int64, limit int) ([]Message, error) {
+	query := `
+        SELECT ` + messageColumns + `
+        FROM messages
+        WHERE conversation_id = $1 AND ($2 = 0 OR id < $2)
+        ORDER BY id DESC
+        LIMIT $3
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, conversationID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Message, error) {
+		m, err := scanMessageFromRow(row)
+		if err != nil {
+			return Message{}, err
+		}
+		return *m, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetMessage returns a message from the conversation
+func (r *Repository) GetMessage(ctx context.Context, conversationID, messageID int64) (*Message, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT `+messageColumns+` FROM messages WHERE id = $1 AND conversation_id = $2
+    `, messageID, conversationID)
+	return scanMessageFromRow(row)
+}
+
+// GetMessageByID returns a message from any conversation
+func (r *Repository) GetMessageByID(ctx context.Context, messageID int64) (*Message, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT `+messageColumns+` FROM messages WHERE id = $1
+    `, messageID)
+	return scanMessageFromRow(row)
+}
+
+// CreateMessage stores a message, bumps the conversation and advances the sender's read cursor
+func (r *Repository) CreateMessage(ctx context.Context, conversationID int64, senderID int, body string) (*Message, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	now := time.Now()
+	row := tx.QueryRow(ctx, `
+        INSERT INTO messages (conversation_id, sender_id, body, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING `+messageColumns, conversationID, senderID, body, now)
+	message, err := scanMessageFromRow(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE conversations SET updated_at = $2 WHERE id = $1`, conversationID, now); err != nil {
+		return nil, fmt.Errorf("failed to update conversation: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE conversation_members SET last_read_message_id = $3
+        WHERE conversation_id = $1 AND user_id = $2
+    `, conversationID, senderID, message.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update read cursor: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit message: %w", err)
+	}
+	return message, nil
+}
+
+// UpdateMessageBody replaces the body of a message that has not been deleted
+func (r *Repository) UpdateMessageBody(ctx context.Context, messageID int64, body string) (*Message, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        UPDATE messages SET body = $2, edited_at = $3
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING `+messageColumns, messageID, body, time.Now())
+	return scanMessageFromRow(row)
+}
+
+// DeleteMessage removes a message's content for every member, keeping a tombstone
+func (r *Repository) DeleteMessage(ctx context.Context, messageID int64) (*Message, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        UPDATE messages SET body = '', deleted_at = COALESCE(deleted_at, $2)
+        WHERE id = $1
+        RETURNING `+messageColumns, messageID, time.Now())
+	return scanMessageFromRow(row)
+}
+
+// MarkRead advances the member's read cursor (it never moves backwards) to a message in the
+// conversation and returns it. It fails with errNotFound when the user is not a member or
+// the message is not in the conversation.
+func (r *Repository) MarkRead(ctx context.Context, conversationID int64, userID int, messageID int64) (int64, error) {
+	var cursor int64
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        UPDATE conversation_members
+        SET last_read_message_id = GREATEST(last_read_message_id, $3)
+        WHERE conversation_id = $1 AND user_id = $2
+          AND EXISTS (SELECT 1 FROM messages WHERE id = $3 AND conversation_id = $1)
+        RETURNING last_read_message_id
+    `, conversationID, userID, messageID).Scan(&cursor)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, errNotFound
+		}
+		return 0, fmt.Errorf("failed to mark conversation read: %w", err)
+	}
+	return cursor, nil
+}
+
+// GetAllowFrom returns who may start direct conversations with the user
+func (r *Repository) GetAllowFrom(ctx context.Context, userID int) (string, error) {
+	var allowFrom string
+	err := r.db.Querier(ctx).QueryRow(ctx, `SELECT allow_from FROM messaging_settings WHERE user_id = $1`, userID).Scan(&allowFrom)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AllowFromEveryone, nil
+		}
+		return "", fmt.Errorf("failed to get messaging settings: %w", err)
+	}
+	return allowFrom, nil
+}
+
+// SetAllowFrom stores who may start direct conversations with the user
+func (r *Repository) SetAllowFrom(ctx context.Context, userID int, allowFrom string) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        INSERT INTO messaging_settings (user_id, allow_from, updated_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE SET allow_from = EXCLUDED.allow_from, updated_at = EXCLUDED.updated_at
+    `, userID, allowFrom, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update messaging settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Real-time events broadcast on a conversation's topic
+const (
+	EventMessageCreated = "message.created"
+	EventMessageUpdated = "message.updated"
+	EventMessageDeleted = "message.deleted"
+	EventReadCursor     = "conversation.read"
+)
+
+// Relationships answers social-graph questions that gate direct messages
+type Relationships interface {
+	IsBlocked(ctx context.Context, userID, otherID int) (bool, error)
+	IsFollower(ctx context.Context, followerID, followeeID int) (bool, error)
+}
+
+// Broadcaster pushes events to clients subscribed to a topic
+type Broadcaster interface {
+	Broadcast(ctx context.Context, topic, event string, data any) error
+}
+
+// ServiceInterface defines business operations for messaging
+type ServiceInterface interface {
+	CreateConversation(ctx context.Context, userID int, req *CreateConversationRequest) (*Conversation, error)
+	GetConversation(ctx context.Context, userID int, conversationID int64) (*Conversation, error)
+	ListConversations(ctx context.Context, userID, limit, offset int) ([]ConversationSummary, error)
+	ListMessages(ctx context.Context, userID int, conversationID, before int64, limit int) (*MessagePage, error)
+	SendMessage(ctx context.Context, userID int, conversationID int64, req *SendMessageRequest) (*Message, error)
+	EditMessage(ctx context.Context, userID int, conversationID, messageID int64, req *SendMessageRequest) (*Message, error)
+	DeleteMessage(ctx context.Context, userID int, conversationID, messageID int64) (*Message, error)
+	MarkRead(ctx context.Context, userID int, conversationID int64, req *MarkReadRequest) error
+	GetSettings(ctx context.Context, userID int) (*Settings, error)
+	UpdateSettings(ctx context.Context, userID int, req *SettingsRequest) (*Settings, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository    RepositoryInterface
+	relationships Relationships
+	broadcaster   Broadcaster
+	validator     *validator.Validate
+}
+
+// NewService creates a new messaging service. Relationships is required because block
+// and followers-only checks cannot be skipped; a nil broadcaster disables real-time events.
+func NewService(repository RepositoryInterface, relationships Relationships, broadcaster Broadcaster) *Service {
+	return &Service{
+		repository:    repository,
+		relationships: relationships,
+		broadcaster:   broadcaster,
+		validator:     validator.New(),
+	}
+}
+
+// CreateConversation starts a direct conversation (returning the existing one if any)
+// when a single member is given, or a group conversation otherwise
+func (s *Service) CreateConversation(ctx context.Context, userID int, req *CreateConversationRequest) (*Conversation, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	memberIDs := []int{userID}
+	seen := map[int]bool{userID: true}
+	for _, id := range req.MemberIDs {
+		if !seen[id] {
+			seen[id] = true
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	if len(memberIDs) < 2 {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("conversation needs another member"), http.StatusBadRequest, "conversation needs at least one other member")
+	}
+
+	count, err := s.repository.CountActiveUsers(ctx, memberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error while checking members %w", err)
+	}
+	if count != len(memberIDs) {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("unknown or inactive member"), http.StatusBadRequest, "unknown or inactive member")
+	}
+
+	// Every invited member is checked, so a group cannot be used to reach a user who only
+	// accepts messages from followers
+	for _, id := range memberIDs[1:] {
+		if err := s.checkCanMessage(ctx, userID, id); err != nil {
+			return nil, err
+		}
+	}
+
+	conv := &Conversation{CreatedBy: userID, Kind: KindGroup}
+	var key *string
+	if len(memberIDs) == 2 {
+		conv.Kind = KindDirect
+		k := directKey(memberIDs[0], memberIDs[1])
+		key = &k
+
+		existing, err := s.repository.FindDirectConversation(ctx, k)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, errNotFound) {
+			return nil, fmt.Errorf("error while finding conversation %w", err)
+		}
+	} else if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		conv.Title = &title
+	}
+
+	created, err := s.repository.CreateConversation(ctx, conv, key, memberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating conversation %w", err)
+	}
+	return created, nil
+}
+
+// GetConversation returns a conversation the user belongs to
+func (s *Service) GetConversation(ctx context.Context, userID int, conversationID int64) (*Conversation, error) {
+	if err := s.requireMember(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	conv, err := s.repository.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, s.mapNotFound(err, "conversation not found")
+	}
+	return conv, nil
+}
+
+// ListConversations returns the user's conversations with unread counts
+func (s *Service) ListConversations(ctx context.Context, userID, limit, offset int) ([]ConversationSummary, error) {
+	summaries, err := s.repository.ListConversations(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing conversations %w", err)
+	}
+	return summaries, nil
+}
+
+// ListMessages returns a page of history older than the before cursor
+func (s *Service) ListMessages(ctx context.Context, userID int, conversationID, before int64, limit int) (*MessagePage, error) {
+	if err := s.requireMember(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to learn whether an older page exists
+	messages, err := s.repository.ListMessages(ctx, conversationID, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing messages %w", err)
+	}
+
+	page := &MessagePage{Messages: messages}
+	if len(messages) > limit {
+		page.Messages = messages[:limit]
+		cursor := page.Messages[limit-1].ID
+		page.NextCursor = &cursor
+	}
+	if page.Messages == nil {
+		page.Messages = []Message{}
+	}
+	return page, nil
+}
+
+// SendMessage posts a message to a conversation the user belongs to
+func (s *Service) SendMessage(ctx context.Context, userID int, conversationID int64, req *SendMessageRequest) (*Message, error) {
+	if err := s.validateBody(req); err != nil {
+		return nil, err
+	}
+
+	conv, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are re-checked on every message, not just when the conversation starts,
+	// and in groups a block between the sender and any other member stops the send
+	for _, m := range conv.Members {
+		if m.UserID == userID {
+			continue
+		}
+		if err := s.checkNotBlocked(ctx, userID, m.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	message, err := s.repository.CreateMessage(ctx, conversationID, userID, strings.TrimSpace(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error while sending message %w", err)
+	}
+
+	s.broadcast(ctx, conversationID, EventMessageCreated, message)
+	return message, nil
+}
+
+// EditMessage replaces the body of one of the user's own messages
+func (s *Service) EditMessage(ctx context.Context, userID int, conversationID, messageID int64, req *SendMessageRequest) (*Message, error) {
+	if err := s.validateBody(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.ownMessage(ctx, userID, conversationID, messageID); err != nil {
+		return nil, err
+	}
+
+	message, err := s.repository.UpdateMessageBody(ctx, messageID, strings.TrimSpace(req.Body))
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, apperrors.WrapWithMessage(err, http.StatusConflict, "deleted messages cannot be edited")
+		}
+		return nil, fmt.Errorf("error while editing message %w", err)
+	}
+
+	s.broadcast(ctx, conversationID, EventMessageUpdated, message)
+	return message, nil
+}
+
+// DeleteMessage removes one of the user's own messages for every member
+func (s *Service) DeleteMessage(ctx context.Context, userID int, conversationID, messageID int64) (*Message, error) {
+	if _, err := s.ownMessage(ctx, userID, conversationID, messageID); err != nil {
+		return nil, err
+	}
+
+	message, err := s.repository.DeleteMessage(ctx, messageID)
+	if err != nil {
+		return nil, s.mapNotFound(err, "message not found")
+	}
+
+	s.broadcast(ctx, conversationID, EventMessageDeleted, message)
+	return message, nil
+}
+
+// MarkRead advances the user's read cursor in a conversation to one of its messages
+func (s *Service) MarkRead(ctx context.Context, userID int, conversationID int64, req *MarkReadRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	if err := s.requireMember(ctx, userID, conversationID); err != nil {
+		return err
+	}
+
+	cursor, err := s.repository.MarkRead(ctx, conversationID, userID, req.MessageID)
+	if err != nil {
+		return s.mapNotFound(err, "message not found")
+	}
+
+	s.broadcast(ctx, conversationID, EventReadCursor, map[string]any{
+		"user_id":              userID,
+		"last_read_message_id": cursor,
+	})
+	return nil
+}
+
+// GetSettings returns the user's messaging settings
+func (s *Service) GetSettings(ctx context.Context, userID int) (*Settings, error) {
+	allowFrom, err := s.repository.GetAllowFrom(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting messaging settings %w", err)
+	}
+	return &Settings{AllowFrom: allowFrom}, nil
+}
+
+// UpdateSettings changes who may start direct conversations with the user
+func (s *Service) UpdateSettings(ctx context.Context, userID int, req *SettingsRequest) (*Settings, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	if err := s.repository.SetAllowFrom(ctx, userID, req.AllowFrom); err != nil {
+		return nil, fmt.Errorf("error while updating messaging settings %w", err)
+	}
+	return &Settings{AllowFrom: req.AllowFrom}, nil
+}
+
+// IsMember reports whether the user belongs to the conversation. It lets the
+// realtime gateway authorize conversation topic subscriptions.
+func (s *Service) IsMember(ctx context.Context, conversationID int64, userID int) (bool, error) {
+	return s.repository.IsMember(ctx, conversationID, userID)
+}
+
+// ReportedMessage returns a message the user can see, so it can be reported.
+// Messages outside the user's conversations are reported as not found.
+func (s *Service) ReportedMessage(ctx context.Context, userID int, messageID int64) (*Message, error) {
+	message, err := s.repository.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, s.mapNotFound(err, "message not found")
+	}
+	if err := s.requireMember(ctx, userID, message.ConversationID); err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusNotFound, "message not found")
+	}
+	return message, nil
+}
+
+// RemoveMessage deletes a message for every member on behalf of a moderator
+func (s *Service) RemoveMessage(ctx context.Context, messageID int64) error {
+	message, err := s.repository.DeleteMessage(ctx, messageID)
+	if err != nil {
+		return s.mapNotFound(err, "message not found")
+	}
+
+	s.broadcast(ctx, message.ConversationID, EventMessageDeleted, message)
+	return nil
+}
+
+// validateBody validates a message body
+func (s *Service) validateBody(req *SendMessageRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		return apperrors.WrapWithMessage(fmt.Errorf("empty message body"), http.StatusBadRequest, "message body cannot be empty")
+	}
+	return nil
+}
+
+// requireMember returns a 404 unless the user belongs to the conversation, so
+// non-members cannot probe which conversations exist
+func (s *Service) requireMember(ctx context.Context, userID int, conversationID int64) error {
+	member, err := s.repository.IsMember(ctx, conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("error while checking membership %w", err)
+	}
+	if !member {
+		return apperrors.WrapWithMessage(fmt.Errorf("user %d is not in conversation %d", userID, conversationID), http.StatusNotFound, "conversation not found")
+	}
+	return nil
+}
+
+// ownMessage returns a message the user sent in a conversation they belong to
+func (s *Service) ownMessage(ctx context.Context, userID int, conversationID, messageID int64) (*Message, error) {
+	if err := s.requireMember(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	message, err := s.repository.GetMessage(ctx, conversationID, messageID)
+	if err != nil {
+		return nil, s.mapNotFound(err, "message not found")
+	}
+	if message.SenderID != userID {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("message %d not sent by user %d", messageID, userID), http.StatusForbidden, "only the sender can change a message")
+	}
+	return message, nil
+}
+
+// checkCanMessage enforces blocks and the recipient's followers-only setting when a
+// conversation is started
+func (s *Service) checkCanMessage(ctx context.Context, senderID, recipientID int) error {
+	if err := s.checkNotBlocked(ctx, senderID, recipientID); err != nil {
+		return err
+	}
+
+	allowFrom, err := s.repository.GetAllowFrom(ctx, recipientID)
+	if err != nil {
+		return fmt.Errorf("error while getting messaging settings %w", err)
+	}
+	if allowFrom != AllowFromFollowers {
+		return nil
+	}
+
+	follows, err := s.relationships.IsFollower(ctx, senderID, recipientID)
+	if err != nil {
+		return fmt.Errorf("error while checking follow %w", err)
+	}
+	if !follows {
+		return apperrors.WrapWithMessage(fmt.Errorf("user %d only accepts messages from followers", recipientID), http.StatusForbidden, "this user only accepts messages from followers")
+	}
+	return nil
+}
+
+// checkNotBlocked rejects messaging between users when either has blocked the other
+func (s *Service) checkNotBlocked(ctx context.Context, userID, otherID int) error {
+	blocked, err := s.relationships.IsBlocked(ctx, userID, otherID)
+	if err != nil {
+		return fmt.Errorf("error while checking blocks %w", err)
+	}
+	if blocked {
+		return apperrors.WrapWithMessage(fmt.Errorf("users %d and %d are blocked", userID, otherID), http.StatusForbidden, "you cannot message this user")
+	}
+	return nil
+}
+
+// mapNotFound converts the repository's not-found error into a 404
+func (s *Service) mapNotFound(err error, message string) error {
+	if errors.Is(err, errNotFound) {
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, message)
+	}
+	return fmt.Errorf("messaging operation failed %w", err)
+}
+
+// broadcast pushes an event to the conversation topic; failures are only logged
+func (s *Service) broadcast(ctx context.Context, conversationID int64, event string, data any) {
+	if s.broadcaster == nil {
+		return
+	}
+	topic := "conversation:" + strconv.FormatInt(conversationID, 10)
+	if err := s.broadcaster.Broadcast(ctx, topic, event, data); err != nil {
+		logging.FromContext(ctx).Error("failed to broadcast conversation event", "event", event, "conversation_id", conversationID, "error", err)
+	}
+}
@@ -0,0 +1,176 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	apperrors "learning/internal/errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// stubRepository serves one conversation; methods the tests don't reach panic
+// through the nil embedded interface
+type stubRepository struct {
+	RepositoryInterface
+	conv          *Conversation
+	sent          bool
+	followersOnly []int
+}
+
+func (r *stubRepository) CountActiveUsers(ctx context.Context, userIDs []int) (int, error) {
+	return len(userIDs), nil
+}
+
+func (r *stubRepository) FindDirectConversation(ctx context.Context, key string) (*Conversation, error) {
+	return nil, errNotFound
+}
+
+func (r *stubRepository) CreateConversation(ctx context.Context, conv *Conversation, directKey *string, memberIDs []int) (*Conversation, error) {
+	for _, id := range memberIDs {
+		conv.Members = append(conv.Members, Member{UserID: id})
+	}
+	r.conv = conv
+	return conv, nil
+}
+
+func (r *stubRepository) GetAllowFrom(ctx context.Context, userID int) (string, error) {
+	if slices.Contains(r.followersOnly, userID) {
+		return AllowFromFollowers, nil
+	}
+	return AllowFromEveryone, nil
+}
+
+func (r *stubRepository) IsMember(ctx context.Context, conversationID int64, userID int) (bool, error) {
+	for _, m := range r.conv.Members {
+		if m.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *stubRepository) GetConversation(ctx context.Context, conversationID int64) (*Conversation, error) {
+	return r.conv, nil
+}
+
+func (r *stubRepository) CreateMessage(ctx context.Context, conversationID int64, senderID int, body string) (*Message, error) {
+	r.sent = true
+	return &Message{ConversationID: conversationID, SenderID: senderID, Body: body}, nil
+}
+
+// stubRelationships treats the listed pairs as blocked in either direction and the
+// followed pairs as follower, followee
+type stubRelationships struct {
+	blocked  [][2]int
+	followed [][2]int
+}
+
+func (s stubRelationships) IsBlocked(ctx context.Context, userID, otherID int) (bool, error) {
+	for _, pair := range s.blocked {
+		if pair == [2]int{userID, otherID} || pair == [2]int{otherID, userID} {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s stubRelationships) IsFollower(ctx context.Context, followerID, followeeID int) (bool, error) {
+	return slices.Contains(s.followed, [2]int{followerID, followeeID}), nil
+}
+
+func TestCreateConversationChecksRecipients(t *testing.T) {
+	tests := []struct {
+		name          string
+		memberIDs     []int
+		followersOnly []int
+		followed      [][2]int
+		blocked       [][2]int
+		wantStatus    int
+	}{
+		{name: "direct", memberIDs: []int{2}},
+		{name: "direct to followers-only recipient", memberIDs: []int{2}, followersOnly: []int{2}, wantStatus: http.StatusForbidden},
+		{name: "direct to followers-only recipient from follower", memberIDs: []int{2}, followersOnly: []int{2}, followed: [][2]int{{1, 2}}},
+		{name: "direct to blocked recipient", memberIDs: []int{2}, blocked: [][2]int{{2, 1}}, wantStatus: http.StatusForbidden},
+		{name: "group", memberIDs: []int{2, 3}},
+		{name: "group with followers-only recipient", memberIDs: []int{2, 3}, followersOnly: []int{3}, wantStatus: http.StatusForbidden},
+		{name: "group with followers-only recipient from follower", memberIDs: []int{2, 3}, followersOnly: []int{3}, followed: [][2]int{{1, 3}}},
+		{name: "group followed by another member only", memberIDs: []int{2, 3}, followersOnly: []int{3}, followed: [][2]int{{2, 3}}, wantStatus: http.StatusForbidden},
+		{name: "group with blocked recipient", memberIDs: []int{2, 3}, blocked: [][2]int{{1, 3}}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepository{followersOnly: tt.followersOnly}
+			svc := NewService(repo, stubRelationships{blocked: tt.blocked, followed: tt.followed}, nil)
+
+			_, err := svc.CreateConversation(context.Background(), 1, &CreateConversationRequest{MemberIDs: tt.memberIDs})
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("CreateConversation() error = %v", err)
+				}
+				if repo.conv == nil {
+					t.Fatal("conversation was not created")
+				}
+				return
+			}
+
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantStatus {
+				t.Fatalf("CreateConversation() error = %v, want status %d", err, tt.wantStatus)
+			}
+			if repo.conv != nil {
+				t.Fatal("conversation was created")
+			}
+		})
+	}
+}
+
+func TestSendMessageChecksBlocks(t *testing.T) {
+	members := func(ids ...int) []Member {
+		out := make([]Member, len(ids))
+		for i, id := range ids {
+			out[i] = Member{UserID: id}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		conv       Conversation
+		blocked    [][2]int
+		wantStatus int
+	}{
+		{name: "direct", conv: Conversation{Kind: KindDirect, Members: members(1, 2)}},
+		{name: "direct blocked by recipient", conv: Conversation{Kind: KindDirect, Members: members(1, 2)}, blocked: [][2]int{{2, 1}}, wantStatus: http.StatusForbidden},
+		{name: "group", conv: Conversation{Kind: KindGroup, Members: members(1, 2, 3)}},
+		{name: "group member blocked by sender", conv: Conversation{Kind: KindGroup, Members: members(1, 2, 3)}, blocked: [][2]int{{1, 3}}, wantStatus: http.StatusForbidden},
+		{name: "block between other members", conv: Conversation{Kind: KindGroup, Members: members(1, 2, 3)}, blocked: [][2]int{{2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepository{conv: &tt.conv}
+			svc := NewService(repo, stubRelationships{blocked: tt.blocked}, nil)
+
+			_, err := svc.SendMessage(context.Background(), 1, 1, &SendMessageRequest{Body: "hello"})
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("SendMessage() error = %v", err)
+				}
+				if !repo.sent {
+					t.Fatal("message was not stored")
+				}
+				return
+			}
+
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantStatus {
+				t.Fatalf("SendMessage() error = %v, want status %d", err, tt.wantStatus)
+			}
+			if repo.sent {
+				t.Fatal("blocked message was stored")
+			}
+		})
+	}
+}
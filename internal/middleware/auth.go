@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"learning/internal/logging"
+	"learning/internal/utils"
+)
+
+// contextKey is the type for values this package stores in request contexts
+type contextKey string
+
+const (
+	userIDKey contextKey = "user_id"
+	adminKey  contextKey = "admin"
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID, which is also
+// added to the request logger and the access log.
+// Authentication middleware calls this once the caller has been verified.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	if state, ok := ctx.Value(requestStateKey).(*requestState); ok {
+		state.userID = userID
+	}
+	ctx = logging.With(ctx, "user_id", userID)
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user ID, if any
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	return userID, ok && userID > 0
+}
+
+// WithAdmin returns a copy of ctx marking the authenticated user as an administrator.
+// Authentication middleware calls this for users holding the admin role.
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey, true)
+}
+
+// IsAdmin reports whether the request was made by an authenticated administrator
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey).(bool)
+	_, authenticated := UserIDFromContext(ctx)
+	return admin && authenticated
+}
+
+// SessionCookie names the cookie carrying the session token for browser clients
+const SessionCookie = "session"
+
+// Authenticator resolves a session token to the user it was issued to
+type Authenticator interface {
+	// Authenticate returns a zero userID without an error for unknown or expired tokens
+	Authenticate(ctx context.Context, token string) (userID int, admin bool, err error)
+}
+
+// SessionToken returns the bearer token sent with r or, without an Authorization header,
+// its session cookie
+func SessionToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// Authenticate identifies the user behind the session token sent with each request
+// through WithUserID and WithAdmin. Requests without a valid token continue
+// anonymously, so public routes keep working with a stale cookie; RequireAuth and
+// RequireAdmin reject them where a user is needed.
+func Authenticate(auth Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := SessionToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, admin, err := auth.Authenticate(r.Context(), token)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("authentication failed", "error", err)
+				utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			if userID > 0 {
+				ctx := WithUserID(r.Context(), userID)
+				if admin {
+					ctx = WithAdmin(ctx)
+				}
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAuth rejects requests that do not carry an authenticated user
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := UserIDFromContext(r.Context()); !ok {
+			utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin rejects requests that do not come from an authenticated administrator
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := UserIDFromContext(r.Context()); !ok {
+			utils.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if !IsAdmin(r.Context()) {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
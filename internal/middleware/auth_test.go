@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubAuthenticator accepts "user" and "admin" tokens and fails on "error"
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(ctx context.Context, token string) (int, bool, error) {
+	switch token {
+	case "user":
+		return 7, false, nil
+	case "admin":
+		return 1, true, nil
+	case "error":
+		return 0, false, errors.New("database unavailable")
+	}
+	return 0, false, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		cookie     string
+		guard      func(http.Handler) http.Handler
+		wantStatus int
+	}{
+		{name: "anonymous on public route", guard: func(h http.Handler) http.Handler { return h }, wantStatus: http.StatusOK},
+		{name: "bearer token", header: "Bearer user", guard: RequireAuth, wantStatus: http.StatusOK},
+		{name: "scheme is case insensitive", header: "bearer user", guard: RequireAuth, wantStatus: http.StatusOK},
+		{name: "session cookie", cookie: "user", guard: RequireAuth, wantStatus: http.StatusOK},
+		{name: "header wins over cookie", header: "Bearer unknown", cookie: "user", guard: RequireAuth, wantStatus: http.StatusUnauthorized},
+		{name: "other scheme", header: "Basic user", guard: RequireAuth, wantStatus: http.StatusUnauthorized},
+		{name: "unknown token", header: "Bearer unknown", guard: RequireAuth, wantStatus: http.StatusUnauthorized},
+		{name: "no token", guard: RequireAuth, wantStatus: http.StatusUnauthorized},
+		{name: "user on admin route", header: "Bearer user", guard: RequireAdmin, wantStatus: http.StatusForbidden},
+		{name: "admin on admin route", header: "Bearer admin", guard: RequireAdmin, wantStatus: http.StatusOK},
+		{name: "authenticator failure", header: "Bearer error", guard: RequireAuth, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			h := Authenticate(stubAuthenticator{})(tt.guard(ok))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
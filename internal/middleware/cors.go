@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"learning/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// CORSPolicy describes which cross-origin callers may use a route.
+//
+// AllowedOrigins entries are exact origins ("https://app.example.com"), wildcard subdomains
+// ("https://*.example.com", which does not match the bare domain), regular expressions
+// starting with "^", which must match the whole origin, or "*" for any origin. Credentials
+// cannot be allowed for any origin.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// compiledPolicy is a CORSPolicy with its origin patterns parsed and header values joined
+type compiledPolicy struct {
+	anyOrigin   bool
+	exact       map[string]bool
+	patterns    []*regexp.Regexp
+	credentials bool
+	methods     string
+	headers     string
+	exposed     string
+	maxAge      string
+}
+
+// compile validates the policy and prepares it for matching
+func (p CORSPolicy) compile() (*compiledPolicy, error) {
+	c := &compiledPolicy{
+		exact:       make(map[string]bool),
+		credentials: p.AllowCredentials,
+		methods:     strings.Join(p.AllowedMethods, ", "),
+		headers:     strings.Join(p.AllowedHeaders, ", "),
+		exposed:     strings.Join(p.ExposedHeaders, ", "),
+		maxAge:      strconv.Itoa(int(p.MaxAge.Seconds())),
+	}
+
+	for _, origin := range p.AllowedOrigins {
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.HasPrefix(origin, "^"):
+			// Anchor the end too, or "^https://app\.example\.com" would also allow
+			// https://app.example.com.evil.net
+			pattern, err := regexp.Compile(`^(?:` + origin[1:] + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CORS origin pattern %q: %w", origin, err)
+			}
+			c.patterns = append(c.patterns, pattern)
+		case strings.Contains(origin, "*"):
+			scheme, host, ok := strings.Cut(origin, "://*.")
+			if !ok || scheme == "" || host == "" || strings.Contains(host, "*") {
+				return nil, fmt.Errorf("invalid CORS wildcard origin %q", origin)
+			}
+			c.patterns = append(c.patterns, regexp.MustCompile(
+				`^`+regexp.QuoteMeta(scheme)+`://[a-z0-9-]+(\.[a-z0-9-]+)*\.`+regexp.QuoteMeta(strings.ToLower(host))+`$`))
+		default:
+			c.exact[strings.ToLower(origin)] = true
+		}
+	}
+
+	if c.anyOrigin && c.credentials {
+		return nil, fmt.Errorf("CORS credentials cannot be allowed for any origin")
+	}
+	return c, nil
+}
+
+// allows reports whether origin may call the route
+func (c *compiledPolicy) allows(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.exact[origin] {
+		return true
+	}
+	for _, pattern := range c.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS applies a default policy, with overrides for named routes, to a router.
+// It wraps the whole router rather than running as router middleware because mux
+// does not run middleware for OPTIONS requests to routes registered for other methods.
+type CORS struct {
+	router    *mux.Router
+	policy    atomic.Pointer[compiledPolicy]
+	overrides map[string]*compiledPolicy
+}
+
+// NewCORS creates a CORS handler for router using policy for every route without an override
+func NewCORS(router *mux.Router, policy CORSPolicy) (*CORS, error) {
+	compiled, err := policy.compile()
+	if err != nil {
+		return nil, err
+	}
+	c := &CORS{router: router, overrides: make(map[string]*compiledPolicy)}
+	c.policy.Store(compiled)
+	return c, nil
+}
+
+// PreparePolicy validates a replacement default policy and returns a function that puts it
+// in force, so it can be swapped in together with other settings. Overrides are unchanged.
+func (c *CORS) PreparePolicy(policy CORSPolicy) (apply func(), err error) {
+	compiled, err := policy.compile()
+	if err != nil {
+		return nil, err
+	}
+	return func() { c.policy.Store(compiled) }, nil
+}
+
+// Override applies policy instead of the default to the route registered under name
+func (c *CORS) Override(name string, policy CORSPolicy) error {
+	if c.router.Get(name) == nil {
+		return fmt.Errorf("no route named %q", name)
+	}
+	compiled, err := policy.compile()
+	if err != nil {
+		return fmt.Errorf("CORS policy for %s: %w", name, err)
+	}
+	c.overrides[name] = compiled
+	return nil
+}
+
+// Handler wraps next, normally the router itself. Preflight requests are answered only
+// when a route exists for the requested method; anything else falls through to next,
+// which responds with 404 or 405 as usual.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultPolicy := c.policy.Load()
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Same-origin responses can still be cached and replayed to other origins
+			if !defaultPolicy.anyOrigin || len(c.overrides) > 0 {
+				w.Header().Add("Vary", "Origin")
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && requestedMethod != ""
+
+		policy := defaultPolicy
+		if preflight {
+			match := r.Clone(r.Context())
+			match.Method = requestedMethod
+			var found bool
+			if policy, found = c.policyFor(match, defaultPolicy); !found {
+				next.ServeHTTP(w, r)
+				return
+			}
+		} else if len(c.overrides) > 0 {
+			policy, _ = c.policyFor(r, defaultPolicy)
+		}
+
+		header := w.Header()
+		if !policy.anyOrigin {
+			// The response depends on the Origin header, so caches must key on it
+			header.Add("Vary", "Origin")
+		}
+		allowed := policy.allows(origin)
+		if allowed {
+			if policy.anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.credentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if allowed && policy.exposed != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposed)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "origin not allowed")
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", policy.methods)
+		if policy.headers != "" {
+			header.Set("Access-Control-Allow-Headers", policy.headers)
+		}
+		header.Set("Access-Control-Max-Age", policy.maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// policyFor returns the policy for the route r matches, or defaultPolicy if it has no
+// override, and whether any route matched
+func (c *CORS) policyFor(r *http.Request, defaultPolicy *compiledPolicy) (*compiledPolicy, bool) {
+	var match mux.RouteMatch
+	if !c.router.Match(r, &match) || match.MatchErr != nil {
+		return defaultPolicy, false
+	}
+	if policy, ok := c.overrides[match.Route.GetName()]; ok {
+		return policy, true
+	}
+	return defaultPolicy, true
+}
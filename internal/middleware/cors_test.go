@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCORSPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "exact", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "exact is case-insensitive", allowed: []string{"https://App.Example.com"}, origin: "https://app.EXAMPLE.com", want: true},
+		{name: "exact other scheme", allowed: []string{"https://app.example.com"}, origin: "http://app.example.com"},
+		{name: "exact with suffix", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com.evil.net"},
+		{name: "wildcard subdomain", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "wildcard nested subdomain", allowed: []string{"https://*.example.com"}, origin: "https://a.b.example.com", want: true},
+		{name: "wildcard bare domain", allowed: []string{"https://*.example.com"}, origin: "https://example.com"},
+		{name: "wildcard lookalike domain", allowed: []string{"https://*.example.com"}, origin: "https://app.notexample.com"},
+		{name: "wildcard with suffix", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com.evil.net"},
+		{name: "wildcard other scheme", allowed: []string{"https://*.example.com"}, origin: "http://app.example.com"},
+		{name: "regex", allowed: []string{`^https://(app|admin)\.example\.com`}, origin: "https://admin.example.com", want: true},
+		{name: "regex with end anchor", allowed: []string{`^https://app\.example\.com$`}, origin: "https://app.example.com", want: true},
+		{name: "regex with suffix", allowed: []string{`^https://app\.example\.com`}, origin: "https://app.example.com.evil.net"},
+		{name: "regex alternation with suffix", allowed: []string{`^https://a\.example\.com|https://b\.example\.com`}, origin: "https://a.example.com.evil.net"},
+		{name: "regex with port", allowed: []string{`^http://localhost:\d+`}, origin: "http://localhost:3000", want: true},
+		{name: "any origin", allowed: []string{"*"}, origin: "https://anything.test", want: true},
+		{name: "no origins", origin: "https://app.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := CORSPolicy{AllowedOrigins: tt.allowed}.compile()
+			if err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			if got := policy.allows(tt.origin); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSPolicyCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CORSPolicy
+	}{
+		{name: "invalid regex", policy: CORSPolicy{AllowedOrigins: []string{"^https://(app"}}},
+		{name: "wildcard without scheme", policy: CORSPolicy{AllowedOrigins: []string{"*.example.com"}}},
+		{name: "wildcard in host", policy: CORSPolicy{AllowedOrigins: []string{"https://app.*.example.com"}}},
+		{name: "credentials for any origin", policy: CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.policy.compile(); err == nil {
+				t.Error("compile() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestCORSHandlerCredentials(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	cors, err := NewCORS(router, CORSPolicy{
+		AllowedOrigins:   []string{`^https://app\.example\.com`},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCORS() error = %v", err)
+	}
+	handler := cors.Handler(router)
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{origin: "https://app.example.com", want: "https://app.example.com"},
+		{origin: "https://app.example.com.evil.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+			wantCredentials := ""
+			if tt.want != "" {
+				wantCredentials = "true"
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, wantCredentials)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"learning/internal/metrics"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricsMiddleware records request count, latency and in-flight requests per route template.
+// Streaming responses are counted but left out of the latency histogram, since a connection
+// that stays open for minutes says nothing about request latency.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		inFlight := metrics.HTTPInFlight.WithLabelValues(route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		wrapped := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		next.ServeHTTP(wrapped, r)
+
+		method := metricMethod(r.Method)
+		if !isStreaming(wrapped) {
+			metrics.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		}
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(wrapped.statusCode)).Inc()
+	})
+}
+
+// metricMethod maps nonstandard methods to a single label value, since routes without a
+// method matcher accept any method
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
+// isStreaming reports whether the response was a WebSocket upgrade or a server-sent event stream
+func isStreaming(w *responseWriter) bool {
+	return w.statusCode == http.StatusSwitchingProtocols ||
+		strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"learning/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMetricsSkipsStreamingLatency(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(MetricsMiddleware)
+	router.HandleFunc("/test/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+	})
+	router.HandleFunc("/test/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		route       string
+		wantLatency bool
+	}{
+		{"/test/plain", true},
+		{"/test/events", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.route, nil))
+
+			// Deleting reports whether the series was created by an observation
+			if got := metrics.HTTPDuration.DeleteLabelValues(http.MethodGet, tt.route); got != tt.wantLatency {
+				t.Errorf("latency recorded = %v, want %v", got, tt.wantLatency)
+			}
+			if !metrics.HTTPRequests.DeleteLabelValues(http.MethodGet, tt.route, "200") {
+				t.Error("request was not counted")
+			}
+		})
+	}
+}
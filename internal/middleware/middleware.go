@@ -1,12 +1,39 @@
 package middleware
 
 import (
-	"log"
+	"bufio"
+	"learning/internal/database"
+	"learning/internal/logging"
+	"learning/internal/utils"
+	"log/slog"
+	"net"
 	"net/http"
+	"runtime/debug"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
-// LoggingMiddleware logs HTTP requests
+// HandleUnmatched answers requests that match no route, or match one only by path, with a
+// JSON 404 or 405 wrapped in mws. mux runs Use middleware for matched routes only, so
+// without this such requests get no request ID, access log or metrics.
+func HandleUnmatched(r *mux.Router, mws ...mux.MiddlewareFunc) {
+	chain := func(h http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+
+	r.NotFoundHandler = chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, http.StatusNotFound, "not found")
+	}))
+	r.MethodNotAllowedHandler = chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}))
+}
+
+// LoggingMiddleware writes an access log entry for every request through the request logger
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -19,45 +46,45 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrapped, r)
 
-		duration := time.Since(start)
-		log.Printf(
-			"%s %s %s %d %v",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			wrapped.statusCode,
-			duration,
-		)
+		attrs := []any{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrapped.statusCode),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+		if state, ok := r.Context().Value(requestStateKey).(*requestState); ok && state.userID > 0 {
+			attrs = append(attrs, slog.Int("user_id", state.userID))
+		}
+		logging.FromContext(r.Context()).Info("request completed", attrs...)
 	})
 }
 
-// RecoveryMiddleware recovers from panics and returns a 500 error
-func RecoveryMiddleware(next http.Handler) http.Handler {
+// ReadYourWritesMiddleware sends every read of a request that may change state to the
+// primary database, so handlers that write and then read back never see a lagging replica
+func ReadYourWritesMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				_, _ = w.Write([]byte(`{"success":false,"error":"internal server error"}`))
-			}
-		}()
-
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			r = r.WithContext(database.WithPrimary(r.Context()))
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-// CORSMiddleware handles CORS headers
-func CORSMiddleware(next http.Handler) http.Handler {
+// RecoveryMiddleware recovers from panics, logs them with the stack trace and returns a 500 error
+func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
+		defer func() {
+			if err := recover(); err != nil {
+				logging.FromContext(r.Context()).Error("panic recovered",
+					slog.Any("panic", err),
+					slog.String("stack", string(debug.Stack())),
+				)
+				utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+			}
+		}()
 
 		next.ServeHTTP(w, r)
 	})
@@ -85,3 +112,20 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the underlying writer so http.ResponseController can flush
+// and adjust deadlines through the wrapper
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack lets WebSocket upgraders take over the connection through the wrapper, which
+// they find by type assertion rather than through http.ResponseController. The upgrade
+// writes its 101 response on the raw connection, so it is recorded here.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+func TestWrappedWriterUpgradesWebSockets(t *testing.T) {
+	var upgrader websocket.Upgrader
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+	})
+	h = LoggingMiddleware(MetricsMiddleware(TracingMiddleware(h)))
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	_, message, err := conn.ReadMessage()
+	if err != nil || string(message) != "hello" {
+		t.Errorf("read %q, %v; want hello", message, err)
+	}
+}
+
+func TestHandleUnmatchedRunsMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware)
+	HandleUnmatched(router, RequestIDMiddleware)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleFunc("/health", ok).Methods(http.MethodGet)
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/users", ok).Methods(http.MethodGet)
+
+	tests := []struct {
+		method, path string
+		wantStatus   int
+	}{
+		{http.MethodGet, "/health", http.StatusOK},
+		{http.MethodGet, "/missing", http.StatusNotFound},
+		{http.MethodPost, "/health", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/missing", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/users", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Header().Get(RequestIDHeader) == "" {
+				t.Error("response has no request ID")
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"learning/internal/logging"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	requestIDKey    contextKey = "request_id"
+	requestStateKey contextKey = "request_state"
+)
+
+// maxRequestIDLength bounds client-supplied IDs so they stay cheap to log
+const maxRequestIDLength = 128
+
+// requestState collects details learned while handling a request for the access log
+type requestState struct {
+	userID int
+}
+
+// RequestIDMiddleware honors a well-formed X-Request-ID from the client or generates one,
+// echoes it in the response and puts a logger carrying the request ID and route template
+// into the request context
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = rand.Text()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx = context.WithValue(ctx, requestStateKey, &requestState{})
+		ctx = logging.With(ctx, "request_id", requestID, "route", routeTemplate(r))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the ID of the request being handled, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// validRequestID accepts IDs made of letters, digits and the punctuation common in
+// UUIDs and tracing IDs, so forwarded values can't inject into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// routeTemplate returns the matched mux route template, so logs and metrics group
+// requests by route rather than by raw path
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
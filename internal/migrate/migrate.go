@@ -0,0 +1,332 @@
+// Package migrate applies the embedded SQL schema migrations.
+//
+// Applied state is kept in a schema_migrations table with the same layout golang-migrate
+// uses (a single version and dirty flag), so databases migrated with that tool carry on
+// where they left off.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"learning/internal/database"
+	"learning/internal/logging"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey identifies the session advisory lock held while migrating, so concurrent
+// runners, such as several instances auto-migrating at startup, apply migrations once
+const lockKey int64 = 0x6d69677261746521
+
+// fileName matches migration files, e.g. 000001_create_users_table.up.sql
+var fileName = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one schema change. Migrations without a down file cannot be reverted.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+	hasDown bool
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Status is the database's schema version and the state of every known migration.
+// Dirty is set when a migration was interrupted and the schema must be checked and
+// repaired with Force.
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []MigrationStatus
+}
+
+// Runner applies migrations to a database
+type Runner struct {
+	db         *database.DataBase
+	migrations []Migration
+}
+
+// NewRunner creates a runner for the migrations in fsys
+func NewRunner(db *database.DataBase, fsys fs.FS) (*Runner, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: migrations}, nil
+}
+
+// Load reads migrations from the root of fsys, ordered by version. Every version needs an
+// up file; down files are optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		body, err := fs.ReadFile(fsys, path.Clean(entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down, m.hasDown = string(body), true
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file or it is empty", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return int(a.Version) - int(b.Version) })
+	return migrations, nil
+}
+
+// Status returns the schema version and which migrations have been applied
+func (r *Runner) Status(ctx context.Context) (*Status, error) {
+	status := &Status{}
+	err := r.locked(ctx, func(conn *pgxpool.Conn, version uint, dirty bool) error {
+		status.Version, status.Dirty = version, dirty
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range r.migrations {
+		status.Migrations = append(status.Migrations, MigrationStatus{
+			Version: m.Version,
+			Name:    m.Name,
+			Applied: m.Version <= status.Version,
+		})
+	}
+	return status, nil
+}
+
+// Up applies every pending migration
+func (r *Runner) Up(ctx context.Context) error {
+	if len(r.migrations) == 0 {
+		return nil
+	}
+	return r.Goto(ctx, r.migrations[len(r.migrations)-1].Version)
+}
+
+// Down reverts the n most recently applied migrations
+func (r *Runner) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("number of migrations to revert must be positive")
+	}
+	return r.locked(ctx, func(conn *pgxpool.Conn, version uint, dirty bool) error {
+		if err := r.checkState(version, dirty); err != nil {
+			return err
+		}
+		i := r.index(version)
+		target := uint(0)
+		if i-n >= 0 {
+			target = r.migrations[i-n].Version
+		}
+		return r.migrate(ctx, conn, version, target)
+	})
+}
+
+// Goto migrates up or down to version; 0 reverts every migration
+func (r *Runner) Goto(ctx context.Context, target uint) error {
+	if target != 0 && r.index(target) < 0 {
+		return fmt.Errorf("migration %d does not exist", target)
+	}
+	return r.locked(ctx, func(conn *pgxpool.Conn, version uint, dirty bool) error {
+		if err := r.checkState(version, dirty); err != nil {
+			return err
+		}
+		return r.migrate(ctx, conn, version, target)
+	})
+}
+
+// Force records version as applied and clears the dirty flag without running anything.
+// It repairs the state after an interrupted migration has been fixed by hand.
+func (r *Runner) Force(ctx context.Context, version uint) error {
+	if version != 0 && r.index(version) < 0 {
+		return fmt.Errorf("migration %d does not exist", version)
+	}
+	return r.locked(ctx, func(conn *pgxpool.Conn, current uint, dirty bool) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer database.Rollback(ctx, tx)
+
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit schema version: %w", err)
+		}
+		logging.FromContext(ctx).Warn("schema version forced", "from", current, "to", version, "was_dirty", dirty)
+		return nil
+	})
+}
+
+// checkState refuses to migrate from a dirty or unknown version
+func (r *Runner) checkState(version uint, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty: check the schema, then force a version", version)
+	}
+	if version != 0 && r.index(version) < 0 {
+		return fmt.Errorf("schema version %d is not a known migration", version)
+	}
+	return nil
+}
+
+// index returns the position of version in r.migrations, or -1
+func (r *Runner) index(version uint) int {
+	return slices.IndexFunc(r.migrations, func(m Migration) bool { return m.Version == version })
+}
+
+// migrate applies up or down migrations one at a time from version current to target.
+// Each migration runs in its own transaction together with the version update, so a
+// failing migration leaves the schema at the previous version.
+func (r *Runner) migrate(ctx context.Context, conn *pgxpool.Conn, current, target uint) error {
+	logger := logging.FromContext(ctx)
+	if current == target {
+		logger.Info("schema is up to date", "version", current)
+		return nil
+	}
+
+	for current != target {
+		var (
+			m         Migration
+			sql       string
+			direction string
+			next      uint
+		)
+		if target > current {
+			m = r.migrations[r.index(current)+1]
+			sql, direction, next = m.Up, "up", m.Version
+		} else {
+			i := r.index(current)
+			m = r.migrations[i]
+			if !m.hasDown {
+				return fmt.Errorf("migration %d_%s cannot be reverted: it has no down file", m.Version, m.Name)
+			}
+			sql, direction = m.Down, "down"
+			if i > 0 {
+				next = r.migrations[i-1].Version
+			}
+		}
+
+		start := time.Now()
+		if err := apply(ctx, conn, sql, next); err != nil {
+			return fmt.Errorf("migration %d_%s %s failed: %w", m.Version, m.Name, direction, err)
+		}
+		logger.Info("migration applied", "version", m.Version, "name", m.Name, "direction", direction, "duration", time.Since(start))
+		current = next
+	}
+	return nil
+}
+
+// apply runs one migration and records version as the schema version
+func apply(ctx context.Context, conn *pgxpool.Conn, sql string, version uint) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	// Without arguments pgx uses the simple protocol, which allows several statements
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// setVersion records version as the clean schema version; 0 means no migrations
+func setVersion(ctx context.Context, tx pgx.Tx, version uint) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(version)); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
+
+// locked runs fn on a dedicated connection holding the migration advisory lock, passing
+// the current schema version
+func (r *Runner) locked(ctx context.Context, fn func(conn *pgxpool.Conn, version uint, dirty bool) error) error {
+	conn, err := r.db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			// Closing the connection ends the session and with it the lock
+			_ = conn.Conn().Close(unlockCtx)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return fn(conn, uint(version), dirty)
+}
@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"learning/migrations"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+
+	fsys := fstest.MapFS{
+		"000010_add_index.up.sql":       file("CREATE INDEX"),
+		"000002_create_posts.up.sql":    file("CREATE TABLE posts"),
+		"000002_create_posts.down.sql":  file("DROP TABLE posts"),
+		"000001_create_users.up.sql":    file("CREATE TABLE users"),
+		"000001_create_users.down.sql":  file("DROP TABLE users"),
+		"README.md":                     file("not a migration"),
+		"000003_notes.sql":              file("no direction"),
+		"archive/000004_old.up.sql":     file("in a subdirectory"),
+		"000005_backfill.up.sql.backup": file("wrong extension"),
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users", Down: "DROP TABLE users", hasDown: true},
+		{Version: 2, Name: "create_posts", Up: "CREATE TABLE posts", Down: "DROP TABLE posts", hasDown: true},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Load() returned %d migrations, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1")}
+	empty := &fstest.MapFile{}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "version zero",
+			fsys:    fstest.MapFS{"000000_init.up.sql": file},
+			wantErr: "invalid migration version",
+		},
+		{
+			name:    "version out of range",
+			fsys:    fstest.MapFS{"99999999999_huge.up.sql": file},
+			wantErr: "invalid migration version",
+		},
+		{
+			name:    "two names",
+			fsys:    fstest.MapFS{"000001_users.up.sql": file, "000001_accounts.down.sql": file},
+			wantErr: "migration 1 has two names",
+		},
+		{
+			name:    "down without up",
+			fsys:    fstest.MapFS{"000001_users.down.sql": file},
+			wantErr: "has no up file",
+		},
+		{
+			name:    "empty up",
+			fsys:    fstest.MapFS{"000001_users.up.sql": empty, "000001_users.down.sql": file},
+			wantErr: "has no up file or it is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range got {
+		if m.Version != uint(i+1) {
+			t.Errorf("migration %d_%s: versions should run 1 to %d without gaps", m.Version, m.Name, len(got))
+		}
+		if !m.hasDown {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
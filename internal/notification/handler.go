@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles notification-related HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new notification handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers notification routes; all of them require an authenticated user
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	nr := r.PathPrefix("/notifications").Subrouter()
+	nr.Use(middleware.RequireAuth)
+
+	nr.HandleFunc("", h.List).Methods(http.MethodGet)
+	nr.HandleFunc("/unread-count", h.UnreadCount).Methods(http.MethodGet)
+	nr.HandleFunc("/read-all", h.MarkAllRead).Methods(http.MethodPost)
+	nr.HandleFunc("/{id}/read", h.MarkRead).Methods(http.MethodPost)
+}
+
+// List handles paginated retrieval of the caller's notifications
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	notifications, err := h.service.ListNotifications(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if notifications == nil {
+		notifications = []Notification{}
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, notifications)
+}
+
+// UnreadCount handles retrieval of the caller's unread notification count
+func (h *Handler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	count, err := h.service.GetUnreadCount(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, UnreadCountResponse{Unread: count})
+}
+
+// MarkRead handles marking a single notification as read
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid notification id")
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), userID, id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "notification marked as read")
+}
+
+// MarkAllRead handles marking all of the caller's notifications as read
+func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	if err := h.service.MarkAllRead(r.Context(), userID); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "all notifications marked as read")
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
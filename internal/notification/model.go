@@ -0,0 +1,150 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notification types
+const (
+	TypeFollow        = "follow"
+	TypeFollowRequest = "follow_request"
+	TypeMention       = "mention"
+	TypeReaction      = "reaction"
+	TypeComment       = "comment"
+	TypeRepost        = "repost"
+
+	// System notifications have no actor
+	TypeReportResolved = "report_resolved"
+	TypeWarning        = "warning"
+)
+
+// maxActorsShown is how many actors are returned with each grouped notification
+const maxActorsShown = 3
+
+// Event is emitted by producers when something happens that a user should hear about
+type Event struct {
+	Type        string
+	RecipientID int
+	ActorID     int    // zero for system notifications
+	SubjectType string // e.g. "post" or "comment"; empty for follows
+	SubjectID   int64
+}
+
+// GroupKey returns the key under which similar unread events are folded together
+func (e Event) GroupKey() string {
+	if e.SubjectType == "" {
+		return e.Type
+	}
+	return fmt.Sprintf("%s:%s:%d", e.Type, e.SubjectType, e.SubjectID)
+}
+
+// Actor represents a user who triggered a notification
+type Actor struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// Notification represents a grouped notification in a user's inbox
+type Notification struct {
+	ID          int64      `json:"id" db:"id"`
+	RecipientID int        `json:"-" db:"recipient_id"`
+	Type        string     `json:"type" db:"type"`
+	SubjectType *string    `json:"subject_type,omitempty" db:"subject_type"`
+	SubjectID   *int64     `json:"subject_id,omitempty" db:"subject_id"`
+	Actors      []Actor    `json:"actors"`
+	ActorCount  int        `json:"actor_count" db:"actor_count"`
+	Summary     string     `json:"summary"`
+	Read        bool       `json:"read"`
+	ReadAt      *time.Time `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PublishedEventType is the real-time event type used for new notifications
+const PublishedEventType = "notification"
+
+// PublishedEvent is the real-time payload pushed when a notification is created or updated
+type PublishedEvent struct {
+	NotificationID int64  `json:"notification_id"`
+	Type           string `json:"type"`
+	ActorID        int    `json:"actor_id"`
+	SubjectType    string `json:"subject_type,omitempty"`
+	SubjectID      int64  `json:"subject_id,omitempty"`
+}
+
+// UnreadCountResponse represents the unread count returned in API responses
+type UnreadCountResponse struct {
+	Unread int `json:"unread"`
+}
+
+// summarize builds the human-readable text for a grouped notification,
+// e.g. "alice and 3 others liked your post"
+func summarize(n *Notification) string {
+	if systemTypes[n.Type] {
+		return action(n)
+	}
+
+	var who string
+	switch {
+	case len(n.Actors) == 0:
+		who = "Someone"
+	case n.ActorCount == 1:
+		who = n.Actors[0].Username
+	case n.ActorCount == 2 && len(n.Actors) >= 2:
+		who = n.Actors[0].Username + " and " + n.Actors[1].Username
+	case n.ActorCount == 2:
+		who = n.Actors[0].Username + " and 1 other"
+	default:
+		who = fmt.Sprintf("%s and %d others", n.Actors[0].Username, n.ActorCount-1)
+	}
+
+	return who + " " + action(n)
+}
+
+// action returns the verb phrase for a notification type
+func action(n *Notification) string {
+	subject := "post"
+	if n.SubjectType != nil && *n.SubjectType != "" {
+		subject = *n.SubjectType
+	}
+
+	switch n.Type {
+	case TypeFollow:
+		return "followed you"
+	case TypeFollowRequest:
+		return "requested to follow you"
+	case TypeMention:
+		return "mentioned you in a " + subject
+	case TypeReaction:
+		return "reacted to your " + subject
+	case TypeComment:
+		return "commented on your " + subject
+	case TypeRepost:
+		return "reposted your " + subject
+	case TypeReportResolved:
+		return "A report you made has been reviewed by the moderators"
+	case TypeWarning:
+		return "You received a warning from the moderators"
+	default:
+		return "interacted with you"
+	}
+}
+
+// validTypes lists the notification types producers may emit
+var validTypes = map[string]bool{
+	TypeFollow:         true,
+	TypeFollowRequest:  true,
+	TypeMention:        true,
+	TypeReaction:       true,
+	TypeComment:        true,
+	TypeRepost:         true,
+	TypeReportResolved: true,
+	TypeWarning:        true,
+}
+
+// systemTypes are emitted by the service itself rather than by another user
+var systemTypes = map[string]bool{
+	TypeReportResolved: true,
+	TypeWarning:        true,
+}
@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for notifications
+type RepositoryInterface interface {
+	AddEvent(ctx context.Context, event Event) (int64, error)
+	ListNotifications(ctx context.Context, recipientID, limit, offset int) ([]Notification, error)
+	CountUnread(ctx context.Context, recipientID int) (int, error)
+	MarkRead(ctx context.Context, recipientID int, id int64) (bool, error)
+	MarkAllRead(ctx context.Context, recipientID int) (int64, error)
+}
+
+// NewRepository creates a new notification repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// AddEvent folds an event into the recipient's unread notification for the same
+// group, creating the notification if none is unread, and returns its ID
+func (r *Repository) AddEvent(ctx context.Context, event Event) (int64, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	var subjectType *string
+	var subjectID *int64
+	if event.SubjectType != "" {
+		subjectType = &event.SubjectType
+		subjectID = &event.SubjectID
+	}
+
+	now := time.Now()
+	var id int64
+	err = tx.QueryRow(ctx, `
+        INSERT INTO notifications (recipient_id, type, subject_type, subject_id, group_key, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        ON CONFLICT (recipient_id, group_key) WHERE read_at IS NULL
+        DO UPDATE SET updated_at = EXCLUDED.updated_at
+        RETURNING id
+    `, event.RecipientID, event.Type, subjectType, subjectID, event.GroupKey(), now).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert notification: %w", err)
+	}
+
+	// System notifications have no actor to record
+	if event.ActorID == 0 {
+		if err := tx.Commit(ctx); err != nil {
+			return 0, fmt.Errorf("failed to commit notification: %w", err)
+		}
+		return id, nil
+	}
+
+	// xmax is zero only for freshly inserted rows, not for conflict updates
+	var inserted bool
+	err = tx.QueryRow(ctx, `
+        INSERT INTO notification_actors (notification_id, actor_id, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = EXCLUDED.created_at
+        RETURNING (xmax = 0)
+    `, id, event.ActorID, now).Scan(&inserted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add notification actor: %w", err)
+	}
+
+	// Only a new actor increases the count; a repeated actor just moves to the front
+	if inserted {
+		_, err = tx.Exec(ctx, `UPDATE notifications SET actor_count = actor_count + 1 WHERE id = $1`, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update actor count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit notification: %w", err)
+	}
+	return id, nil
+}
+
+// ListNotifications returns a page of the recipient's notifications, most recently updated first
+func (r *Repository) ListNotifications(ctx context.Context, recipientID, limit, offset int) ([]Notification, error) {
+	query := `
+        SELECT n.id, n.recipient_id, n.type, n.subject_type, n.subject_id, n.actor_count,
+               n.read_at, n.created_at, n.updated_at,
+               COALESCE(a.actor_ids, '{}'), COALESCE(a.usernames, '{}')
+        FROM notifications n
+        LEFT JOIN LATERAL (
+            SELECT ARRAY_AGG(u.id ORDER BY na.created_at DESC) AS actor_ids,
+                   ARRAY_AGG(u.username ORDER BY na.created_at DESC) AS usernames
+            FROM (
+                SELECT actor_id, created_at
+                FROM notification_actors
+                WHERE notification_id = n.id
+                ORDER BY created_at DESC
+                LIMIT $4
+            ) na
+            JOIN users u ON u.id = na.actor_id
+        ) a ON true
+        WHERE n.recipient_id = $1
+        ORDER BY n.updated_at DESC, n.id DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, recipientID, limit, offset, maxActorsShown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	notifications, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Notification, error) {
+		var n Notification
+		var actorIDs []int32
+		var usernames []string
+		err := row.Scan(
+			&n.ID,
+			&n.RecipientID,
+			&n.Type,
+			&n.SubjectType,
+			&n.SubjectID,
+			&n.ActorCount,
+			&n.ReadAt,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+			&actorIDs,
+			&usernames,
+		)
+		if err != nil {
+			return n, err
+		}
+
+		n.Actors = make([]Actor, 0, len(actorIDs))
+		for i := range actorIDs {
+			n.Actors = append(n.Actors, Actor{ID: int(actorIDs[i]), Username: usernames[i]})
+		}
+		n.Read = n.ReadAt != nil
+		return n, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// CountUnread returns the number of unread notifications for the recipient
+func (r *Repository) CountUnread(ctx context.Context, recipientID int) (int, error) {
+	var count int
+	err := r.db.Reader(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FROM notifications
+        WHERE recipient_id = $1 AND read_at IS NULL
+    `, recipientID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a single notification as read and reports whether it belonged to the recipient
+func (r *Repository) MarkRead(ctx context.Context, recipientID int, id int64) (bool, error) {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `
+        UPDATE notifications SET read_at = COALESCE(read_at, $3)
+        WHERE id = $1 AND recipient_id = $2
+    `, id, recipientID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkAllRead marks every unread notification of the recipient as read
+func (r *Repository) MarkAllRead(ctx context.Context, recipientID int) (int64, error) {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `
+        UPDATE notifications SET read_at = $2
+        WHERE recipient_id = $1 AND read_at IS NULL
+    `, recipientID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark all notifications read: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes.
+// The returned service is the Emitter handed to notification producers.
+func Register(r *mux.Router, db *database.DataBase, publisher Publisher) *Service {
+	repo := NewRepository(db)
+	svc := NewService(repo, publisher)
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc
+}
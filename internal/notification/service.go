@@ -0,0 +1,125 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	apperrors "learning/internal/errors"
+	"net/http"
+)
+
+// Emitter is the interface producers use to raise notification events.
+// Services depend on this rather than on the notification storage.
+type Emitter interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+// Publisher pushes real-time events to the recipient's open connections
+type Publisher interface {
+	Publish(userID int, eventType string, data any)
+}
+
+// Publishers fans a real-time event out to several publishers
+type Publishers []Publisher
+
+// Publish sends the event through every publisher
+func (p Publishers) Publish(userID int, eventType string, data any) {
+	for _, publisher := range p {
+		publisher.Publish(userID, eventType, data)
+	}
+}
+
+// ServiceInterface defines business operations for notifications
+type ServiceInterface interface {
+	Emitter
+	ListNotifications(ctx context.Context, recipientID, limit, offset int) ([]Notification, error)
+	GetUnreadCount(ctx context.Context, recipientID int) (int, error)
+	MarkRead(ctx context.Context, recipientID int, id int64) error
+	MarkAllRead(ctx context.Context, recipientID int) error
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	publisher  Publisher
+}
+
+// NewService creates a new notification service. A nil publisher disables real-time delivery.
+func NewService(repository RepositoryInterface, publisher Publisher) *Service {
+	return &Service{
+		repository: repository,
+		publisher:  publisher,
+	}
+}
+
+// Emit records an event in the recipient's inbox. Self-notifications are dropped.
+func (s *Service) Emit(ctx context.Context, event Event) error {
+	if !validTypes[event.Type] {
+		return fmt.Errorf("invalid notification type %q", event.Type)
+	}
+	if event.RecipientID <= 0 || event.ActorID < 0 || event.ActorID == 0 && !systemTypes[event.Type] {
+		return fmt.Errorf("invalid notification recipient %d or actor %d", event.RecipientID, event.ActorID)
+	}
+	if event.RecipientID == event.ActorID {
+		return nil
+	}
+
+	id, err := s.repository.AddEvent(ctx, event)
+	if err != nil {
+		return fmt.Errorf("error while emitting notification %w", err)
+	}
+
+	if s.publisher != nil {
+		s.publisher.Publish(event.RecipientID, PublishedEventType, PublishedEvent{
+			NotificationID: id,
+			Type:           event.Type,
+			ActorID:        event.ActorID,
+			SubjectType:    event.SubjectType,
+			SubjectID:      event.SubjectID,
+		})
+	}
+	return nil
+}
+
+// ListNotifications returns a page of the recipient's grouped notifications
+func (s *Service) ListNotifications(ctx context.Context, recipientID, limit, offset int) ([]Notification, error) {
+	notifications, err := s.repository.ListNotifications(ctx, recipientID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing notifications %w", err)
+	}
+
+	for i := range notifications {
+		notifications[i].Summary = summarize(&notifications[i])
+	}
+	return notifications, nil
+}
+
+// GetUnreadCount returns the number of unread notifications
+func (s *Service) GetUnreadCount(ctx context.Context, recipientID int) (int, error) {
+	count, err := s.repository.CountUnread(ctx, recipientID)
+	if err != nil {
+		return 0, fmt.Errorf("error while counting unread notifications %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the recipient's notifications as read
+func (s *Service) MarkRead(ctx context.Context, recipientID int, id int64) error {
+	found, err := s.repository.MarkRead(ctx, recipientID, id)
+	if err != nil {
+		return fmt.Errorf("error while marking notification read %w", err)
+	}
+	if !found {
+		return apperrors.WrapWithMessage(fmt.Errorf("notification %d not found", id), http.StatusNotFound, "notification not found")
+	}
+	return nil
+}
+
+// MarkAllRead marks all of the recipient's notifications as read
+func (s *Service) MarkAllRead(ctx context.Context, recipientID int) error {
+	if _, err := s.repository.MarkAllRead(ctx, recipientID); err != nil {
+		return fmt.Errorf("error while marking notifications read %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package post
+
+import (
+	"encoding/json"
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles post-related HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new post handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers post routes; writing requires an authenticated user
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/posts/{id}", h.GetPost).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/posts", h.ListPostsByAuthor).Methods(http.MethodGet)
+
+	ar := r.PathPrefix("/posts").Subrouter()
+	ar.Use(middleware.RequireAuth)
+
+	ar.HandleFunc("", h.CreatePost).Methods(http.MethodPost)
+	ar.HandleFunc("/{id}", h.EditPost).Methods(http.MethodPatch)
+	ar.HandleFunc("/{id}", h.DeletePost).Methods(http.MethodDelete)
+}
+
+// CreatePost handles publishing a post
+func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req PostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	post, err := h.service.CreatePost(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, post)
+}
+
+// GetPost handles retrieval of a post by ID
+func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := h.service.GetPost(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, post)
+}
+
+// ListPostsByAuthor handles listing a user's posts
+func (h *Handler) ListPostsByAuthor(w http.ResponseWriter, r *http.Request) {
+	authorID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || authorID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	posts, err := h.service.ListPostsByAuthor(r.Context(), authorID, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, posts)
+}
+
+// EditPost handles replacing the body of one of the caller's posts
+func (h *Handler) EditPost(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req PostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	post, err := h.service.EditPost(r.Context(), userID, id, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, post)
+}
+
+// DeletePost handles deleting one of the caller's posts
+func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePost(r.Context(), userID, id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "post deleted")
+}
+
+// parseID reads the post ID from the path, writing a 400 when it is invalid
+func parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid post id")
+		return 0, false
+	}
+	return id, true
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
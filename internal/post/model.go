@@ -0,0 +1,32 @@
+package post
+
+import (
+	"learning/internal/media"
+	"learning/internal/mention"
+	"time"
+)
+
+// MaxBodyLength is the longest post body (in characters) that is accepted
+const MaxBodyLength = 5000
+
+// Post represents a user's post. A held post matched a content filter hold rule and is
+// hidden until a moderator approves it; EditPending marks a published post whose latest
+// edit is held instead.
+type Post struct {
+	ID          int64            `json:"id" db:"id"`
+	AuthorID    int              `json:"author_id" db:"author_id"`
+	Body        string           `json:"body" db:"body"`
+	Mentions    []mention.Entity `json:"mentions"`
+	Media       []media.Media    `json:"media"`
+	Held        bool             `json:"held,omitempty" db:"held"`
+	EditPending bool             `json:"edit_pending,omitempty"`
+	EditedAt    *time.Time       `json:"edited_at,omitempty" db:"edited_at"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+}
+
+// PostRequest represents the body of a new or edited post. MediaIDs lists the attached
+// media in display order; when editing, omitting it keeps the current attachments.
+type PostRequest struct {
+	Body     string  `json:"body" validate:"required,max=5000"`
+	MediaIDs []int64 `json:"media_ids,omitempty"`
+}
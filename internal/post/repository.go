@@ -0,0 +1,128 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Sentinel errors returned by the repository
+var (
+	errNotFound = errors.New("post not found")
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for posts
+type RepositoryInterface interface {
+	CreatePost(ctx context.Context, authorID int, body string, held bool) (*Post, error)
+	GetPost(ctx context.Context, id int64) (*Post, error)
+	ListPostsByAuthor(ctx context.Context, authorID, limit, offset int) ([]Post, error)
+	UpdatePostBody(ctx context.Context, id int64, body string, held bool) (*Post, error)
+	DeletePost(ctx context.Context, id int64) error
+}
+
+// NewRepository creates a new post repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// postColumns lists the columns scanned by scanPostFromRow, in order
+const postColumns = `id, author_id, body, held, edited_at, created_at`
+
+// scanPostFromRow scans a database row into a Post model
+func scanPostFromRow(row pgx.Row) (*Post, error) {
+	var p Post
+
+	err := row.Scan(
+		&p.ID,
+		&p.AuthorID,
+		&p.Body,
+		&p.Held,
+		&p.EditedAt,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+
+	return &p, nil
+}
+
+// CreatePost stores a new post, hidden when held
+func (r *Repository) CreatePost(ctx context.Context, authorID int, body string, held bool) (*Post, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        INSERT INTO posts (author_id, body, held, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING `+postColumns, authorID, body, held, time.Now())
+	return scanPostFromRow(row)
+}
+
+// GetPost returns a post by ID, including held posts
+func (r *Repository) GetPost(ctx context.Context, id int64) (*Post, error) {
+	row := r.db.Reader(ctx).QueryRow(ctx, `SELECT `+postColumns+` FROM posts WHERE id = $1`, id)
+	return scanPostFromRow(row)
+}
+
+// ListPostsByAuthor returns a user's published posts, newest first
+func (r *Repository) ListPostsByAuthor(ctx context.Context, authorID, limit, offset int) ([]Post, error) {
+	query := `
+        SELECT ` + postColumns + `
+        FROM posts
+        WHERE author_id = $1 AND held = false
+        ORDER BY id DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, authorID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Post, error) {
+		p, err := scanPostFromRow(row)
+		if err != nil {
+			return Post{}, err
+		}
+		return *p, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// UpdatePostBody replaces the body of a post and whether it is held. Only posts that were
+// already published are marked as edited.
+func (r *Repository) UpdatePostBody(ctx context.Context, id int64, body string, held bool) (*Post, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        UPDATE posts SET body = $2, held = $3, edited_at = CASE WHEN held THEN edited_at ELSE $4 END
+        WHERE id = $1
+        RETURNING `+postColumns, id, body, held, time.Now())
+	return scanPostFromRow(row)
+}
+
+// DeletePost removes a post
+func (r *Repository) DeletePost(ctx context.Context, id int64) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM posts WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errNotFound
+	}
+	return nil
+}
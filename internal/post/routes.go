@@ -0,0 +1,27 @@
+package post
+
+import (
+	"learning/internal/contentfilter"
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes.
+// Post bodies are screened by filters, which publishes held posts through the service once
+// approved, indexed by hashtags and scanned for mentions on every write, and attachments
+// must be media uploaded by the post's author. Published posts are streamed to the author's
+// followers through publisher.
+func Register(r *mux.Router, db *database.DataBase, filters *contentfilter.Service, hashtags Indexer, mentions Mentions, attachments Attachments, followers Followers, publisher Publisher) *Service {
+	repo := NewRepository(db)
+	svc := NewService(repo, database.NewTxManager(db), filters, hashtags, mentions, attachments, followers, publisher)
+	filters.OnRelease(contentfilter.ScopePost, svc.ReleasePost)
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc
+}
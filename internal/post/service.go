@@ -0,0 +1,430 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/contentfilter"
+	"learning/internal/database"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/media"
+	"learning/internal/mention"
+	"learning/internal/stream"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Indexer keeps derived content such as hashtags in step with post bodies
+type Indexer interface {
+	IndexPost(ctx context.Context, postID int64, body string, createdAt time.Time) ([]string, error)
+}
+
+// Mentions resolves and stores the @mentions in post bodies and notifies mentioned users
+type Mentions interface {
+	ProcessContent(ctx context.Context, sourceType string, sourceID int64, authorID int, body string) ([]mention.Entity, error)
+	GetEntities(ctx context.Context, sourceType string, sourceID int64) ([]mention.Entity, error)
+}
+
+// Attachments attaches uploaded media to posts and lists them back
+type Attachments interface {
+	AttachToPost(ctx context.Context, ownerID int, postID int64, req *media.AttachRequest) error
+	GetPostMedia(ctx context.Context, postID int64) ([]media.Media, error)
+}
+
+// Followers lists the users following an author
+type Followers interface {
+	FollowerIDs(ctx context.Context, userID int) ([]int, error)
+}
+
+// Publisher pushes real-time events to a user's open streams
+type Publisher interface {
+	Publish(userID int, eventType string, data any)
+}
+
+// ContentFilter screens post bodies against moderation rules
+type ContentFilter interface {
+	Check(scope, text string) contentfilter.Verdict
+	Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict contentfilter.Verdict) error
+	Withdraw(ctx context.Context, scope string, subjectID int64) error
+}
+
+// noFilter allows all text; it is used when no content filter is configured
+type noFilter struct{}
+
+func (noFilter) Check(scope, text string) contentfilter.Verdict {
+	return contentfilter.Verdict{Action: contentfilter.ActionAllow, Text: text}
+}
+
+func (noFilter) Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict contentfilter.Verdict) error {
+	return nil
+}
+
+func (noFilter) Withdraw(ctx context.Context, scope string, subjectID int64) error {
+	return nil
+}
+
+// ServiceInterface defines business operations for posts
+type ServiceInterface interface {
+	CreatePost(ctx context.Context, authorID int, req *PostRequest) (*Post, error)
+	GetPost(ctx context.Context, id int64) (*Post, error)
+	ListPostsByAuthor(ctx context.Context, authorID, limit, offset int) ([]Post, error)
+	EditPost(ctx context.Context, userID int, id int64, req *PostRequest) (*Post, error)
+	DeletePost(ctx context.Context, userID int, id int64) error
+	RemovePost(ctx context.Context, id int64) error
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	transactor database.Transactor
+	filter     ContentFilter
+	hashtags   Indexer
+	mentions   Mentions
+	media      Attachments
+	followers  Followers
+	publisher  Publisher
+	validator  *validator.Validate
+}
+
+// NewService creates a new post service. A nil publisher disables streaming new posts to
+// followers.
+func NewService(repository RepositoryInterface, transactor database.Transactor, filter ContentFilter, hashtags Indexer, mentions Mentions, attachments Attachments, followers Followers, publisher Publisher) *Service {
+	if filter == nil {
+		filter = noFilter{}
+	}
+	return &Service{
+		repository: repository,
+		transactor: transactor,
+		filter:     filter,
+		hashtags:   hashtags,
+		mentions:   mentions,
+		media:      attachments,
+		followers:  followers,
+		publisher:  publisher,
+		validator:  validator.New(),
+	}
+}
+
+// CreatePost screens a post's body, then stores the post, attaches its media and indexes
+// its hashtags in the same transaction, then resolves its mentions and streams it to the
+// author's followers. A held post is stored hidden and only indexed and streamed once a
+// moderator releases it.
+func (s *Service) CreatePost(ctx context.Context, authorID int, req *PostRequest) (*Post, error) {
+	if err := s.validateBody(req); err != nil {
+		return nil, err
+	}
+
+	var post *Post
+	var held bool
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		body, verdict, err := s.screenBody(req.Body)
+		if err != nil {
+			return err
+		}
+		held = verdict.Action == contentfilter.ActionHold
+
+		if post, err = s.repository.CreatePost(ctx, authorID, body, held); err != nil {
+			return err
+		}
+		if post.Media, err = s.attach(ctx, authorID, post.ID, req.MediaIDs); err != nil {
+			return err
+		}
+		if held {
+			return s.filter.Hold(ctx, contentfilter.ScopePost, authorID, post.ID, body, verdict)
+		}
+		_, err = s.hashtags.IndexPost(ctx, post.ID, post.Body, post.CreatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, mapError(err, "error while creating post")
+	}
+
+	if held {
+		post.Mentions = []mention.Entity{}
+	} else {
+		s.processMentions(ctx, post)
+		s.publishFeedItem(ctx, post)
+	}
+	return post, nil
+}
+
+// GetPost returns a post by ID
+func (s *Service) GetPost(ctx context.Context, id int64) (*Post, error) {
+	post, err := s.repository.GetPost(ctx, id)
+	if err != nil {
+		return nil, mapNotFound(err, "error while getting post")
+	}
+	if post.Held {
+		return nil, mapNotFound(errNotFound, "error while getting post")
+	}
+	if err := s.load(ctx, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// ListPostsByAuthor returns a user's posts, newest first
+func (s *Service) ListPostsByAuthor(ctx context.Context, authorID, limit, offset int) ([]Post, error) {
+	posts, err := s.repository.ListPostsByAuthor(ctx, authorID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing posts %w", err)
+	}
+	if posts == nil {
+		posts = []Post{}
+	}
+	for i := range posts {
+		if err := s.load(ctx, &posts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
+
+// EditPost screens the new body of one of the user's posts, replaces the body and, when
+// given, the media and re-indexes its hashtags and mentions. Users mentioned before the
+// edit are not notified again. A held edit leaves a published post's body as it was until
+// a moderator releases it; an edit that is not held withdraws any pending one.
+func (s *Service) EditPost(ctx context.Context, userID int, id int64, req *PostRequest) (*Post, error) {
+	if err := s.validateBody(req); err != nil {
+		return nil, err
+	}
+	current, err := s.requireAuthor(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var post *Post
+	var held bool
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		body, verdict, err := s.screenBody(req.Body)
+		if err != nil {
+			return err
+		}
+		held = verdict.Action == contentfilter.ActionHold
+
+		if held && !current.Held {
+			post = current
+			post.EditPending = true
+		} else if post, err = s.repository.UpdatePostBody(ctx, id, body, held); err != nil {
+			return err
+		}
+		if req.MediaIDs == nil {
+			post.Media, err = s.media.GetPostMedia(ctx, id)
+		} else {
+			post.Media, err = s.attach(ctx, userID, id, req.MediaIDs)
+		}
+		if err != nil {
+			return err
+		}
+		if held {
+			return s.filter.Hold(ctx, contentfilter.ScopePost, userID, id, body, verdict)
+		}
+		if err := s.filter.Withdraw(ctx, contentfilter.ScopePost, id); err != nil {
+			return err
+		}
+		// Tags keep the post's original time so edits don't push old posts up the tag feeds
+		_, err = s.hashtags.IndexPost(ctx, post.ID, post.Body, post.CreatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, mapError(err, "error while editing post")
+	}
+
+	if !held {
+		s.processMentions(ctx, post)
+	} else if err := s.loadMentions(ctx, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// ReleasePost publishes a held post or post edit once a moderator approves it. It runs in
+// the approval's transaction, so mentioned users are notified and a newly published post is
+// streamed to followers just before it commits.
+func (s *Service) ReleasePost(ctx context.Context, hold contentfilter.Hold) error {
+	current, err := s.repository.GetPost(ctx, hold.SubjectID)
+	if err != nil {
+		return mapNotFound(err, "error while releasing post")
+	}
+	post, err := s.repository.UpdatePostBody(ctx, hold.SubjectID, hold.Content, false)
+	if err != nil {
+		return mapNotFound(err, "error while releasing post")
+	}
+	if _, err := s.hashtags.IndexPost(ctx, post.ID, post.Body, post.CreatedAt); err != nil {
+		return fmt.Errorf("error while releasing post %w", err)
+	}
+	s.processMentions(ctx, post)
+	if current.Held {
+		s.publishFeedItem(ctx, post)
+	}
+	return nil
+}
+
+// DeletePost removes one of the user's posts along with its media attachments, hashtags,
+// mentions and held edits
+func (s *Service) DeletePost(ctx context.Context, userID int, id int64) error {
+	post, err := s.requireAuthor(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, post)
+}
+
+// RemovePost deletes any post on behalf of a moderator, like DeletePost
+func (s *Service) RemovePost(ctx context.Context, id int64) error {
+	post, err := s.repository.GetPost(database.WithPrimary(ctx), id)
+	if err != nil {
+		return mapNotFound(err, "error while getting post")
+	}
+	return s.remove(ctx, post)
+}
+
+// remove deletes a post and everything derived from it in one transaction
+func (s *Service) remove(ctx context.Context, post *Post) error {
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.DeletePost(ctx, post.ID); err != nil {
+			return err
+		}
+		if err := s.filter.Withdraw(ctx, contentfilter.ScopePost, post.ID); err != nil {
+			return err
+		}
+		if _, err := s.attach(ctx, post.AuthorID, post.ID, nil); err != nil {
+			return err
+		}
+		if _, err := s.hashtags.IndexPost(ctx, post.ID, "", time.Now()); err != nil {
+			return err
+		}
+		_, err := s.mentions.ProcessContent(ctx, mention.SourcePost, post.ID, post.AuthorID, "")
+		return err
+	})
+	if err != nil {
+		return mapError(err, "error while deleting post")
+	}
+	return nil
+}
+
+// processMentions resolves and stores a written post's mentions. It runs after the post
+// is committed so nobody is notified about a post that was rolled back; a failure is
+// logged and leaves the post without mentions rather than failing the write.
+func (s *Service) processMentions(ctx context.Context, post *Post) {
+	entities, err := s.mentions.ProcessContent(ctx, mention.SourcePost, post.ID, post.AuthorID, post.Body)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to process mentions", "post_id", post.ID, "error", err)
+		entities = []mention.Entity{}
+	}
+	post.Mentions = entities
+}
+
+// publishFeedItem streams a newly published post to its author's followers. Like mentions
+// it runs once the post is written; a failure to list followers is logged, not returned.
+func (s *Service) publishFeedItem(ctx context.Context, post *Post) {
+	if s.publisher == nil {
+		return
+	}
+	followerIDs, err := s.followers.FollowerIDs(ctx, post.AuthorID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list followers", "post_id", post.ID, "error", err)
+		return
+	}
+	for _, id := range followerIDs {
+		s.publisher.Publish(id, stream.TypeFeedItem, post)
+	}
+}
+
+// attach replaces a post's media and returns the attached items in order
+func (s *Service) attach(ctx context.Context, authorID int, postID int64, mediaIDs []int64) ([]media.Media, error) {
+	if err := s.media.AttachToPost(ctx, authorID, postID, &media.AttachRequest{MediaIDs: mediaIDs}); err != nil {
+		return nil, err
+	}
+	if len(mediaIDs) == 0 {
+		return []media.Media{}, nil
+	}
+	return s.media.GetPostMedia(ctx, postID)
+}
+
+// load attaches the stored mention entities and media to a post
+func (s *Service) load(ctx context.Context, post *Post) error {
+	if err := s.loadMentions(ctx, post); err != nil {
+		return err
+	}
+
+	var err error
+	if post.Media, err = s.media.GetPostMedia(ctx, post.ID); err != nil {
+		return fmt.Errorf("error while getting post media %w", err)
+	}
+	return nil
+}
+
+// loadMentions attaches the stored mention entities to a post
+func (s *Service) loadMentions(ctx context.Context, post *Post) error {
+	entities, err := s.mentions.GetEntities(ctx, mention.SourcePost, post.ID)
+	if err != nil {
+		return fmt.Errorf("error while getting mentions %w", err)
+	}
+	if entities == nil {
+		entities = []mention.Entity{}
+	}
+	post.Mentions = entities
+	return nil
+}
+
+// screenBody checks a post body against the content filter and returns the text to store.
+// Rejected bodies fail with 422; masked bodies come back with the matched terms replaced.
+func (s *Service) screenBody(body string) (string, contentfilter.Verdict, error) {
+	body = strings.TrimSpace(body)
+	verdict := s.filter.Check(contentfilter.ScopePost, body)
+	switch verdict.Action {
+	case contentfilter.ActionReject:
+		return "", verdict, apperrors.NewAppError(http.StatusUnprocessableEntity, "post contains disallowed content", nil)
+	case contentfilter.ActionMask:
+		return verdict.Text, verdict, nil
+	}
+	return body, verdict, nil
+}
+
+// validateBody rejects empty or oversized post bodies
+func (s *Service) validateBody(req *PostRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return apperrors.WrapWithMessage(err, http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		return apperrors.WrapWithMessage(fmt.Errorf("empty post body"), http.StatusBadRequest, "post body cannot be empty")
+	}
+	return nil
+}
+
+// requireAuthor returns the post, a 404 for missing posts or a 403 unless the user wrote the post
+func (s *Service) requireAuthor(ctx context.Context, userID int, id int64) (*Post, error) {
+	post, err := s.repository.GetPost(database.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, mapNotFound(err, "error while getting post")
+	}
+	if post.AuthorID != userID {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("user %d does not own post %d", userID, id), http.StatusForbidden, "you can only change your own posts")
+	}
+	return post, nil
+}
+
+// mapNotFound converts the repository's not-found error into a 404
+func mapNotFound(err error, message string) error {
+	if errors.Is(err, errNotFound) {
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "post not found")
+	}
+	return fmt.Errorf("%s %w", message, err)
+}
+
+// mapError passes through errors that already carry a status, such as rejected media,
+// and maps everything else like mapNotFound
+func mapError(err error, message string) error {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return mapNotFound(err, message)
+}
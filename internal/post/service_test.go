@@ -0,0 +1,305 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"learning/internal/contentfilter"
+	apperrors "learning/internal/errors"
+	"learning/internal/media"
+	"learning/internal/mention"
+	"learning/internal/stream"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// stubRepository holds a single post; methods the tests don't reach panic through the nil
+// embedded interface
+type stubRepository struct {
+	RepositoryInterface
+	post *Post
+}
+
+func (r *stubRepository) CreatePost(ctx context.Context, authorID int, body string, held bool) (*Post, error) {
+	r.post = &Post{ID: 1, AuthorID: authorID, Body: body, Held: held, CreatedAt: time.Now()}
+	post := *r.post
+	return &post, nil
+}
+
+func (r *stubRepository) GetPost(ctx context.Context, id int64) (*Post, error) {
+	if r.post == nil || r.post.ID != id {
+		return nil, errNotFound
+	}
+	post := *r.post
+	return &post, nil
+}
+
+func (r *stubRepository) UpdatePostBody(ctx context.Context, id int64, body string, held bool) (*Post, error) {
+	r.post.Body, r.post.Held = body, held
+	post := *r.post
+	return &post, nil
+}
+
+// stubTransactor runs the function directly
+type stubTransactor struct{}
+
+func (stubTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// stubFilter returns action for every body, masking with "***", and records holds
+type stubFilter struct {
+	action    string
+	held      []string
+	withdrawn int
+}
+
+func (f *stubFilter) Check(scope, text string) contentfilter.Verdict {
+	if f.action == contentfilter.ActionMask {
+		return contentfilter.Verdict{Action: f.action, Text: "***"}
+	}
+	return contentfilter.Verdict{Action: f.action, Text: text}
+}
+
+func (f *stubFilter) Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict contentfilter.Verdict) error {
+	f.held = append(f.held, text)
+	return nil
+}
+
+func (f *stubFilter) Withdraw(ctx context.Context, scope string, subjectID int64) error {
+	f.withdrawn++
+	return nil
+}
+
+// stubContent records the bodies indexed for hashtags and scanned for mentions
+type stubContent struct {
+	indexed  []string
+	mentions []string
+}
+
+func (c *stubContent) IndexPost(ctx context.Context, postID int64, body string, createdAt time.Time) ([]string, error) {
+	c.indexed = append(c.indexed, body)
+	return nil, nil
+}
+
+func (c *stubContent) ProcessContent(ctx context.Context, sourceType string, sourceID int64, authorID int, body string) ([]mention.Entity, error) {
+	c.mentions = append(c.mentions, body)
+	return []mention.Entity{}, nil
+}
+
+func (c *stubContent) GetEntities(ctx context.Context, sourceType string, sourceID int64) ([]mention.Entity, error) {
+	return []mention.Entity{}, nil
+}
+
+func (c *stubContent) AttachToPost(ctx context.Context, ownerID int, postID int64, req *media.AttachRequest) error {
+	return nil
+}
+
+func (c *stubContent) GetPostMedia(ctx context.Context, postID int64) ([]media.Media, error) {
+	return []media.Media{}, nil
+}
+
+// stubFeed follows every author with followerIDs and records the feed items published to each
+type stubFeed struct {
+	followerIDs []int
+	published   map[int][]int64
+}
+
+func (f *stubFeed) FollowerIDs(ctx context.Context, userID int) ([]int, error) {
+	return f.followerIDs, nil
+}
+
+func (f *stubFeed) Publish(userID int, eventType string, data any) {
+	if eventType == stream.TypeFeedItem {
+		f.published[userID] = append(f.published[userID], data.(*Post).ID)
+	}
+}
+
+func TestPostsAreFiltered(t *testing.T) {
+	const published = "original body"
+
+	tests := []struct {
+		name       string
+		edit       bool
+		action     string
+		wantStatus int
+		wantBody   string // stored body
+		wantHeld   bool   // post hidden
+		wantPend   bool   // edit held on a published post
+		wantHolds  int
+		wantIndex  int // bodies indexed and scanned for mentions
+	}{
+		{name: "create allowed", action: contentfilter.ActionAllow, wantBody: "new body", wantIndex: 1},
+		{name: "create rejected", action: contentfilter.ActionReject, wantStatus: http.StatusUnprocessableEntity},
+		{name: "create held", action: contentfilter.ActionHold, wantBody: "new body", wantHeld: true, wantHolds: 1},
+		{name: "create masked", action: contentfilter.ActionMask, wantBody: "***", wantIndex: 1},
+		{name: "edit allowed", edit: true, action: contentfilter.ActionAllow, wantBody: "new body", wantIndex: 1},
+		{name: "edit rejected", edit: true, action: contentfilter.ActionReject, wantStatus: http.StatusUnprocessableEntity, wantBody: published},
+		{name: "edit held", edit: true, action: contentfilter.ActionHold, wantBody: published, wantPend: true, wantHolds: 1},
+		{name: "edit masked", edit: true, action: contentfilter.ActionMask, wantBody: "***", wantIndex: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepository{}
+			if tt.edit {
+				repo.post = &Post{ID: 1, AuthorID: 7, Body: published}
+			}
+			filter := &stubFilter{action: tt.action}
+			content := &stubContent{}
+			svc := NewService(repo, stubTransactor{}, filter, content, content, content, nil, nil)
+
+			req := &PostRequest{Body: "  new body  "}
+			var post *Post
+			var err error
+			if tt.edit {
+				post, err = svc.EditPost(context.Background(), 7, 1, req)
+			} else {
+				post, err = svc.CreatePost(context.Background(), 7, req)
+			}
+
+			if tt.wantStatus != 0 {
+				var appErr *apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantStatus {
+					t.Fatalf("error = %v, want status %d", err, tt.wantStatus)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if post.Held != tt.wantHeld || post.EditPending != tt.wantPend {
+					t.Errorf("held = %v, edit pending = %v; want %v, %v", post.Held, post.EditPending, tt.wantHeld, tt.wantPend)
+				}
+			}
+
+			if tt.wantBody == "" {
+				if repo.post != nil {
+					t.Errorf("post stored with body %q", repo.post.Body)
+				}
+			} else if repo.post.Body != tt.wantBody || repo.post.Held != tt.wantHeld {
+				t.Errorf("stored body %q, held %v; want %q, %v", repo.post.Body, repo.post.Held, tt.wantBody, tt.wantHeld)
+			}
+			if len(filter.held) != tt.wantHolds {
+				t.Errorf("holds = %q, want %d", filter.held, tt.wantHolds)
+			}
+			if tt.wantHolds > 0 && filter.held[0] != "new body" {
+				t.Errorf("held text = %q, want the trimmed body", filter.held[0])
+			}
+			if len(content.indexed) != tt.wantIndex || len(content.mentions) != tt.wantIndex {
+				t.Errorf("indexed %q, scanned for mentions %q; want %d each", content.indexed, content.mentions, tt.wantIndex)
+			}
+			if wantWithdrawn := tt.edit && tt.wantIndex > 0; wantWithdrawn != (filter.withdrawn > 0) {
+				t.Errorf("withdrawn %d times, want pending edits withdrawn = %v", filter.withdrawn, wantWithdrawn)
+			}
+			if tt.wantIndex > 0 && (content.indexed[0] != tt.wantBody || content.mentions[0] != tt.wantBody) {
+				t.Errorf("indexed %q, scanned for mentions %q; want %q", content.indexed, content.mentions, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestReleasePost(t *testing.T) {
+	repo := &stubRepository{post: &Post{ID: 1, AuthorID: 7, Body: "held body", Held: true}}
+	content := &stubContent{}
+	svc := NewService(repo, stubTransactor{}, &stubFilter{action: contentfilter.ActionAllow}, content, content, content, nil, nil)
+
+	if _, err := svc.GetPost(context.Background(), 1); err == nil {
+		t.Fatal("GetPost() returned a held post")
+	}
+
+	if err := svc.ReleasePost(context.Background(), contentfilter.Hold{SubjectID: 1, Content: "held body"}); err != nil {
+		t.Fatalf("ReleasePost() error = %v", err)
+	}
+	if repo.post.Held {
+		t.Error("released post is still held")
+	}
+	if len(content.indexed) != 1 || len(content.mentions) != 1 {
+		t.Errorf("indexed %q, scanned for mentions %q; want the released body once each", content.indexed, content.mentions)
+	}
+	if _, err := svc.GetPost(context.Background(), 1); err != nil {
+		t.Errorf("GetPost() after release error = %v", err)
+	}
+}
+
+func (r *stubRepository) DeletePost(ctx context.Context, id int64) error {
+	if r.post == nil || r.post.ID != id {
+		return errNotFound
+	}
+	r.post = nil
+	return nil
+}
+
+func TestRemovePost(t *testing.T) {
+	repo := &stubRepository{post: &Post{ID: 1, AuthorID: 7, Body: "#tag @user"}}
+	filter := &stubFilter{action: contentfilter.ActionAllow}
+	content := &stubContent{}
+	svc := NewService(repo, stubTransactor{}, filter, content, content, content, nil, nil)
+
+	if err := svc.DeletePost(context.Background(), 8, 1); err == nil {
+		t.Fatal("DeletePost() by another user succeeded")
+	}
+	if err := svc.RemovePost(context.Background(), 1); err != nil {
+		t.Fatalf("RemovePost() error = %v", err)
+	}
+	if repo.post != nil {
+		t.Error("post was not deleted")
+	}
+	if filter.withdrawn != 1 || len(content.indexed) != 1 || content.indexed[0] != "" || len(content.mentions) != 1 || content.mentions[0] != "" {
+		t.Errorf("withdrawn %d, indexed %q, mentions %q; want derived content cleared", filter.withdrawn, content.indexed, content.mentions)
+	}
+
+	var appErr *apperrors.AppError
+	if err := svc.RemovePost(context.Background(), 1); !errors.As(err, &appErr) || appErr.Code != http.StatusNotFound {
+		t.Errorf("RemovePost() of a missing post error = %v, want status 404", err)
+	}
+}
+
+func TestFeedItemsReachFollowers(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		edit     bool
+		release  bool
+		wantSent bool
+	}{
+		{name: "created", action: contentfilter.ActionAllow, wantSent: true},
+		{name: "created held", action: contentfilter.ActionHold},
+		{name: "held then released", action: contentfilter.ActionHold, release: true, wantSent: true},
+		{name: "edited", action: contentfilter.ActionAllow, edit: true},
+		{name: "edit released", action: contentfilter.ActionHold, edit: true, release: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepository{}
+			if tt.edit {
+				repo.post = &Post{ID: 1, AuthorID: 7, Body: "original body"}
+			}
+			content := &stubContent{}
+			feed := &stubFeed{followerIDs: []int{8, 9}, published: map[int][]int64{}}
+			svc := NewService(repo, stubTransactor{}, &stubFilter{action: tt.action}, content, content, content, feed, feed)
+
+			req := &PostRequest{Body: "new body"}
+			var err error
+			if tt.edit {
+				_, err = svc.EditPost(context.Background(), 7, 1, req)
+			} else {
+				_, err = svc.CreatePost(context.Background(), 7, req)
+			}
+			if err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+			if tt.release {
+				if err := svc.ReleasePost(context.Background(), contentfilter.Hold{SubjectID: 1, Content: "new body"}); err != nil {
+					t.Fatalf("ReleasePost() error = %v", err)
+				}
+			}
+
+			for _, id := range feed.followerIDs {
+				if sent := len(feed.published[id]) == 1 && feed.published[id][0] == 1; sent != tt.wantSent || len(feed.published[id]) > 1 {
+					t.Errorf("follower %d got feed items %v, want sent = %v", id, feed.published[id], tt.wantSent)
+				}
+			}
+		})
+	}
+}
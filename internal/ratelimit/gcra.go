@@ -0,0 +1,82 @@
+// Package ratelimit limits request rates with the generic cell rate algorithm (GCRA)
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limit allows Rate requests per Period, with bursts of up to Burst requests
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// PerMinute returns a limit of rate requests per minute with an equal burst
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute, Burst: rate}
+}
+
+// PerHour returns a limit of rate requests per hour with an equal burst
+func PerHour(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Hour, Burst: rate}
+}
+
+// interval is the time one request's worth of capacity takes to replenish
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// String formats the limit as a RateLimit-Policy value, e.g. "10;w=60"
+func (l Limit) String() string {
+	return fmt.Sprintf("%d;w=%d", l.Rate, int(l.Period.Seconds()))
+}
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	ResetAfter time.Duration // until capacity is fully replenished
+	RetryAfter time.Duration // until the next request would be allowed; zero when allowed
+}
+
+// allow applies a request at now to a key with the given theoretical arrival time (TAT), the
+// time at which the key's capacity is full again; unseen keys have a zero TAT. Each request
+// pushes the TAT one interval further and is allowed if it stays within the burst tolerance
+// of now. The returned TAT is only stored when the request is allowed.
+func allow(tat, now time.Time, limit Limit) (time.Time, Result) {
+	interval := limit.interval()
+	tolerance := interval * time.Duration(limit.Burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-tolerance)
+	if now.Before(allowAt) {
+		return tat, Result{
+			Allowed:    false,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+	return newTAT, allowed(newTAT, now, limit)
+}
+
+// allowed builds the result of an allowed request that moved the TAT to newTAT
+func allowed(newTAT, now time.Time, limit Limit) Result {
+	interval := limit.interval()
+	tolerance := interval * time.Duration(limit.Burst)
+	return Result{
+		Allowed:    true,
+		Remaining:  int(now.Sub(newTAT.Add(-tolerance)) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}
+}
+
+// denied builds the result of a request refused for a key whose TAT is tat
+func denied(tat, now time.Time, limit Limit) Result {
+	_, result := allow(tat, now, limit)
+	return result
+}
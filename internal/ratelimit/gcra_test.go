@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	// Two requests per second: each takes 500ms to replenish and two fit in a burst
+	limit := Limit{Rate: 2, Period: time.Second, Burst: 2}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name string
+		at   time.Duration
+		want Result
+	}{
+		{name: "unseen key", at: 0, want: Result{Allowed: true, Remaining: 1, ResetAfter: 500 * time.Millisecond}},
+		{name: "end of burst", at: 0, want: Result{Allowed: true, Remaining: 0, ResetAfter: time.Second}},
+		{name: "over burst", at: 0, want: Result{Allowed: false, ResetAfter: time.Second, RetryAfter: 500 * time.Millisecond}},
+		{name: "still over burst", at: 250 * time.Millisecond, want: Result{Allowed: false, ResetAfter: 750 * time.Millisecond, RetryAfter: 250 * time.Millisecond}},
+		{name: "one interval replenished", at: 500 * time.Millisecond, want: Result{Allowed: true, Remaining: 0, ResetAfter: time.Second}},
+		{name: "fully replenished", at: 10 * time.Second, want: Result{Allowed: true, Remaining: 1, ResetAfter: 500 * time.Millisecond}},
+	}
+
+	var tat time.Time
+	for _, step := range steps {
+		newTAT, got := allow(tat, start.Add(step.at), limit)
+		if got != step.want {
+			t.Fatalf("%s: allow() = %+v, want %+v", step.name, got, step.want)
+		}
+		if got.Allowed {
+			tat = newTAT
+		}
+	}
+}
+
+func TestDeniedMatchesAllow(t *testing.T) {
+	limit := PerMinute(1)
+	now := time.Now()
+	tat := now.Add(2 * time.Minute)
+
+	_, want := allow(tat, now, limit)
+	if got := denied(tat, now, limit); got != want {
+		t.Errorf("denied() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"learning/internal/logging"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// cleanupInterval is how often stores drop keys whose capacity is full again
+const cleanupInterval = time.Minute
+
+// Store applies requests to per-key rate limit state. Run drops state for keys whose
+// capacity is full again until ctx is cancelled.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	Run(ctx context.Context)
+}
+
+// MemoryStore keeps rate limit state in process. Limits are per instance, so it
+// suits single-instance deployments and tests.
+type MemoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+// Ensure MemoryStore implements Store
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time)}
+}
+
+// Allow applies one request for key
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	tat, result := allow(s.tats[key], now, limit)
+	if result.Allowed {
+		s.tats[key] = tat
+	}
+	return result, nil
+}
+
+// Run drops expired keys on every interval until ctx is cancelled
+func (s *MemoryStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for key, tat := range s.tats {
+				if tat.Before(now) {
+					delete(s.tats, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// PostgresStore keeps rate limit state in the database so limits hold across instances.
+// TATs are stored as microseconds since the Unix epoch; instance clocks are assumed to be in sync.
+type PostgresStore struct {
+	db *database.DataBase
+}
+
+// Ensure PostgresStore implements Store
+var _ Store = (*PostgresStore)(nil)
+
+// NewPostgresStore creates a database-backed store
+func NewPostgresStore(db *database.DataBase) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Allow applies one request for key in a single statement: the TAT only advances when the
+// request fits within the burst tolerance. The parameters are cast because Postgres cannot
+// infer the type of an operand of + from another parameter.
+func (s *PostgresStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	interval := limit.interval().Microseconds()
+	tolerance := interval * int64(limit.Burst)
+
+	var tatMicros int64
+	err := s.db.Pool.QueryRow(ctx, `
+        INSERT INTO rate_limits AS rl (key, tat)
+        VALUES ($1, $2::bigint + $3::bigint)
+        ON CONFLICT (key) DO UPDATE SET tat = GREATEST(rl.tat, $2::bigint) + $3::bigint
+        WHERE GREATEST(rl.tat, $2::bigint) + $3::bigint - $4::bigint <= $2::bigint
+        RETURNING tat
+    `, key, now.UnixMicro(), interval, tolerance).Scan(&tatMicros)
+	if err == nil {
+		return allowed(time.UnixMicro(tatMicros), now, limit), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Result{}, fmt.Errorf("failed to apply rate limit: %w", err)
+	}
+
+	// The conflicting row was left unchanged, so the request is denied
+	err = s.db.Pool.QueryRow(ctx, `SELECT tat FROM rate_limits WHERE key = $1`, key).Scan(&tatMicros)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read rate limit: %w", err)
+	}
+	return denied(time.UnixMicro(tatMicros), now, limit), nil
+}
+
+// Run deletes expired keys on every interval until ctx is cancelled
+func (s *PostgresStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.db.Pool.Exec(ctx, `DELETE FROM rate_limits WHERE tat < $1`, now.UnixMicro()); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("rate limit cleanup failed", "error", err)
+			}
+		}
+	}
+}
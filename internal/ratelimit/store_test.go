@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/database"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testStore checks that a store allows a burst, then denies without advancing the key
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	limit := PerHour(3)
+	key := fmt.Sprintf("test:%d", time.Now().UnixNano())
+
+	for i := range 5 {
+		result, err := store.Allow(ctx, key, limit)
+		if err != nil {
+			t.Fatalf("request %d: Allow() error = %v", i+1, err)
+		}
+		if wantAllowed := i < 3; result.Allowed != wantAllowed {
+			t.Fatalf("request %d: allowed = %v, want %v", i+1, result.Allowed, wantAllowed)
+		}
+		if result.Allowed && result.Remaining != 2-i {
+			t.Errorf("request %d: remaining = %d, want %d", i+1, result.Remaining, 2-i)
+		}
+		if !result.Allowed && (result.RetryAfter <= 0 || result.RetryAfter > limit.interval()) {
+			t.Errorf("request %d: retry after = %v, want within %v", i+1, result.RetryAfter, limit.interval())
+		}
+	}
+
+	other, err := store.Allow(ctx, key+":other", limit)
+	if err != nil || !other.Allowed {
+		t.Errorf("other key: Allow() = %+v, %v; want allowed", other, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+// TestPostgresStore runs against the database in TEST_DATABASE_URL, using a temporary
+// rate_limits table on a single connection so existing state is left alone
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse TEST_DATABASE_URL: %v", err)
+	}
+	config.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer pool.Close()
+
+	_, err = pool.Exec(context.Background(), `
+        CREATE TEMPORARY TABLE rate_limits (
+        key VARCHAR(255) PRIMARY KEY,
+        tat BIGINT NOT NULL
+        )
+    `)
+	if err != nil {
+		t.Fatalf("failed to create rate_limits table: %v", err)
+	}
+
+	testStore(t, NewPostgresStore(&database.DataBase{Pool: pool}))
+}
@@ -0,0 +1,159 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"learning/internal/database"
+	"learning/internal/logging"
+	"sync"
+	"time"
+)
+
+// Message is a topic event carried between API instances by a Broker
+type Message struct {
+	Topic string          `json:"topic"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Broker carries messages to every API instance, including the publishing one
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+	Subscribe(deliver func(Message))
+}
+
+// MemoryBroker delivers messages within the current process only.
+// It is suitable for single-instance deployments and tests.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers []func(Message)
+}
+
+// Ensure MemoryBroker implements Broker
+var _ Broker = (*MemoryBroker)(nil)
+
+// NewMemoryBroker creates a new in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Publish delivers msg synchronously to all subscribers
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, deliver := range b.handlers {
+		deliver(msg)
+	}
+	return nil
+}
+
+// Subscribe registers a delivery callback
+func (b *MemoryBroker) Subscribe(deliver func(Message)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, deliver)
+}
+
+// PostgresChannel is the LISTEN/NOTIFY channel used by PostgresBroker
+const PostgresChannel = "realtime_events"
+
+// maxNotifyPayload is PostgreSQL's limit on NOTIFY payloads (8000 bytes, minus headroom)
+const maxNotifyPayload = 7900
+
+// PostgresBroker fans messages out across instances with LISTEN/NOTIFY.
+// Run must be started for the instance to receive messages.
+type PostgresBroker struct {
+	db *database.DataBase
+
+	mu       sync.RWMutex
+	handlers []func(Message)
+}
+
+// Ensure PostgresBroker implements Broker
+var _ Broker = (*PostgresBroker)(nil)
+
+// NewPostgresBroker creates a new LISTEN/NOTIFY broker
+func NewPostgresBroker(db *database.DataBase) *PostgresBroker {
+	return &PostgresBroker{db: db}
+}
+
+// Publish sends msg to every listening instance via pg_notify
+func (b *PostgresBroker) Publish(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode realtime message: %w", err)
+	}
+	if len(payload) > maxNotifyPayload {
+		return fmt.Errorf("realtime message for %s is %d bytes, over the NOTIFY limit", msg.Topic, len(payload))
+	}
+
+	if _, err := b.db.Pool.Exec(ctx, `SELECT pg_notify($1, $2)`, PostgresChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to publish realtime message: %w", err)
+	}
+	return nil
+}
+
+// Subscribe registers a delivery callback
+func (b *PostgresBroker) Subscribe(deliver func(Message)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, deliver)
+}
+
+// Run listens for notifications until ctx is cancelled, reconnecting with backoff on errors
+func (b *PostgresBroker) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("realtime listener stopped, reconnecting", "backoff", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// listen holds a dedicated connection on the channel and dispatches each notification
+func (b *PostgresBroker) listen(ctx context.Context) error {
+	pooled, err := b.db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire listener connection: %w", err)
+	}
+
+	// Take the connection out of the pool so a LISTENing session is never handed to queries
+	conn := pooled.Hijack()
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+PostgresChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", PostgresChannel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+
+		var msg Message
+		if err := json.Unmarshal([]byte(notification.Payload), &msg); err != nil {
+			logging.FromContext(ctx).Warn("ignoring malformed realtime message", "error", err)
+			continue
+		}
+
+		b.mu.RLock()
+		for _, deliver := range b.handlers {
+			deliver(msg)
+		}
+		b.mu.RUnlock()
+	}
+}
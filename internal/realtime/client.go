@@ -0,0 +1,142 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Connection limits and keep-alive timings
+const (
+	writeWait          = 10 * time.Second
+	pongWait           = 60 * time.Second
+	pingPeriod         = (pongWait * 9) / 10
+	maxMessageSize     = 4096
+	sendBuffer         = 64
+	maxTopicsPerClient = 100
+)
+
+// Client is a single WebSocket connection
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	userID int
+	topics map[string]struct{} // guarded by hub.mu
+
+	out  chan ServerMessage
+	done chan struct{}
+	once sync.Once
+}
+
+// newClient wraps an upgraded connection for the given user
+func newClient(hub *Hub, conn *websocket.Conn, userID int) *Client {
+	return &Client{
+		hub:    hub,
+		conn:   conn,
+		userID: userID,
+		topics: make(map[string]struct{}),
+		out:    make(chan ServerMessage, sendBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// send queues a frame for the client, disconnecting it if it cannot keep up
+func (c *Client) send(msg ServerMessage) {
+	select {
+	case <-c.done:
+	case c.out <- msg:
+	default:
+		c.shutdown()
+	}
+}
+
+// shutdown signals the write pump to close the connection
+func (c *Client) shutdown() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// serve runs the connection until the client goes away or the hub shuts down
+func (c *Client) serve(ctx context.Context) {
+	if !c.hub.register(c) {
+		_ = c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(writeWait))
+		_ = c.conn.Close()
+		return
+	}
+	defer c.hub.unregister(c)
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writePump()
+	}()
+
+	c.readPump(ctx)
+	c.shutdown()
+	<-writerDone
+}
+
+// readPump processes client frames until the connection fails
+func (c *Client) readPump(ctx context.Context) {
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg ClientMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		// Any frame proves the client is alive
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		switch msg.Type {
+		case TypeSubscribe:
+			if err := c.hub.subscribe(ctx, c, msg.Topic); err != nil {
+				c.send(ServerMessage{Type: TypeError, Topic: msg.Topic, ID: msg.ID, Message: err.Error()})
+				continue
+			}
+			c.send(ServerMessage{Type: TypeSubscribed, Topic: msg.Topic, ID: msg.ID})
+		case TypeUnsubscribe:
+			c.hub.unsubscribe(c, msg.Topic)
+			c.send(ServerMessage{Type: TypeUnsubscribed, Topic: msg.Topic, ID: msg.ID})
+		case TypePing:
+			c.send(ServerMessage{Type: TypePong, ID: msg.ID})
+		default:
+			c.send(ServerMessage{Type: TypeError, ID: msg.ID, Message: "unknown message type"})
+		}
+	}
+}
+
+// writePump writes queued frames and keep-alive pings, closing the connection on shutdown
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(writeWait))
+			return
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
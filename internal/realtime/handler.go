@@ -0,0 +1,80 @@
+package realtime
+
+import (
+	"context"
+	"learning/internal/logging"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// Handler serves the WebSocket gateway and presence lookups
+type Handler struct {
+	hub      *Hub
+	presence *Presence
+	upgrader websocket.Upgrader
+}
+
+// NewHandler creates a new realtime handler
+func NewHandler(hub *Hub, presence *Presence) *Handler {
+	return &Handler{
+		hub:      hub,
+		presence: presence,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+	}
+}
+
+// RegisterRoutes registers realtime routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.Handle("/ws", middleware.RequireAuth(http.HandlerFunc(h.Connect))).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/presence", h.GetPresence).Methods(http.MethodGet)
+}
+
+// Connect upgrades the request to a WebSocket and serves it until it closes
+func (h *Handler) Connect(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		logging.FromContext(r.Context()).Warn("websocket upgrade failed", "error", err)
+		return
+	}
+
+	// The request context ends when the handler returns, so presence updates
+	// on disconnect use their own context
+	h.presence.Connect(r.Context(), userID)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.presence.Disconnect(ctx, userID)
+	}()
+
+	newClient(h.hub, conn, userID).serve(r.Context())
+}
+
+// GetPresence handles retrieval of a user's online status
+func (h *Handler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	status, err := h.presence.Status(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("presence lookup failed", "error", err)
+		utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, status)
+}
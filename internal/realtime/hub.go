@@ -0,0 +1,192 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"learning/internal/logging"
+	"log/slog"
+	"sync"
+)
+
+// Authorizer decides whether a user may subscribe to a topic
+type Authorizer interface {
+	CanSubscribe(ctx context.Context, userID int, kind string, id int64) (bool, error)
+}
+
+// MembershipChecker reports whether a user belongs to a conversation
+type MembershipChecker interface {
+	IsMember(ctx context.Context, conversationID int64, userID int) (bool, error)
+}
+
+// defaultAuthorizer allows a user's own feed, any post and any presence topic.
+// Conversations require membership and are denied when no checker is configured.
+type defaultAuthorizer struct {
+	members MembershipChecker
+}
+
+// NewAuthorizer returns the default topic policy using members for conversation topics
+func NewAuthorizer(members MembershipChecker) Authorizer {
+	return defaultAuthorizer{members: members}
+}
+
+func (a defaultAuthorizer) CanSubscribe(ctx context.Context, userID int, kind string, id int64) (bool, error) {
+	switch kind {
+	case TopicFeed:
+		return int64(userID) == id, nil
+	case TopicPost, TopicPresence:
+		return true, nil
+	case TopicConversation:
+		if a.members == nil {
+			return false, nil
+		}
+		return a.members.IsMember(ctx, id, userID)
+	default:
+		return false, nil
+	}
+}
+
+// Hub tracks local client subscriptions and relays topic events through a Broker
+// so that clients connected to any instance receive them
+type Hub struct {
+	broker     Broker
+	authorizer Authorizer
+
+	mu      sync.RWMutex
+	topics  map[string]map[*Client]struct{}
+	clients map[*Client]struct{}
+	closed  bool
+}
+
+// NewHub creates a new hub on top of broker. A nil authorizer uses the default policy.
+func NewHub(broker Broker, authorizer Authorizer) *Hub {
+	if authorizer == nil {
+		authorizer = defaultAuthorizer{}
+	}
+	h := &Hub{
+		broker:     broker,
+		authorizer: authorizer,
+		topics:     make(map[string]map[*Client]struct{}),
+		clients:    make(map[*Client]struct{}),
+	}
+	broker.Subscribe(h.deliver)
+	return h
+}
+
+// Broadcast publishes an event on a topic to every subscribed client on every instance
+func (h *Hub) Broadcast(ctx context.Context, topic, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+
+	if err := h.broker.Publish(ctx, Message{Topic: topic, Event: event, Data: payload}); err != nil {
+		return fmt.Errorf("failed to broadcast on %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Publish sends an event to the user's feed topic. It satisfies notification.Publisher.
+func (h *Hub) Publish(userID int, event string, data any) {
+	if err := h.Broadcast(context.Background(), Topic(TopicFeed, int64(userID)), event, data); err != nil {
+		slog.Error("realtime publish failed", "user_id", userID, "error", err)
+	}
+}
+
+// deliver hands a brokered message to the local clients subscribed to its topic
+func (h *Hub) deliver(msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	frame := ServerMessage{Type: TypeEvent, Topic: msg.Topic, Event: msg.Event, Data: msg.Data}
+	for c := range h.topics[msg.Topic] {
+		c.send(frame)
+	}
+}
+
+// register adds a connected client to the hub
+func (h *Hub) register(c *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	return true
+}
+
+// unregister removes a client and all of its subscriptions
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients, c)
+	for topic := range c.topics {
+		h.removeLocked(c, topic)
+	}
+}
+
+// subscribe authorizes and adds a topic subscription for the client
+func (h *Hub) subscribe(ctx context.Context, c *Client, topic string) error {
+	kind, id, err := ParseTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := h.authorizer.CanSubscribe(ctx, c.userID, kind, id)
+	if err != nil {
+		logging.FromContext(ctx).Error("realtime authorization failed", "topic", topic, "error", err)
+		return fmt.Errorf("subscription failed")
+	}
+	if !allowed {
+		return fmt.Errorf("not allowed to subscribe to %s", topic)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(c.topics) >= maxTopicsPerClient {
+		return fmt.Errorf("too many subscriptions")
+	}
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Client]struct{})
+	}
+	h.topics[topic][c] = struct{}{}
+	c.topics[topic] = struct{}{}
+	return nil
+}
+
+// unsubscribe removes a topic subscription for the client
+func (h *Hub) unsubscribe(c *Client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c, topic)
+}
+
+// removeLocked drops one subscription. Callers hold h.mu.
+func (h *Hub) removeLocked(c *Client, topic string) {
+	delete(c.topics, topic)
+	if subs, ok := h.topics[topic]; ok {
+		delete(subs, c)
+		if len(subs) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Close disconnects every client and rejects new connections. It is registered with
+// http.Server.RegisterOnShutdown because hijacked connections are not tracked by Shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.shutdown()
+	}
+}
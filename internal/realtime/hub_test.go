@@ -0,0 +1,176 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// stubMembers reports membership of conversation 1 for user 7 only
+type stubMembers struct{}
+
+func (stubMembers) IsMember(ctx context.Context, conversationID int64, userID int) (bool, error) {
+	return conversationID == 1 && userID == 7, nil
+}
+
+// newTestClient registers a client without a connection; frames queue on its out channel
+func newTestClient(t *testing.T, hub *Hub, userID int) *Client {
+	t.Helper()
+	c := newClient(hub, nil, userID)
+	if !hub.register(c) {
+		t.Fatal("register() refused the client")
+	}
+	return c
+}
+
+// received drains the frames queued for a client
+func received(c *Client) []ServerMessage {
+	var frames []ServerMessage
+	for {
+		select {
+		case msg := <-c.out:
+			frames = append(frames, msg)
+		default:
+			return frames
+		}
+	}
+}
+
+func TestSubscribeAuthorization(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), NewAuthorizer(stubMembers{}))
+
+	tests := []struct {
+		topic   string
+		wantErr bool
+	}{
+		{topic: "feed:7"},
+		{topic: "feed:8", wantErr: true},
+		{topic: "post:3"},
+		{topic: "presence:8"},
+		{topic: "conversation:1"},
+		{topic: "conversation:2", wantErr: true},
+		{topic: "group:1", wantErr: true},
+		{topic: "post:0", wantErr: true},
+		{topic: "post", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			c := newTestClient(t, hub, 7)
+			defer hub.unregister(c)
+
+			err := hub.subscribe(context.Background(), c, tt.topic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("subscribe(%q) error = %v, want error %v", tt.topic, err, tt.wantErr)
+			}
+			if _, subscribed := hub.topics[tt.topic][c]; subscribed == tt.wantErr {
+				t.Errorf("subscribed = %v after subscribe(%q)", subscribed, tt.topic)
+			}
+		})
+	}
+
+	t.Run("no membership checker", func(t *testing.T) {
+		hub := NewHub(NewMemoryBroker(), nil)
+		c := newTestClient(t, hub, 7)
+		if err := hub.subscribe(context.Background(), c, "conversation:1"); err == nil {
+			t.Error("subscribe() to a conversation succeeded without a membership checker")
+		}
+	})
+}
+
+func TestHubFanOut(t *testing.T) {
+	// Two hubs on one broker stand in for two API instances
+	broker := NewMemoryBroker()
+	local, remote := NewHub(broker, nil), NewHub(broker, nil)
+	ctx := context.Background()
+
+	a := newTestClient(t, local, 1)
+	b := newTestClient(t, remote, 2)
+	other := newTestClient(t, local, 3)
+	for _, c := range []*Client{a, b} {
+		if err := c.hub.subscribe(ctx, c, "post:5"); err != nil {
+			t.Fatalf("subscribe() error = %v", err)
+		}
+	}
+	if err := other.hub.subscribe(ctx, other, "post:6"); err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+
+	if err := remote.Broadcast(ctx, "post:5", "comment", map[string]int{"id": 9}); err != nil {
+		t.Fatalf("Broadcast() error = %v", err)
+	}
+	for name, c := range map[string]*Client{"local": a, "remote": b} {
+		frames := received(c)
+		if len(frames) != 1 {
+			t.Fatalf("%s subscriber got %d frames, want 1", name, len(frames))
+		}
+		if f := frames[0]; f.Type != TypeEvent || f.Topic != "post:5" || f.Event != "comment" || string(f.Data) != `{"id":9}` {
+			t.Errorf("%s subscriber got %+v", name, f)
+		}
+	}
+	if frames := received(other); len(frames) != 0 {
+		t.Errorf("subscriber to another topic got %v", frames)
+	}
+
+	local.unsubscribe(a, "post:5")
+	remote.unregister(b)
+	if err := local.Broadcast(ctx, "post:5", "comment", 10); err != nil {
+		t.Fatalf("Broadcast() error = %v", err)
+	}
+	if frames := append(received(a), received(b)...); len(frames) != 0 {
+		t.Errorf("unsubscribed clients got %v", frames)
+	}
+	if len(local.topics) != 1 || len(remote.topics) != 0 {
+		t.Errorf("topics left: local %v, remote %v; want only post:6", local.topics, remote.topics)
+	}
+}
+
+func TestHubPublishesToFeed(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	c := newTestClient(t, hub, 7)
+	if err := hub.subscribe(context.Background(), c, "feed:7"); err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+
+	hub.Publish(7, "notification", map[string]string{"type": "follow"})
+	hub.Publish(8, "notification", map[string]string{"type": "follow"})
+
+	frames := received(c)
+	if len(frames) != 1 || frames[0].Topic != "feed:7" || frames[0].Event != "notification" {
+		t.Fatalf("feed subscriber got %+v, want one notification", frames)
+	}
+	var data map[string]string
+	if err := json.Unmarshal(frames[0].Data, &data); err != nil || data["type"] != "follow" {
+		t.Errorf("event data = %s, %v", frames[0].Data, err)
+	}
+}
+
+func TestSubscriptionLimit(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	c := newTestClient(t, hub, 7)
+
+	for i := range maxTopicsPerClient {
+		if err := hub.subscribe(context.Background(), c, fmt.Sprintf("post:%d", i+1)); err != nil {
+			t.Fatalf("subscribe() %d error = %v", i+1, err)
+		}
+	}
+	if err := hub.subscribe(context.Background(), c, "post:1000"); err == nil {
+		t.Error("subscribe() past the limit succeeded")
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	c := newTestClient(t, hub, 7)
+
+	hub.Close()
+	select {
+	case <-c.done:
+	default:
+		t.Error("Close() left a client connected")
+	}
+	if hub.register(newClient(hub, nil, 8)) {
+		t.Error("register() after Close succeeded")
+	}
+}
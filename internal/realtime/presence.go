@@ -0,0 +1,233 @@
+package realtime
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"learning/internal/logging"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// presenceInterval is how often connected users' presence is refreshed
+const presenceInterval = 30 * time.Second
+
+// PresenceStatus is a user's online state as returned in API responses
+type PresenceStatus struct {
+	UserID     int        `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// PresenceRepositoryInterface defines the shared presence storage used by Presence
+type PresenceRepositoryInterface interface {
+	Touch(ctx context.Context, instanceID string, userIDs []int, onlineUntil time.Time) error
+	Leave(ctx context.Context, instanceID string, userID int) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+	Get(ctx context.Context, userID int) (*PresenceStatus, error)
+}
+
+// Ensure PresenceRepository implements PresenceRepositoryInterface
+var _ PresenceRepositoryInterface = (*PresenceRepository)(nil)
+
+// PresenceRepository persists presence so it is shared by all API instances
+type PresenceRepository struct {
+	db *database.DataBase
+}
+
+// NewPresenceRepository creates a new presence repository
+func NewPresenceRepository(db *database.DataBase) *PresenceRepository {
+	return &PresenceRepository{db: db}
+}
+
+// Touch records that instanceID holds connections for users until onlineUntil, and marks
+// the users as seen now and online until at least then
+func (r *PresenceRepository) Touch(ctx context.Context, instanceID string, userIDs []int, onlineUntil time.Time) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`
+        INSERT INTO user_presence_instances (user_id, instance_id, expires_at)
+        SELECT id, $2, $3 FROM UNNEST($1::INTEGER[]) AS id
+        ON CONFLICT (user_id, instance_id) DO UPDATE SET expires_at = EXCLUDED.expires_at
+    `, userIDs, instanceID, onlineUntil)
+	batch.Queue(`
+        INSERT INTO user_presence (user_id, last_seen_at, online_until)
+        SELECT id, $2, $3 FROM UNNEST($1::INTEGER[]) AS id
+        ON CONFLICT (user_id) DO UPDATE
+        SET last_seen_at = EXCLUDED.last_seen_at,
+            online_until = GREATEST(user_presence.online_until, EXCLUDED.online_until)
+    `, userIDs, time.Now(), onlineUntil)
+
+	if err := r.db.Querier(ctx).SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to update presence: %w", err)
+	}
+	return nil
+}
+
+// Leave records that instanceID no longer holds connections for a user and reports whether
+// another instance still does. The user stays online until the last of those expires.
+//
+// The two statements run separately so that of two instances leaving at once, the later
+// one sees both rows gone.
+func (r *PresenceRepository) Leave(ctx context.Context, instanceID string, userID int) (bool, error) {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        DELETE FROM user_presence_instances WHERE user_id = $1 AND instance_id = $2
+    `, userID, instanceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to leave presence: %w", err)
+	}
+
+	now := time.Now()
+	var online bool
+	err = r.db.Querier(ctx).QueryRow(ctx, `
+        UPDATE user_presence
+        SET last_seen_at = $2,
+            online_until = COALESCE((
+                SELECT MAX(expires_at) FROM user_presence_instances
+                WHERE user_id = $1 AND expires_at > $2
+            ), $2)
+        WHERE user_id = $1
+        RETURNING online_until > $2
+    `, userID, now).Scan(&online)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to update presence: %w", err)
+	}
+	return online, nil
+}
+
+// DeleteExpired removes instance records that were not refreshed in time, such as those
+// of an instance that died
+func (r *PresenceRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	if _, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM user_presence_instances WHERE expires_at <= $1`, now); err != nil {
+		return fmt.Errorf("failed to delete expired presence: %w", err)
+	}
+	return nil
+}
+
+// Get returns the stored presence of a user
+func (r *PresenceRepository) Get(ctx context.Context, userID int) (*PresenceStatus, error) {
+	status := &PresenceStatus{UserID: userID}
+
+	var onlineUntil *time.Time
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT last_seen_at, online_until FROM user_presence WHERE user_id = $1
+    `, userID).Scan(&status.LastSeenAt, &onlineUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return status, nil
+		}
+		return nil, fmt.Errorf("failed to get presence: %w", err)
+	}
+
+	status.Online = onlineUntil != nil && onlineUntil.After(time.Now())
+	return status, nil
+}
+
+// Presence tracks which users have open connections on this instance. A user is online
+// while any instance holds a connection for them.
+type Presence struct {
+	repo       PresenceRepositoryInterface
+	hub        *Hub
+	instanceID string // identifies this instance's records in the shared table
+
+	mu    sync.Mutex
+	local map[int]int // user ID -> open connections on this instance
+}
+
+// NewPresence creates a new presence tracker
+func NewPresence(repo PresenceRepositoryInterface, hub *Hub) *Presence {
+	return &Presence{
+		repo:       repo,
+		hub:        hub,
+		instanceID: rand.Text(),
+		local:      make(map[int]int),
+	}
+}
+
+// Connect records a new connection for the user
+func (p *Presence) Connect(ctx context.Context, userID int) {
+	p.mu.Lock()
+	p.local[userID]++
+	first := p.local[userID] == 1
+	p.mu.Unlock()
+
+	if !first {
+		return
+	}
+	if err := p.repo.Touch(ctx, p.instanceID, []int{userID}, time.Now().Add(2*presenceInterval)); err != nil {
+		logging.FromContext(ctx).Error("presence update failed", "user_id", userID, "error", err)
+	}
+	p.broadcast(ctx, userID, true)
+}
+
+// Disconnect records a closed connection for the user, who goes offline once no instance
+// holds a connection for them
+func (p *Presence) Disconnect(ctx context.Context, userID int) {
+	p.mu.Lock()
+	p.local[userID]--
+	last := p.local[userID] <= 0
+	if last {
+		delete(p.local, userID)
+	}
+	p.mu.Unlock()
+
+	if !last {
+		return
+	}
+	online, err := p.repo.Leave(ctx, p.instanceID, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("presence update failed", "user_id", userID, "error", err)
+		return
+	}
+	if !online {
+		p.broadcast(ctx, userID, false)
+	}
+}
+
+// Status returns a user's presence across all instances
+func (p *Presence) Status(ctx context.Context, userID int) (*PresenceStatus, error) {
+	return p.repo.Get(ctx, userID)
+}
+
+// Run refreshes presence for locally connected users until ctx is cancelled.
+// Users on an instance that dies drop offline once its records expire.
+func (p *Presence) Run(ctx context.Context) {
+	ticker := time.NewTicker(presenceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			userIDs := make([]int, 0, len(p.local))
+			for id := range p.local {
+				userIDs = append(userIDs, id)
+			}
+			p.mu.Unlock()
+
+			if err := p.repo.DeleteExpired(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("presence pruning failed", "error", err)
+			}
+			if len(userIDs) == 0 {
+				continue
+			}
+			if err := p.repo.Touch(ctx, p.instanceID, userIDs, time.Now().Add(2*presenceInterval)); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("presence refresh failed", "error", err)
+			}
+		}
+	}
+}
+
+// broadcast announces a user's transition online or offline
+func (p *Presence) broadcast(ctx context.Context, userID int, online bool) {
+	now := time.Now()
+	status := PresenceStatus{UserID: userID, Online: online, LastSeenAt: &now}
+	if err := p.hub.Broadcast(ctx, Topic(TopicPresence, int64(userID)), TopicPresence, status); err != nil {
+		logging.FromContext(ctx).Error("presence broadcast failed", "user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"learning/internal/database"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// memoryPresence is an in-memory PresenceRepository shared by several Presence trackers
+type memoryPresence struct {
+	mu          sync.Mutex
+	instances   map[int]map[string]time.Time // user ID -> instance ID -> expiry
+	onlineUntil map[int]time.Time
+}
+
+func newMemoryPresence() *memoryPresence {
+	return &memoryPresence{instances: map[int]map[string]time.Time{}, onlineUntil: map[int]time.Time{}}
+}
+
+func (m *memoryPresence) Touch(ctx context.Context, instanceID string, userIDs []int, onlineUntil time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range userIDs {
+		if m.instances[id] == nil {
+			m.instances[id] = map[string]time.Time{}
+		}
+		m.instances[id][instanceID] = onlineUntil
+		if onlineUntil.After(m.onlineUntil[id]) {
+			m.onlineUntil[id] = onlineUntil
+		}
+	}
+	return nil
+}
+
+func (m *memoryPresence) Leave(ctx context.Context, instanceID string, userID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.instances[userID], instanceID)
+
+	now := time.Now()
+	m.onlineUntil[userID] = now
+	for _, expires := range m.instances[userID] {
+		if expires.After(m.onlineUntil[userID]) {
+			m.onlineUntil[userID] = expires
+		}
+	}
+	return m.onlineUntil[userID].After(now), nil
+}
+
+func (m *memoryPresence) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+func (m *memoryPresence) Get(ctx context.Context, userID int) (*PresenceStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &PresenceStatus{UserID: userID, Online: m.onlineUntil[userID].After(time.Now())}, nil
+}
+
+// presenceChanges returns the online states broadcast to a presence subscriber
+func presenceChanges(t *testing.T, c *Client) []bool {
+	t.Helper()
+	var changes []bool
+	for _, frame := range received(c) {
+		var status PresenceStatus
+		if err := json.Unmarshal(frame.Data, &status); err != nil {
+			t.Fatalf("invalid presence event %s: %v", frame.Data, err)
+		}
+		changes = append(changes, status.Online)
+	}
+	return changes
+}
+
+func TestPresenceAcrossInstances(t *testing.T) {
+	const userID = 7
+	ctx := context.Background()
+	repo := newMemoryPresence()
+	broker := NewMemoryBroker()
+	hubA, hubB := NewHub(broker, nil), NewHub(broker, nil)
+	a, b := NewPresence(repo, hubA), NewPresence(repo, hubB)
+
+	watcher := newTestClient(t, hubA, 8)
+	if err := hubA.subscribe(ctx, watcher, Topic(TopicPresence, userID)); err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+
+	steps := []struct {
+		name        string
+		presence    *Presence
+		connect     bool
+		wantOnline  bool
+		wantChanges []bool
+	}{
+		{name: "connect on A", presence: a, connect: true, wantOnline: true, wantChanges: []bool{true}},
+		{name: "second connection on A", presence: a, connect: true, wantOnline: true},
+		{name: "connect on B", presence: b, connect: true, wantOnline: true, wantChanges: []bool{true}},
+		{name: "one connection on A closes", presence: a, wantOnline: true},
+		{name: "last connection on A closes while B holds one", presence: a, wantOnline: true},
+		{name: "last connection on B closes", presence: b, wantChanges: []bool{false}},
+	}
+
+	for _, step := range steps {
+		if step.connect {
+			step.presence.Connect(ctx, userID)
+		} else {
+			step.presence.Disconnect(ctx, userID)
+		}
+
+		status, err := b.Status(ctx, userID)
+		if err != nil {
+			t.Fatalf("%s: Status() error = %v", step.name, err)
+		}
+		if status.Online != step.wantOnline {
+			t.Errorf("%s: online = %v, want %v", step.name, status.Online, step.wantOnline)
+		}
+		changes := presenceChanges(t, watcher)
+		if len(changes) != len(step.wantChanges) || (len(changes) == 1 && changes[0] != step.wantChanges[0]) {
+			t.Errorf("%s: broadcast %v, want %v", step.name, changes, step.wantChanges)
+		}
+	}
+}
+
+// TestPresenceRepository runs against the database in TEST_DATABASE_URL, using temporary
+// presence tables on a single connection so existing state is left alone
+func TestPresenceRepository(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse TEST_DATABASE_URL: %v", err)
+	}
+	config.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer pool.Close()
+
+	_, err = pool.Exec(context.Background(), `
+        CREATE TEMPORARY TABLE user_presence (
+        user_id INTEGER PRIMARY KEY,
+        last_seen_at TIMESTAMP NOT NULL,
+        online_until TIMESTAMP NOT NULL
+        );
+        CREATE TEMPORARY TABLE user_presence_instances (
+        user_id INTEGER NOT NULL,
+        instance_id VARCHAR(32) NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (user_id, instance_id)
+        )
+    `)
+	if err != nil {
+		t.Fatalf("failed to create presence tables: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewPresenceRepository(&database.DataBase{Pool: pool})
+	until := time.Now().Add(time.Minute)
+	for _, instance := range []string{"a", "b"} {
+		if err := repo.Touch(ctx, instance, []int{7}, until); err != nil {
+			t.Fatalf("Touch(%s) error = %v", instance, err)
+		}
+	}
+
+	online, err := repo.Leave(ctx, "a", 7)
+	if err != nil || !online {
+		t.Errorf("Leave(a) = %v, %v; want still online through b", online, err)
+	}
+	if status, err := repo.Get(ctx, 7); err != nil || !status.Online {
+		t.Errorf("Get() after leaving a = %+v, %v; want online", status, err)
+	}
+
+	online, err = repo.Leave(ctx, "b", 7)
+	if err != nil || online {
+		t.Errorf("Leave(b) = %v, %v; want offline", online, err)
+	}
+	if status, err := repo.Get(ctx, 7); err != nil || status.Online {
+		t.Errorf("Get() after leaving b = %+v, %v; want offline", status, err)
+	}
+}
@@ -0,0 +1,74 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Client -> server message types
+const (
+	TypeSubscribe   = "subscribe"
+	TypeUnsubscribe = "unsubscribe"
+	TypePing        = "ping"
+)
+
+// Server -> client message types
+const (
+	TypeSubscribed   = "subscribed"
+	TypeUnsubscribed = "unsubscribed"
+	TypePong         = "pong"
+	TypeEvent        = "event"
+	TypeError        = "error"
+)
+
+// Topic kinds a client may subscribe to
+const (
+	TopicFeed         = "feed"         // feed:{user_id}, the user's own feed and notifications
+	TopicPost         = "post"         // post:{post_id}, new comments on a post
+	TopicConversation = "conversation" // conversation:{conversation_id}, direct messages
+	TopicPresence     = "presence"     // presence:{user_id}, online/offline changes
+)
+
+// ClientMessage is a frame sent by the client
+type ClientMessage struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic,omitempty"`
+	ID    string `json:"id,omitempty"`
+}
+
+// ServerMessage is a frame sent by the server
+type ServerMessage struct {
+	Type    string          `json:"type"`
+	Topic   string          `json:"topic,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Topic builds a topic name such as "post:42"
+func Topic(kind string, id int64) string {
+	return kind + ":" + strconv.FormatInt(id, 10)
+}
+
+// ParseTopic splits a topic into its kind and numeric ID
+func ParseTopic(topic string) (string, int64, error) {
+	kind, idStr, ok := strings.Cut(topic, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid topic %q", topic)
+	}
+
+	switch kind {
+	case TopicFeed, TopicPost, TopicConversation, TopicPresence:
+	default:
+		return "", 0, fmt.Errorf("unknown topic kind %q", kind)
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("invalid topic id in %q", topic)
+	}
+	return kind, id, nil
+}
@@ -0,0 +1,21 @@
+package realtime
+
+import (
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes presence -> handler on top of hub and registers routes.
+// The returned presence tracker's Run loop must be started by the caller.
+func Register(r *mux.Router, db *database.DataBase, hub *Hub) *Presence {
+	presence := NewPresence(NewPresenceRepository(db), hub)
+	h := NewHandler(hub, presence)
+	h.RegisterRoutes(r)
+	return presence
+}
@@ -0,0 +1,109 @@
+package relationship
+
+import (
+	"context"
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles follow and block HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new relationship handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers follow and block routes; all of them act for the authenticated user
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	ar := r.NewRoute().Subrouter()
+	ar.Use(middleware.RequireAuth)
+
+	ar.HandleFunc("/users/me/blocks", h.ListBlocks).Methods(http.MethodGet)
+	ar.HandleFunc("/users/{id}/relationship", h.Get).Methods(http.MethodGet)
+	ar.HandleFunc("/users/{id}/follow", h.Follow).Methods(http.MethodPut)
+	ar.HandleFunc("/users/{id}/follow", h.Unfollow).Methods(http.MethodDelete)
+	ar.HandleFunc("/users/{id}/block", h.Block).Methods(http.MethodPut)
+	ar.HandleFunc("/users/{id}/block", h.Unblock).Methods(http.MethodDelete)
+}
+
+// Get handles retrieval of the caller's relationship with a user
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.service.GetRelationship)
+}
+
+// Follow handles following a user
+func (h *Handler) Follow(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.service.Follow)
+}
+
+// Unfollow handles unfollowing a user
+func (h *Handler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.service.Unfollow)
+}
+
+// Block handles blocking a user
+func (h *Handler) Block(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.service.Block)
+}
+
+// Unblock handles unblocking a user
+func (h *Handler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.service.Unblock)
+}
+
+// ListBlocks handles listing the users the caller has blocked
+func (h *Handler) ListBlocks(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	blocks, err := h.service.ListBlocks(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, blocks)
+}
+
+// act runs a relationship operation between the caller and the user in the path
+func (h *Handler) act(w http.ResponseWriter, r *http.Request, op func(ctx context.Context, userID, otherID int) (*Relationship, error)) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	otherID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || otherID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	rel, err := op(r.Context(), userID, otherID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, rel)
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
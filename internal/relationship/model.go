@@ -0,0 +1,18 @@
+package relationship
+
+import "time"
+
+// Relationship describes how the requesting user relates to another user
+type Relationship struct {
+	UserID     int  `json:"user_id"`
+	Following  bool `json:"following"`
+	FollowedBy bool `json:"followed_by"`
+	Blocking   bool `json:"blocking"`
+}
+
+// Block is a user the requesting user has blocked
+type Block struct {
+	UserID    int       `json:"user_id" db:"blocked_id"`
+	Username  string    `json:"username" db:"username"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,209 @@
+package relationship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Sentinel errors returned by the repository
+var (
+	errUserNotFound = errors.New("user not found")
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for follows and blocks
+type RepositoryInterface interface {
+	IsActiveUser(ctx context.Context, id int) (bool, error)
+	Follow(ctx context.Context, followerID, followeeID int) (bool, error)
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+	Block(ctx context.Context, blockerID, blockedID int) error
+	Unblock(ctx context.Context, blockerID, blockedID int) error
+	RemoveFollows(ctx context.Context, userID, otherID int) error
+	IsFollower(ctx context.Context, followerID, followeeID int) (bool, error)
+	ListFollowerIDs(ctx context.Context, followeeID int) ([]int, error)
+	IsBlocked(ctx context.Context, userID, otherID int) (bool, error)
+	GetRelationship(ctx context.Context, userID, otherID int) (*Relationship, error)
+	ListBlocks(ctx context.Context, blockerID, limit, offset int) ([]Block, error)
+}
+
+// NewRepository creates a new relationship repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// IsActiveUser reports whether an active user with the given ID exists
+func (r *Repository) IsActiveUser(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	err := r.db.Querier(ctx).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND active = true)`, id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+	return exists, nil
+}
+
+// Follow records that followerID follows followeeID, reporting whether the follow is new
+func (r *Repository) Follow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `
+        INSERT INTO follows (follower_id, followee_id, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING
+    `, followerID, followeeID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to follow user: %w", mapForeignKeyViolation(err))
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Unfollow removes a follow; removing one that does not exist is not an error
+func (r *Repository) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+	return nil
+}
+
+// Block records that blockerID blocks blockedID
+func (r *Repository) Block(ctx context.Context, blockerID, blockedID int) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        INSERT INTO blocks (blocker_id, blocked_id, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING
+    `, blockerID, blockedID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to block user: %w", mapForeignKeyViolation(err))
+	}
+	return nil
+}
+
+// Unblock removes a block; removing one that does not exist is not an error
+func (r *Repository) Unblock(ctx context.Context, blockerID, blockedID int) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+	return nil
+}
+
+// RemoveFollows removes follows between two users in both directions
+func (r *Repository) RemoveFollows(ctx context.Context, userID, otherID int) error {
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        DELETE FROM follows
+        WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)
+    `, userID, otherID)
+	if err != nil {
+		return fmt.Errorf("failed to remove follows: %w", err)
+	}
+	return nil
+}
+
+// IsFollower reports whether followerID follows followeeID
+func (r *Repository) IsFollower(ctx context.Context, followerID, followeeID int) (bool, error) {
+	var follows bool
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)
+    `, followerID, followeeID).Scan(&follows)
+	if err != nil {
+		return false, fmt.Errorf("failed to check follow: %w", err)
+	}
+	return follows, nil
+}
+
+// ListFollowerIDs returns the IDs of the active users following followeeID
+func (r *Repository) ListFollowerIDs(ctx context.Context, followeeID int) ([]int, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, `
+        SELECT f.follower_id
+        FROM follows f
+        JOIN users u ON u.id = f.follower_id
+        WHERE f.followee_id = $1 AND u.active = true
+    `, followeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list followers: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan followers: %w", err)
+	}
+	return ids, nil
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (r *Repository) IsBlocked(ctx context.Context, userID, otherID int) (bool, error) {
+	var blocked bool
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+        )
+    `, userID, otherID).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check block: %w", err)
+	}
+	return blocked, nil
+}
+
+// GetRelationship returns how userID relates to otherID
+func (r *Repository) GetRelationship(ctx context.Context, userID, otherID int) (*Relationship, error) {
+	rel := &Relationship{UserID: otherID}
+	err := r.db.Reader(ctx).QueryRow(ctx, `
+        SELECT
+            EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2),
+            EXISTS (SELECT 1 FROM follows WHERE follower_id = $2 AND followee_id = $1),
+            EXISTS (SELECT 1 FROM blocks WHERE blocker_id = $1 AND blocked_id = $2)
+    `, userID, otherID).Scan(&rel.Following, &rel.FollowedBy, &rel.Blocking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationship: %w", err)
+	}
+	return rel, nil
+}
+
+// ListBlocks returns the users blockerID has blocked, most recent first
+func (r *Repository) ListBlocks(ctx context.Context, blockerID, limit, offset int) ([]Block, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, `
+        SELECT b.blocked_id, u.username, b.created_at
+        FROM blocks b
+        JOIN users u ON u.id = b.blocked_id
+        WHERE b.blocker_id = $1
+        ORDER BY b.created_at DESC
+        LIMIT $2 OFFSET $3
+    `, blockerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []Block
+	for rows.Next() {
+		var b Block
+		if err := rows.Scan(&b.UserID, &b.Username, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list blocks: %w", err)
+	}
+	return blocks, nil
+}
+
+// mapForeignKeyViolation converts a reference to a missing user into errUserNotFound
+func mapForeignKeyViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return errUserNotFound
+	}
+	return err
+}
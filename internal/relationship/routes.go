@@ -0,0 +1,23 @@
+package relationship
+
+import (
+	"learning/internal/database"
+	"learning/internal/notification"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes. The returned
+// service answers follow and block checks for messaging, profiles and mentions.
+func Register(r *mux.Router, db *database.DataBase, emitter notification.Emitter) *Service {
+	repo := NewRepository(db)
+	svc := NewService(repo, database.NewTxManager(db), emitter)
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc
+}
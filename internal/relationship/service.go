@@ -0,0 +1,172 @@
+package relationship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/notification"
+	"net/http"
+)
+
+// ServiceInterface defines business operations for follows and blocks
+type ServiceInterface interface {
+	Follow(ctx context.Context, userID, otherID int) (*Relationship, error)
+	Unfollow(ctx context.Context, userID, otherID int) (*Relationship, error)
+	Block(ctx context.Context, userID, otherID int) (*Relationship, error)
+	Unblock(ctx context.Context, userID, otherID int) (*Relationship, error)
+	GetRelationship(ctx context.Context, userID, otherID int) (*Relationship, error)
+	ListBlocks(ctx context.Context, userID, limit, offset int) ([]Block, error)
+	IsFollower(ctx context.Context, followerID, followeeID int) (bool, error)
+	FollowerIDs(ctx context.Context, userID int) ([]int, error)
+	IsBlocked(ctx context.Context, userID, otherID int) (bool, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	transactor database.Transactor
+	emitter    notification.Emitter
+}
+
+// NewService creates a new relationship service. A nil emitter disables follow notifications.
+func NewService(repository RepositoryInterface, transactor database.Transactor, emitter notification.Emitter) *Service {
+	return &Service{
+		repository: repository,
+		transactor: transactor,
+		emitter:    emitter,
+	}
+}
+
+// Follow makes userID follow otherID and notifies otherID of a new follow. Users who
+// have blocked each other cannot follow each other.
+func (s *Service) Follow(ctx context.Context, userID, otherID int) (*Relationship, error) {
+	if err := s.checkTarget(ctx, userID, otherID); err != nil {
+		return nil, err
+	}
+
+	blocked, err := s.repository.IsBlocked(ctx, userID, otherID)
+	if err != nil {
+		return nil, fmt.Errorf("error while checking blocks %w", err)
+	}
+	if blocked {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("users %d and %d are blocked", userID, otherID), http.StatusForbidden, "you cannot follow this user")
+	}
+
+	created, err := s.repository.Follow(ctx, userID, otherID)
+	if err != nil {
+		return nil, mapError(err, "error while following user")
+	}
+	if created && s.emitter != nil {
+		err := s.emitter.Emit(ctx, notification.Event{Type: notification.TypeFollow, RecipientID: otherID, ActorID: userID})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to notify follow", "user_id", otherID, "error", err)
+		}
+	}
+	return s.GetRelationship(database.WithPrimary(ctx), userID, otherID)
+}
+
+// Unfollow stops userID following otherID
+func (s *Service) Unfollow(ctx context.Context, userID, otherID int) (*Relationship, error) {
+	if err := s.repository.Unfollow(ctx, userID, otherID); err != nil {
+		return nil, fmt.Errorf("error while unfollowing user %w", err)
+	}
+	return s.GetRelationship(database.WithPrimary(ctx), userID, otherID)
+}
+
+// Block makes userID block otherID, removing any follows between them in the same transaction
+func (s *Service) Block(ctx context.Context, userID, otherID int) (*Relationship, error) {
+	if err := s.checkTarget(ctx, userID, otherID); err != nil {
+		return nil, err
+	}
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.Block(ctx, userID, otherID); err != nil {
+			return err
+		}
+		return s.repository.RemoveFollows(ctx, userID, otherID)
+	})
+	if err != nil {
+		return nil, mapError(err, "error while blocking user")
+	}
+	return s.GetRelationship(database.WithPrimary(ctx), userID, otherID)
+}
+
+// Unblock removes userID's block on otherID. Follows removed by the block are not restored.
+func (s *Service) Unblock(ctx context.Context, userID, otherID int) (*Relationship, error) {
+	if err := s.repository.Unblock(ctx, userID, otherID); err != nil {
+		return nil, fmt.Errorf("error while unblocking user %w", err)
+	}
+	return s.GetRelationship(database.WithPrimary(ctx), userID, otherID)
+}
+
+// GetRelationship returns how userID relates to otherID
+func (s *Service) GetRelationship(ctx context.Context, userID, otherID int) (*Relationship, error) {
+	rel, err := s.repository.GetRelationship(ctx, userID, otherID)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting relationship %w", err)
+	}
+	return rel, nil
+}
+
+// ListBlocks returns the users userID has blocked
+func (s *Service) ListBlocks(ctx context.Context, userID, limit, offset int) ([]Block, error) {
+	blocks, err := s.repository.ListBlocks(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing blocks %w", err)
+	}
+	if blocks == nil {
+		blocks = []Block{}
+	}
+	return blocks, nil
+}
+
+// IsFollower reports whether followerID follows followeeID. It lets other services gate
+// features such as followers-only inboxes and profile fields.
+func (s *Service) IsFollower(ctx context.Context, followerID, followeeID int) (bool, error) {
+	return s.repository.IsFollower(ctx, followerID, followeeID)
+}
+
+// FollowerIDs returns the IDs of the active users following userID. It lets other services
+// fan content out to followers.
+func (s *Service) FollowerIDs(ctx context.Context, userID int) ([]int, error) {
+	ids, err := s.repository.ListFollowerIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing followers %w", err)
+	}
+	return ids, nil
+}
+
+// IsBlocked reports whether either user has blocked the other. It lets other services
+// keep blocked users from reaching each other.
+func (s *Service) IsBlocked(ctx context.Context, userID, otherID int) (bool, error) {
+	return s.repository.IsBlocked(ctx, userID, otherID)
+}
+
+// checkTarget rejects relationships with oneself and with unknown or inactive users
+func (s *Service) checkTarget(ctx context.Context, userID, otherID int) error {
+	if userID == otherID {
+		return apperrors.WrapWithMessage(fmt.Errorf("user %d targeted themselves", userID), http.StatusBadRequest, "you cannot follow or block yourself")
+	}
+
+	exists, err := s.repository.IsActiveUser(ctx, otherID)
+	if err != nil {
+		return fmt.Errorf("error while checking user %w", err)
+	}
+	if !exists {
+		return apperrors.WrapWithMessage(errUserNotFound, http.StatusNotFound, "user not found")
+	}
+	return nil
+}
+
+// mapError converts the repository's missing-user error into a 404 and wraps anything else
+func mapError(err error, message string) error {
+	if errors.Is(err, errUserNotFound) {
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "user not found")
+	}
+	return fmt.Errorf("%s %w", message, err)
+}
@@ -0,0 +1,30 @@
+package reload
+
+import (
+	"learning/internal/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles configuration status HTTP requests
+type Handler struct {
+	reloader *Reloader
+}
+
+// NewHandler creates a new configuration status handler
+func NewHandler(reloader *Reloader) *Handler {
+	return &Handler{reloader: reloader}
+}
+
+// RegisterRoutes registers the configuration status route. It exposes the effective
+// settings, secrets redacted, so r should be the admin router, which is kept off the
+// public network like /metrics.
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/config", h.Status).Methods(http.MethodGet)
+}
+
+// Status handles reporting the effective configuration version and last reload outcome
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	utils.WriteSuccess(w, http.StatusOK, h.reloader.Status())
+}
@@ -0,0 +1,184 @@
+// Package reload applies the hot-reloadable subset of the configuration on SIGHUP
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"learning/internal/config"
+	"learning/internal/logging"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// PrepareFunc validates cfg and does any fallible work for one component, such as reading
+// files, and returns a function that puts the result in force. It must not change anything
+// itself, so that a reload is applied either in full or not at all.
+type PrepareFunc func(cfg *config.Config) (apply func(), err error)
+
+// component is a part of the application that can take new settings while running
+type component struct {
+	name    string
+	keys    []string
+	prepare PrepareFunc
+}
+
+// Attempt describes the outcome of one reload
+type Attempt struct {
+	At              time.Time `json:"at"`
+	Error           string    `json:"error,omitempty"`
+	Changed         []string  `json:"changed"`          // applied settings whose values changed
+	RestartRequired []string  `json:"restart_required"` // changed settings that were not applied
+}
+
+// Status describes the configuration in force. Version counts successful reloads and
+// Checksum identifies the effective values.
+type Status struct {
+	Version     int            `json:"version"`
+	Checksum    string         `json:"checksum"`
+	AppliedAt   time.Time      `json:"applied_at"`
+	LastAttempt *Attempt       `json:"last_attempt,omitempty"`
+	Settings    []config.Value `json:"settings"`
+}
+
+// state is the effective configuration and reload history, replaced as a whole
+type state struct {
+	config    *config.Config
+	version   int
+	checksum  string
+	appliedAt time.Time
+	last      *Attempt
+}
+
+// Reloader reloads configuration and hands the reloadable settings to registered components
+type Reloader struct {
+	load       func() (*config.Config, error)
+	components []component
+
+	mu    sync.Mutex // serializes reloads
+	state atomic.Pointer[state]
+}
+
+// NewReloader creates a reloader for the configuration the application started with.
+// load is called on every reload to build the new configuration.
+func NewReloader(cfg *config.Config, load func() (*config.Config, error)) *Reloader {
+	r := &Reloader{load: load}
+	r.state.Store(&state{config: cfg, version: 1, checksum: checksum(cfg), appliedAt: time.Now()})
+	return r
+}
+
+// Add registers a component taking the settings named by keys. Components are prepared
+// on every reload, even when their settings are unchanged, so files they read are re-read.
+func (r *Reloader) Add(name string, prepare PrepareFunc, keys ...string) {
+	r.components = append(r.components, component{name: name, keys: keys, prepare: prepare})
+}
+
+// Reload loads the configuration, prepares every component and, only if all of them
+// succeed, applies them together. Changes to settings no component takes are logged and
+// left for the next restart.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	logger := logging.FromContext(ctx)
+	current := r.state.Load()
+	attempt := &Attempt{At: time.Now(), Changed: []string{}, RestartRequired: []string{}}
+
+	next, err := r.load()
+	if err != nil {
+		return r.fail(ctx, current, attempt, err)
+	}
+
+	var reloadable []string
+	for _, c := range r.components {
+		reloadable = append(reloadable, c.keys...)
+	}
+	for _, key := range config.Changed(current.config, next) {
+		if slices.Contains(reloadable, key) {
+			attempt.Changed = append(attempt.Changed, key)
+		} else {
+			attempt.RestartRequired = append(attempt.RestartRequired, key)
+		}
+	}
+
+	applies := make([]func(), 0, len(r.components))
+	for _, c := range r.components {
+		apply, err := c.prepare(next)
+		if err != nil {
+			return r.fail(ctx, current, attempt, fmt.Errorf("%s: %w", c.name, err))
+		}
+		applies = append(applies, apply)
+	}
+	for _, apply := range applies {
+		apply()
+	}
+
+	effective := current.config.With(next, reloadable)
+	r.state.Store(&state{
+		config:    effective,
+		version:   current.version + 1,
+		checksum:  checksum(effective),
+		appliedAt: attempt.At,
+		last:      attempt,
+	})
+
+	logger.Info("configuration reloaded", "version", current.version+1, "changed", attempt.Changed)
+	if len(attempt.RestartRequired) > 0 {
+		logger.Warn("configuration changes require a restart", "settings", attempt.RestartRequired)
+	}
+	return nil
+}
+
+// fail records a reload that was not applied
+func (r *Reloader) fail(ctx context.Context, current *state, attempt *Attempt, err error) error {
+	attempt.Error = err.Error()
+	failed := *current
+	failed.last = attempt
+	r.state.Store(&failed)
+
+	logging.FromContext(ctx).Error("configuration reload failed", "version", current.version, "error", err)
+	return err
+}
+
+// Status returns the effective configuration, with secrets masked, and the last reload outcome
+func (r *Reloader) Status() Status {
+	current := r.state.Load()
+	return Status{
+		Version:     current.version,
+		Checksum:    current.checksum,
+		AppliedAt:   current.appliedAt,
+		LastAttempt: current.last,
+		Settings:    current.config.Values(true),
+	}
+}
+
+// Run reloads on every SIGHUP until ctx is cancelled
+func (r *Reloader) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logging.FromContext(ctx).Info("reloading configuration")
+			_ = r.Reload(ctx)
+		}
+	}
+}
+
+// checksum identifies a configuration by its effective values
+func checksum(cfg *config.Config) string {
+	data, _ := json.Marshal(cfg.Values(false))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,16 @@
+package reload
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register registers the status routes for reloader
+func Register(r *mux.Router, reloader *Reloader) {
+	h := NewHandler(reloader)
+	h.RegisterRoutes(r)
+}
@@ -0,0 +1,216 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles report and moderation HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new report handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers report routes for users and moderation routes for administrators
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	sr := r.PathPrefix("/reports").Subrouter()
+	sr.Use(middleware.RequireAuth)
+
+	sr.HandleFunc("", h.Create).Methods(http.MethodPost)
+	sr.HandleFunc("", h.ListMine).Methods(http.MethodGet)
+
+	ar := r.PathPrefix("/admin").Subrouter()
+	ar.Use(middleware.RequireAdmin)
+
+	ar.HandleFunc("/reports", h.ListQueue).Methods(http.MethodGet)
+	ar.HandleFunc("/reports/{id:[0-9]+}", h.Get).Methods(http.MethodGet)
+	ar.HandleFunc("/reports/{id:[0-9]+}/actions", h.ActOnReport).Methods(http.MethodPost)
+	ar.HandleFunc("/users/{id:[0-9]+}/actions", h.ActOnUser).Methods(http.MethodPost)
+	ar.HandleFunc("/users/{id:[0-9]+}/actions", h.ListUserActions).Methods(http.MethodGet)
+}
+
+// Create handles filing a report
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req CreateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	report, err := h.service.CreateReport(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, report)
+}
+
+// ListMine handles listing the caller's own reports
+func (h *Handler) ListMine(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reports, err := h.service.ListMyReports(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, reports)
+}
+
+// ListQueue handles listing the moderation queue, filtered by status and target_type
+func (h *Handler) ListQueue(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := ListFilter{
+		Status:     r.URL.Query().Get("status"),
+		TargetType: r.URL.Query().Get("target_type"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	if _, ok := r.URL.Query()["status"]; !ok {
+		filter.Status = StatusOpen
+	}
+
+	reports, err := h.service.ListQueue(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, reports)
+}
+
+// Get handles retrieving a report with its moderation history
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid report id")
+		return
+	}
+
+	report, err := h.service.GetReport(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, report)
+}
+
+// ActOnReport handles a moderator's action on a report
+func (h *Handler) ActOnReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID, _ := middleware.UserIDFromContext(r.Context())
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid report id")
+		return
+	}
+
+	var req ReportActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	report, err := h.service.ActOnReport(r.Context(), moderatorID, id, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, report)
+}
+
+// ActOnUser handles suspending, reinstating or warning an account directly
+func (h *Handler) ActOnUser(w http.ResponseWriter, r *http.Request) {
+	moderatorID, _ := middleware.UserIDFromContext(r.Context())
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || userID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var req UserActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	action, err := h.service.ActOnUser(r.Context(), moderatorID, userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, action)
+}
+
+// ListUserActions handles listing the moderation history of a user
+func (h *Handler) ListUserActions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || userID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	actions, err := h.service.ListUserActions(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, actions)
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Handle validation errors
+	var validationErr validator.ValidationErrors
+	if errors.As(err, &validationErr) {
+		utils.WriteError(w, http.StatusBadRequest, "validation failed: "+validationErr.Error())
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,111 @@
+package report
+
+import "time"
+
+// Reportable target types
+const (
+	TargetUser    = "user"
+	TargetPost    = "post"
+	TargetComment = "comment"
+	TargetMessage = "message"
+)
+
+// Report statuses
+const (
+	StatusOpen      = "open"
+	StatusTriaged   = "triaged"
+	StatusActioned  = "actioned"
+	StatusDismissed = "dismissed"
+)
+
+// Moderation actions
+const (
+	ActionTriage        = "triage"
+	ActionDismiss       = "dismiss"
+	ActionRemoveContent = "remove_content"
+	ActionSuspend       = "suspend_user"
+	ActionUnsuspend     = "unsuspend_user"
+	ActionWarn          = "warn_user"
+)
+
+// transitions lists, per action taken on a report, the statuses it may be taken from
+// and the status the report moves to
+var transitions = map[string]struct {
+	from []string
+	to   string
+}{
+	ActionTriage:        {from: []string{StatusOpen}, to: StatusTriaged},
+	ActionDismiss:       {from: []string{StatusOpen, StatusTriaged}, to: StatusDismissed},
+	ActionRemoveContent: {from: []string{StatusOpen, StatusTriaged, StatusActioned}, to: StatusActioned},
+	ActionSuspend:       {from: []string{StatusOpen, StatusTriaged, StatusActioned}, to: StatusActioned},
+	ActionWarn:          {from: []string{StatusOpen, StatusTriaged, StatusActioned}, to: StatusActioned},
+}
+
+// Report represents a user's report about a user or piece of content
+type Report struct {
+	ID              int64      `json:"id" db:"id"`
+	ReporterID      int        `json:"reporter_id" db:"reporter_id"`
+	TargetType      string     `json:"target_type" db:"target_type"`
+	TargetID        int64      `json:"target_id" db:"target_id"`
+	TargetUserID    *int       `json:"target_user_id,omitempty" db:"target_user_id"`
+	Category        string     `json:"category" db:"category"`
+	Details         *string    `json:"details,omitempty" db:"details"`
+	ContentSnapshot *string    `json:"content_snapshot,omitempty" db:"content_snapshot"`
+	Status          string     `json:"status" db:"status"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// Action is an immutable moderation log entry
+type Action struct {
+	ID           int64     `json:"id" db:"id"`
+	ReportID     *int64    `json:"report_id,omitempty" db:"report_id"`
+	ModeratorID  int       `json:"moderator_id" db:"moderator_id"`
+	Action       string    `json:"action" db:"action"`
+	TargetType   string    `json:"target_type" db:"target_type"`
+	TargetID     int64     `json:"target_id" db:"target_id"`
+	TargetUserID *int      `json:"target_user_id,omitempty" db:"target_user_id"`
+	Note         *string   `json:"note,omitempty" db:"note"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReportDetail is a report with its moderation history, returned to moderators
+type ReportDetail struct {
+	Report
+	Actions []Action `json:"actions"`
+}
+
+// CreateReportRequest represents the payload for reporting a user or content
+type CreateReportRequest struct {
+	TargetType string  `json:"target_type" validate:"required,oneof=user post comment message"`
+	TargetID   int64   `json:"target_id" validate:"required,gt=0"`
+	Category   string  `json:"category" validate:"required,oneof=spam harassment hate violence sexual self_harm misinformation impersonation other"`
+	Details    *string `json:"details,omitempty" validate:"omitempty,max=2000"`
+}
+
+// ReportActionRequest represents a moderator's action on a report
+type ReportActionRequest struct {
+	Action string  `json:"action" validate:"required,oneof=triage dismiss remove_content suspend_user warn_user"`
+	Note   *string `json:"note,omitempty" validate:"omitempty,max=2000"`
+}
+
+// UserActionRequest represents a moderator's action on an account outside any report
+type UserActionRequest struct {
+	Action string  `json:"action" validate:"required,oneof=suspend_user unsuspend_user warn_user"`
+	Note   *string `json:"note,omitempty" validate:"omitempty,max=2000"`
+}
+
+// ListFilter narrows the moderation queue
+type ListFilter struct {
+	Status     string
+	TargetType string
+	Limit      int
+	Offset     int
+}
+
+// Content is a reported target as resolved when the report is made
+type Content struct {
+	OwnerID  int
+	Snapshot string
+}
@@ -0,0 +1,328 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Sentinel errors returned by the repository and targets
+var (
+	errNotFound          = errors.New("report not found")
+	errTargetNotFound    = errors.New("report target not found")
+	errUserNotFound      = errors.New("user not found")
+	errDuplicateReport   = errors.New("report already open")
+	errInvalidTransition = errors.New("invalid report status transition")
+	errRemoveUnsupported = errors.New("target cannot be removed")
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for reports and moderation actions
+type RepositoryInterface interface {
+	UserSnapshot(ctx context.Context, userID int64) (*Content, error)
+	CreateReport(ctx context.Context, report *Report) (*Report, error)
+	GetReport(ctx context.Context, id int64) (*Report, error)
+	ListReports(ctx context.Context, filter ListFilter) ([]Report, error)
+	ListReporterReports(ctx context.Context, reporterID, limit, offset int) ([]Report, error)
+	ListReportActions(ctx context.Context, reportID int64) ([]Action, error)
+	ListUserActions(ctx context.Context, userID, limit, offset int) ([]Action, error)
+	RecordAction(ctx context.Context, action *Action, from []string, to string, setActive *bool) (bool, error)
+}
+
+// NewRepository creates a new report repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+const reportColumns = `id, reporter_id, target_type, target_id, target_user_id, category, details,
+        content_snapshot, status, created_at, updated_at, resolved_at`
+
+const actionColumns = `id, report_id, moderator_id, action, target_type, target_id, target_user_id, note, created_at`
+
+// scanReport scans a database row into a Report model
+func scanReport(row pgx.Row) (*Report, error) {
+	var r Report
+
+	err := row.Scan(
+		&r.ID,
+		&r.ReporterID,
+		&r.TargetType,
+		&r.TargetID,
+		&r.TargetUserID,
+		&r.Category,
+		&r.Details,
+		&r.ContentSnapshot,
+		&r.Status,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.ResolvedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to scan report: %w", err)
+	}
+	return &r, nil
+}
+
+// scanAction scans a database row into an Action model
+func scanAction(row pgx.Row) (*Action, error) {
+	var a Action
+
+	err := row.Scan(
+		&a.ID,
+		&a.ReportID,
+		&a.ModeratorID,
+		&a.Action,
+		&a.TargetType,
+		&a.TargetID,
+		&a.TargetUserID,
+		&a.Note,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan moderation action: %w", err)
+	}
+	return &a, nil
+}
+
+// UserSnapshot returns a reported account and the profile text at the time of reporting
+func (r *Repository) UserSnapshot(ctx context.Context, userID int64) (*Content, error) {
+	var content Content
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT id, concat_ws(E'\n', 'username: ' || username, 'name: ' || name, 'bio: ' || bio)
+        FROM users
+        WHERE id = $1 AND active = true
+    `, userID).Scan(&content.OwnerID, &content.Snapshot)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errTargetNotFound
+		}
+		return nil, fmt.Errorf("failed to get reported user: %w", err)
+	}
+	return &content, nil
+}
+
+// CreateReport stores a new open report
+func (r *Repository) CreateReport(ctx context.Context, report *Report) (*Report, error) {
+	now := time.Now()
+	row := r.db.Querier(ctx).QueryRow(ctx, `
+        INSERT INTO reports (reporter_id, target_type, target_id, target_user_id, category, details,
+                             content_snapshot, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+        RETURNING `+reportColumns,
+		report.ReporterID,
+		report.TargetType,
+		report.TargetID,
+		report.TargetUserID,
+		report.Category,
+		report.Details,
+		report.ContentSnapshot,
+		StatusOpen,
+		now,
+	)
+
+	created, err := scanReport(row)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, errDuplicateReport
+		}
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+	return created, nil
+}
+
+// GetReport returns a report by ID
+func (r *Repository) GetReport(ctx context.Context, id int64) (*Report, error) {
+	row := r.db.Querier(ctx).QueryRow(ctx, `SELECT `+reportColumns+` FROM reports WHERE id = $1`, id)
+	return scanReport(row)
+}
+
+// ListReports returns the moderation queue, oldest first
+func (r *Repository) ListReports(ctx context.Context, filter ListFilter) ([]Report, error) {
+	query := `
+        SELECT ` + reportColumns + `
+        FROM reports
+        WHERE ($1 = '' OR status = $1) AND ($2 = '' OR target_type = $2)
+        ORDER BY created_at, id
+        LIMIT $3 OFFSET $4
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, filter.Status, filter.TargetType, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	return collectReports(rows)
+}
+
+// ListReporterReports returns the reports a user has made, newest first
+func (r *Repository) ListReporterReports(ctx context.Context, reporterID, limit, offset int) ([]Report, error) {
+	query := `
+        SELECT ` + reportColumns + `
+        FROM reports
+        WHERE reporter_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, reporterID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	return collectReports(rows)
+}
+
+// collectReports scans every row into a report
+func collectReports(rows pgx.Rows) ([]Report, error) {
+	defer rows.Close()
+
+	reports := []Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reports: %w", err)
+	}
+	return reports, nil
+}
+
+// ListReportActions returns the moderation log of a report, oldest first
+func (r *Repository) ListReportActions(ctx context.Context, reportID int64) ([]Action, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, `
+        SELECT `+actionColumns+`
+        FROM moderation_actions
+        WHERE report_id = $1
+        ORDER BY created_at, id
+    `, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation actions: %w", err)
+	}
+	return collectActions(rows)
+}
+
+// ListUserActions returns the moderation log of actions taken against a user, newest first
+func (r *Repository) ListUserActions(ctx context.Context, userID, limit, offset int) ([]Action, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, `
+        SELECT `+actionColumns+`
+        FROM moderation_actions
+        WHERE target_user_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation actions: %w", err)
+	}
+	return collectActions(rows)
+}
+
+// collectActions scans every row into an action
+func collectActions(rows pgx.Rows) ([]Action, error) {
+	defer rows.Close()
+
+	actions := []Action{}
+	for rows.Next() {
+		action, err := scanAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, *action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate moderation actions: %w", err)
+	}
+	return actions, nil
+}
+
+// RecordAction appends action to the moderation log in one transaction with its effects:
+// when action.ReportID is set the report moves from one of the from statuses to to, and
+// when setActive is set the target user's active flag is updated. It reports whether the
+// report was resolved by this action.
+func (r *Repository) RecordAction(ctx context.Context, action *Action, from []string, to string, setActive *bool) (bool, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	now := time.Now()
+	resolved := false
+	if action.ReportID != nil {
+		var status string
+		err := tx.QueryRow(ctx, `SELECT status FROM reports WHERE id = $1 FOR UPDATE`, *action.ReportID).Scan(&status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, errNotFound
+			}
+			return false, fmt.Errorf("failed to lock report: %w", err)
+		}
+		if !slices.Contains(from, status) {
+			return false, errInvalidTransition
+		}
+
+		resolved = status != to && (to == StatusActioned || to == StatusDismissed)
+		_, err = tx.Exec(ctx, `
+            UPDATE reports
+            SET status = $2, updated_at = $3, resolved_at = CASE WHEN $4::BOOLEAN THEN $3 ELSE resolved_at END
+            WHERE id = $1
+        `, *action.ReportID, to, now, resolved)
+		if err != nil {
+			return false, fmt.Errorf("failed to update report status: %w", err)
+		}
+	}
+
+	if setActive != nil {
+		if action.TargetUserID == nil {
+			return false, errUserNotFound
+		}
+		tag, err := tx.Exec(ctx, `UPDATE users SET active = $2, updated_at = $3 WHERE id = $1`, *action.TargetUserID, *setActive, now)
+		if err != nil {
+			return false, fmt.Errorf("failed to update user status: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return false, errUserNotFound
+		}
+	}
+
+	row := tx.QueryRow(ctx, `
+        INSERT INTO moderation_actions (report_id, moderator_id, action, target_type, target_id, target_user_id, note, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING `+actionColumns,
+		action.ReportID,
+		action.ModeratorID,
+		action.Action,
+		action.TargetType,
+		action.TargetID,
+		action.TargetUserID,
+		action.Note,
+		now,
+	)
+	recorded, err := scanAction(row)
+	if err != nil {
+		return false, fmt.Errorf("failed to record moderation action: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit moderation action: %w", err)
+	}
+
+	*action = *recorded
+	return resolved, nil
+}
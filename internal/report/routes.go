@@ -0,0 +1,29 @@
+package report
+
+import (
+	"learning/internal/database"
+	"learning/internal/notification"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes. Accounts can
+// always be reported; other target types are reportable once present in targets.
+func Register(r *mux.Router, db *database.DataBase, targets Targets, notifier notification.Emitter) *Service {
+	repo := NewRepository(db)
+
+	all := Targets{TargetUser: userTarget{repository: repo}}
+	for targetType, target := range targets {
+		all[targetType] = target
+	}
+
+	svc := NewService(repo, database.NewTxManager(db), all, notifier)
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc
+}
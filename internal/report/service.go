@@ -0,0 +1,269 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/notification"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ServiceInterface defines business operations for reports and moderation
+type ServiceInterface interface {
+	CreateReport(ctx context.Context, reporterID int, req *CreateReportRequest) (*Report, error)
+	ListMyReports(ctx context.Context, reporterID, limit, offset int) ([]Report, error)
+	ListQueue(ctx context.Context, filter ListFilter) ([]Report, error)
+	GetReport(ctx context.Context, id int64) (*ReportDetail, error)
+	ActOnReport(ctx context.Context, moderatorID int, reportID int64, req *ReportActionRequest) (*ReportDetail, error)
+	ActOnUser(ctx context.Context, moderatorID, userID int, req *UserActionRequest) (*Action, error)
+	ListUserActions(ctx context.Context, userID, limit, offset int) ([]Action, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	transactor database.Transactor
+	validator  *validator.Validate
+	targets    Targets
+	notifier   notification.Emitter
+}
+
+// NewService creates a new report service. Reports can be made against the target
+// types present in targets.
+func NewService(repository RepositoryInterface, transactor database.Transactor, targets Targets, notifier notification.Emitter) *Service {
+	return &Service{
+		repository: repository,
+		transactor: transactor,
+		validator:  validator.New(),
+		targets:    targets,
+		notifier:   notifier,
+	}
+}
+
+// CreateReport files a report about a user or piece of content the reporter can see.
+// A snapshot of the content is kept so moderators can review it after edits or removal.
+func (s *Service) CreateReport(ctx context.Context, reporterID int, req *CreateReportRequest) (*Report, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	target, ok := s.targets[req.TargetType]
+	if !ok {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("no target registered for %q", req.TargetType), http.StatusBadRequest,
+			req.TargetType+" reports are not supported yet")
+	}
+
+	content, err := target.Resolve(ctx, reporterID, req.TargetID)
+	if err != nil {
+		return nil, mapError(err, "error while resolving report target")
+	}
+	if content.OwnerID == reporterID {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("user %d reported themselves", reporterID), http.StatusBadRequest, "you cannot report yourself")
+	}
+
+	report, err := s.repository.CreateReport(ctx, &Report{
+		ReporterID:      reporterID,
+		TargetType:      req.TargetType,
+		TargetID:        req.TargetID,
+		TargetUserID:    &content.OwnerID,
+		Category:        req.Category,
+		Details:         req.Details,
+		ContentSnapshot: &content.Snapshot,
+	})
+	if err != nil {
+		return nil, mapError(err, "error while creating report")
+	}
+	return report, nil
+}
+
+// ListMyReports returns the reports a user has made, so they can follow their status
+func (s *Service) ListMyReports(ctx context.Context, reporterID, limit, offset int) ([]Report, error) {
+	reports, err := s.repository.ListReporterReports(ctx, reporterID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing reports %w", err)
+	}
+	return reports, nil
+}
+
+// ListQueue returns the moderation queue, oldest reports first
+func (s *Service) ListQueue(ctx context.Context, filter ListFilter) ([]Report, error) {
+	reports, err := s.repository.ListReports(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing reports %w", err)
+	}
+	return reports, nil
+}
+
+// GetReport returns a report with its moderation history
+func (s *Service) GetReport(ctx context.Context, id int64) (*ReportDetail, error) {
+	report, err := s.repository.GetReport(ctx, id)
+	if err != nil {
+		return nil, mapError(err, "error while getting report")
+	}
+
+	actions, err := s.repository.ListReportActions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing moderation actions %w", err)
+	}
+	return &ReportDetail{Report: *report, Actions: actions}, nil
+}
+
+// ActOnReport applies a moderator's action to a report, moves it through the review
+// workflow and records the action. The reporter is notified once the report is resolved.
+func (s *Service) ActOnReport(ctx context.Context, moderatorID int, reportID int64, req *ReportActionRequest) (*ReportDetail, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	report, err := s.repository.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, mapError(err, "error while getting report")
+	}
+
+	transition := transitions[req.Action]
+	action := &Action{
+		ReportID:     &report.ID,
+		ModeratorID:  moderatorID,
+		Action:       req.Action,
+		TargetType:   report.TargetType,
+		TargetID:     report.TargetID,
+		TargetUserID: report.TargetUserID,
+		Note:         req.Note,
+	}
+
+	var setActive *bool
+	if req.Action == ActionSuspend {
+		setActive = new(bool)
+	}
+
+	// Recording the action locks the report and checks the transition before any content
+	// is removed, and a failed removal rolls the action back
+	var resolved bool
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		resolved, err = s.repository.RecordAction(ctx, action, transition.from, transition.to, setActive)
+		if err != nil {
+			return mapError(err, "error while recording moderation action")
+		}
+		if req.Action == ActionRemoveContent {
+			return s.removeContent(ctx, report)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Action == ActionWarn {
+		s.notify(ctx, notification.TypeWarning, action.TargetUserID, "", 0)
+	}
+	if resolved {
+		s.notify(ctx, notification.TypeReportResolved, &report.ReporterID, "report", report.ID)
+	}
+
+	return s.GetReport(database.WithPrimary(ctx), reportID)
+}
+
+// ActOnUser suspends, reinstates or warns an account outside any report
+func (s *Service) ActOnUser(ctx context.Context, moderatorID, userID int, req *UserActionRequest) (*Action, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if userID == moderatorID {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("moderator %d acted on themselves", moderatorID), http.StatusBadRequest, "you cannot moderate your own account")
+	}
+
+	action := &Action{
+		ModeratorID:  moderatorID,
+		Action:       req.Action,
+		TargetType:   TargetUser,
+		TargetID:     int64(userID),
+		TargetUserID: &userID,
+		Note:         req.Note,
+	}
+
+	var setActive *bool
+	switch req.Action {
+	case ActionSuspend:
+		setActive = new(bool)
+	case ActionUnsuspend:
+		active := true
+		setActive = &active
+	}
+
+	if _, err := s.repository.RecordAction(ctx, action, nil, "", setActive); err != nil {
+		return nil, mapError(err, "error while recording moderation action")
+	}
+
+	if req.Action == ActionWarn {
+		s.notify(ctx, notification.TypeWarning, &userID, "", 0)
+	}
+	return action, nil
+}
+
+// ListUserActions returns the moderation history of a user
+func (s *Service) ListUserActions(ctx context.Context, userID, limit, offset int) ([]Action, error) {
+	actions, err := s.repository.ListUserActions(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing moderation actions %w", err)
+	}
+	return actions, nil
+}
+
+// removeContent takes down the reported content through its target
+func (s *Service) removeContent(ctx context.Context, report *Report) error {
+	target, ok := s.targets[report.TargetType]
+	if !ok {
+		return apperrors.WrapWithMessage(errRemoveUnsupported, http.StatusBadRequest, report.TargetType+" content cannot be removed")
+	}
+	if err := target.Remove(ctx, report.TargetID); err != nil {
+		return mapError(err, "error while removing reported content")
+	}
+	return nil
+}
+
+// notify sends a system notification; failures are only logged
+func (s *Service) notify(ctx context.Context, notificationType string, recipientID *int, subjectType string, subjectID int64) {
+	if s.notifier == nil || recipientID == nil {
+		return
+	}
+
+	err := s.notifier.Emit(ctx, notification.Event{
+		Type:        notificationType,
+		RecipientID: *recipientID,
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to send notification", "type", notificationType, "user_id", *recipientID, "error", err)
+	}
+}
+
+// mapError converts repository sentinel errors into client errors and wraps anything else
+func mapError(err error, message string) error {
+	var appErr *apperrors.AppError
+	switch {
+	case errors.As(err, &appErr):
+		return err
+	case errors.Is(err, errNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "report not found")
+	case errors.Is(err, errTargetNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "reported content not found")
+	case errors.Is(err, errUserNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "user not found")
+	case errors.Is(err, errDuplicateReport):
+		return apperrors.WrapWithMessage(err, http.StatusConflict, "you have already reported this")
+	case errors.Is(err, errInvalidTransition):
+		return apperrors.WrapWithMessage(err, http.StatusConflict, "action is not allowed in the report's current status")
+	case errors.Is(err, errRemoveUnsupported):
+		return apperrors.WrapWithMessage(err, http.StatusBadRequest, "this content cannot be removed; suspend the account instead")
+	}
+	return fmt.Errorf("%s %w", message, err)
+}
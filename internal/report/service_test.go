@@ -0,0 +1,107 @@
+package report
+
+import (
+	"context"
+	"errors"
+	apperrors "learning/internal/errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// stubRepository holds a single report; methods the tests don't reach panic
+// through the nil embedded interface
+type stubRepository struct {
+	RepositoryInterface
+	report   Report
+	recorded []string
+}
+
+func (r *stubRepository) GetReport(ctx context.Context, id int64) (*Report, error) {
+	report := r.report
+	return &report, nil
+}
+
+func (r *stubRepository) ListReportActions(ctx context.Context, reportID int64) ([]Action, error) {
+	return nil, nil
+}
+
+func (r *stubRepository) RecordAction(ctx context.Context, action *Action, from []string, to string, setActive *bool) (bool, error) {
+	if !slices.Contains(from, r.report.Status) {
+		return false, errInvalidTransition
+	}
+	r.recorded = append(r.recorded, action.Action)
+	return true, nil
+}
+
+// stubTransactor runs the function directly and remembers whether it would have committed
+type stubTransactor struct {
+	committed bool
+}
+
+func (t *stubTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	t.committed = err == nil
+	return err
+}
+
+// stubTarget records removals and fails them with err
+type stubTarget struct {
+	removed []int64
+	err     error
+}
+
+func (t *stubTarget) Resolve(ctx context.Context, reporterID int, id int64) (*Content, error) {
+	return nil, errTargetNotFound
+}
+
+func (t *stubTarget) Remove(ctx context.Context, id int64) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.removed = append(t.removed, id)
+	return nil
+}
+
+func TestActOnReportRemovesContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		targetType  string
+		removeErr   error
+		wantStatus  int
+		wantRemoved bool
+	}{
+		{name: "open report", status: StatusOpen, targetType: TargetMessage, wantRemoved: true},
+		{name: "already actioned", status: StatusActioned, targetType: TargetMessage, wantRemoved: true},
+		{name: "dismissed report", status: StatusDismissed, targetType: TargetMessage, wantStatus: http.StatusConflict},
+		{name: "content already gone", status: StatusOpen, targetType: TargetMessage, removeErr: errTargetNotFound, wantStatus: http.StatusNotFound},
+		{name: "unremovable target", status: StatusOpen, targetType: TargetUser, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepository{report: Report{ID: 1, ReporterID: 2, TargetType: tt.targetType, TargetID: 3, Status: tt.status}}
+			tx := &stubTransactor{}
+			target := &stubTarget{err: tt.removeErr}
+			svc := NewService(repo, tx, Targets{TargetMessage: target, TargetUser: userTarget{repository: repo}}, nil)
+
+			_, err := svc.ActOnReport(context.Background(), 9, 1, &ReportActionRequest{Action: ActionRemoveContent})
+			if tt.wantStatus != 0 {
+				var appErr *apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantStatus {
+					t.Fatalf("ActOnReport() error = %v, want status %d", err, tt.wantStatus)
+				}
+				if tx.committed {
+					t.Error("failed action was committed")
+				}
+			} else if err != nil {
+				t.Fatalf("ActOnReport() error = %v", err)
+			}
+
+			if removed := len(target.removed) > 0; removed != tt.wantRemoved {
+				t.Errorf("content removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
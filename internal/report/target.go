@@ -0,0 +1,79 @@
+package report
+
+import (
+	"context"
+	"learning/internal/messaging"
+	"learning/internal/post"
+)
+
+// Target resolves and removes one type of reportable content
+type Target interface {
+	// Resolve returns the content's owner and a snapshot of it, failing with a 404 if
+	// the reporter cannot see it
+	Resolve(ctx context.Context, reporterID int, id int64) (*Content, error)
+	// Remove takes the content down on behalf of a moderator
+	Remove(ctx context.Context, id int64) error
+}
+
+// Targets maps target types to their implementations
+type Targets map[string]Target
+
+// userTarget resolves reported accounts. Accounts are suspended rather than removed.
+type userTarget struct {
+	repository RepositoryInterface
+}
+
+func (t userTarget) Resolve(ctx context.Context, reporterID int, id int64) (*Content, error) {
+	return t.repository.UserSnapshot(ctx, id)
+}
+
+func (t userTarget) Remove(ctx context.Context, id int64) error {
+	return errRemoveUnsupported
+}
+
+// MessageTarget resolves reported direct messages through the messaging service
+type MessageTarget struct {
+	Messages *messaging.Service
+}
+
+// Ensure MessageTarget implements Target
+var _ Target = MessageTarget{}
+
+// Resolve returns a message the reporter can see in one of their conversations
+func (t MessageTarget) Resolve(ctx context.Context, reporterID int, id int64) (*Content, error) {
+	message, err := t.Messages.ReportedMessage(ctx, reporterID, id)
+	if err != nil {
+		return nil, err
+	}
+	if message.Deleted {
+		return nil, errTargetNotFound
+	}
+	return &Content{OwnerID: message.SenderID, Snapshot: message.Body}, nil
+}
+
+// Remove deletes the message for every conversation member
+func (t MessageTarget) Remove(ctx context.Context, id int64) error {
+	return t.Messages.RemoveMessage(ctx, id)
+}
+
+// PostTarget resolves reported posts through the post service
+type PostTarget struct {
+	Posts *post.Service
+}
+
+// Ensure PostTarget implements Target
+var _ Target = PostTarget{}
+
+// Resolve returns a published post
+func (t PostTarget) Resolve(ctx context.Context, reporterID int, id int64) (*Content, error) {
+	p, err := t.Posts.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Content{OwnerID: p.AuthorID, Snapshot: p.Body}, nil
+}
+
+// Remove deletes the post along with its attachments, hashtags and mentions
+func (t PostTarget) Remove(ctx context.Context, id int64) error {
+	return t.Posts.RemovePost(ctx, id)
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/ratelimit"
+	"learning/internal/utils"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+// loginPolicy slows password guessing from a single address
+var loginPolicy = ratelimit.Policy{Name: "sessions.create", Limit: ratelimit.PerMinute(10), Key: ratelimit.ByIP}
+
+// Handler handles session-related HTTP requests
+type Handler struct {
+	service      ServiceInterface
+	limiter      *ratelimit.Limiter
+	secureCookie bool
+}
+
+// NewHandler creates a new session handler. Session cookies are marked Secure when
+// secureCookie is set, which it should be whenever the API is served over HTTPS.
+func NewHandler(service ServiceInterface, limiter *ratelimit.Limiter, secureCookie bool) *Handler {
+	return &Handler{service: service, limiter: limiter, secureCookie: secureCookie}
+}
+
+// RegisterRoutes registers session-related routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.Handle("/sessions", h.limiter.Handler(loginPolicy, http.HandlerFunc(h.Login))).Methods(http.MethodPost)
+	r.Handle("/sessions/current", middleware.RequireAuth(http.HandlerFunc(h.Logout))).Methods(http.MethodDelete)
+}
+
+// Login handles login requests. The token is returned in the body for API clients and
+// set as an HttpOnly cookie for browsers.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	resp, err := h.service.Login(r.Context(), &req, r.UserAgent())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.SessionCookie,
+		Value:    resp.Token,
+		Path:     "/",
+		Expires:  resp.ExpiresAt,
+		Secure:   h.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	utils.WriteSuccess(w, http.StatusCreated, resp)
+}
+
+// Logout handles ending the session the request was made with
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Logout(r.Context(), middleware.SessionToken(r)); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   h.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	utils.WriteMessage(w, http.StatusOK, "logged out")
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Handle validation errors
+	var validationErr validator.ValidationErrors
+	if errors.As(err, &validationErr) {
+		utils.WriteError(w, http.StatusBadRequest, "validation failed: "+validationErr.Error())
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,37 @@
+package session
+
+import "time"
+
+// Session is a login issued to a user. Only a hash of its token is stored.
+type Session struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	UserAgent *string   `json:"user_agent,omitempty" db:"user_agent"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Credentials are the stored login details of an active user
+type Credentials struct {
+	UserID   int
+	Password string // bcrypt hash
+}
+
+// Principal is the user a valid session token was issued to
+type Principal struct {
+	UserID int
+	Admin  bool
+}
+
+// LoginRequest represents a login with a username or email address and a password
+type LoginRequest struct {
+	Login    string `json:"login" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// LoginResponse carries the token clients send as a bearer token on later requests
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	UserID    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learning/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Sentinel errors returned by the repository
+var (
+	errNotFound = errors.New("session not found")
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for sessions
+type RepositoryInterface interface {
+	FindCredentials(ctx context.Context, username, canonicalEmail string) (*Credentials, error)
+	CreateSession(ctx context.Context, userID int, tokenHash []byte, userAgent *string, expiresAt time.Time) (*Session, error)
+	GetPrincipal(ctx context.Context, tokenHash []byte, now time.Time) (*Principal, error)
+	DeleteSession(ctx context.Context, tokenHash []byte) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// NewRepository creates a new session repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// FindCredentials returns the password hash of the active user with the given username or
+// canonical email address; the other one is left empty
+func (r *Repository) FindCredentials(ctx context.Context, username, canonicalEmail string) (*Credentials, error) {
+	query := `
+        SELECT id, password FROM users
+        WHERE active = true AND (lower(username) = lower($1) OR email_canonical = $2)
+    `
+
+	var creds Credentials
+	err := r.db.Querier(ctx).QueryRow(ctx, query, username, canonicalEmail).Scan(&creds.UserID, &creds.Password)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to find credentials: %w", err)
+	}
+	return &creds, nil
+}
+
+// CreateSession stores a session for userID under the hash of its token
+func (r *Repository) CreateSession(ctx context.Context, userID int, tokenHash []byte, userAgent *string, expiresAt time.Time) (*Session, error) {
+	query := `
+        INSERT INTO sessions (user_id, token_hash, user_agent, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, user_id, user_agent, expires_at, created_at
+    `
+
+	var s Session
+	err := r.db.Querier(ctx).QueryRow(ctx, query, userID, tokenHash, userAgent, expiresAt, time.Now()).
+		Scan(&s.ID, &s.UserID, &s.UserAgent, &s.ExpiresAt, &s.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return &s, nil
+}
+
+// GetPrincipal returns the active user holding the unexpired session with the given token hash.
+// It always reads the primary so a session is usable as soon as it is created.
+func (r *Repository) GetPrincipal(ctx context.Context, tokenHash []byte, now time.Time) (*Principal, error) {
+	query := `
+        SELECT u.id, u.is_admin
+        FROM sessions s
+        JOIN users u ON u.id = s.user_id
+        WHERE s.token_hash = $1 AND s.expires_at > $2 AND u.active = true
+    `
+
+	var p Principal
+	err := r.db.Querier(ctx).QueryRow(ctx, query, tokenHash, now).Scan(&p.UserID, &p.Admin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &p, nil
+}
+
+// DeleteSession removes the session with the given token hash
+func (r *Repository) DeleteSession(ctx context.Context, tokenHash []byte) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM sessions WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+// DeleteExpired removes sessions that expired before now
+func (r *Repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM sessions WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,25 @@
+package session
+
+import (
+	"learning/internal/database"
+	"learning/internal/ratelimit"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler and registers routes. The returned
+// service authenticates requests through middleware.Authenticate and must be run to
+// prune expired sessions.
+func Register(r *mux.Router, db *database.DataBase, limiter *ratelimit.Limiter, publicURL string) *Service {
+	repo := NewRepository(db)
+	svc := NewService(repo)
+	h := NewHandler(svc, limiter, strings.HasPrefix(publicURL, "https://"))
+	h.RegisterRoutes(r)
+	return svc
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/metrics"
+	"learning/internal/middleware"
+	"learning/internal/user"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Sessions last this long, after which the user logs in again; expired sessions are pruned
+// on every interval
+const (
+	sessionTTL    = 30 * 24 * time.Hour
+	pruneInterval = time.Hour
+)
+
+// maxUserAgentLength is the longest User-Agent kept to help users recognise their sessions
+const maxUserAgentLength = 255
+
+// dummyHash is compared against when no user matches a login so unknown logins take as
+// long as wrong passwords
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("no such user"), bcrypt.DefaultCost)
+
+// errInvalidLogin is returned for unknown logins and wrong passwords alike
+var errInvalidLogin = apperrors.WrapWithMessage(errors.New("invalid login"), http.StatusUnauthorized, "invalid login or password")
+
+// ServiceInterface defines business operations for sessions
+type ServiceInterface interface {
+	Login(ctx context.Context, req *LoginRequest, userAgent string) (*LoginResponse, error)
+	Logout(ctx context.Context, token string) error
+}
+
+// Ensure Service implements ServiceInterface and middleware.Authenticator
+var (
+	_ ServiceInterface         = (*Service)(nil)
+	_ middleware.Authenticator = (*Service)(nil)
+)
+
+type Service struct {
+	repository RepositoryInterface
+	validator  *validator.Validate
+}
+
+// NewService creates a new session service
+func NewService(repository RepositoryInterface) *Service {
+	return &Service{
+		repository: repository,
+		validator:  validator.New(),
+	}
+}
+
+// Login checks a username or email address and password and issues a session token.
+// Every attempt is counted by outcome.
+func (s *Service) Login(ctx context.Context, req *LoginRequest, userAgent string) (_ *LoginResponse, err error) {
+	defer func() { metrics.LoginsTotal.WithLabelValues(loginOutcome(err)).Inc() }()
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	var username, canonicalEmail string
+	if strings.Contains(req.Login, "@") {
+		_, canonical, err := user.NormalizeEmail(req.Login)
+		if err != nil {
+			return nil, errInvalidLogin
+		}
+		canonicalEmail = canonical
+	} else {
+		username = strings.TrimSpace(req.Login)
+	}
+
+	creds, err := s.repository.FindCredentials(ctx, username, canonicalEmail)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("error while finding credentials %w", err)
+	}
+	if creds == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(req.Password))
+		return nil, errInvalidLogin
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(creds.Password), []byte(req.Password)); err != nil {
+		return nil, errInvalidLogin
+	}
+
+	token := rand.Text()
+	var agent *string
+	if userAgent != "" {
+		userAgent = strings.ToValidUTF8(userAgent[:min(len(userAgent), maxUserAgentLength)], "")
+		agent = &userAgent
+	}
+	session, err := s.repository.CreateSession(ctx, creds.UserID, hashToken(token), agent, time.Now().Add(sessionTTL))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating session %w", err)
+	}
+
+	logging.FromContext(ctx).Info("user logged in", "user_id", creds.UserID, "session_id", session.ID)
+	return &LoginResponse{Token: token, UserID: session.UserID, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// loginOutcome classifies the result of a login attempt for metrics
+func loginOutcome(err error) string {
+	var invalid validator.ValidationErrors
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, errInvalidLogin), errors.As(err, &invalid):
+		return "invalid"
+	}
+	return "error"
+}
+
+// Logout ends the session holding token
+func (s *Service) Logout(ctx context.Context, token string) error {
+	if err := s.repository.DeleteSession(ctx, hashToken(token)); err != nil {
+		if errors.Is(err, errNotFound) {
+			return apperrors.WrapWithMessage(err, http.StatusNotFound, "session not found")
+		}
+		return fmt.Errorf("error while deleting session %w", err)
+	}
+	return nil
+}
+
+// Authenticate returns the user holding an unexpired session for token. Unknown and
+// expired tokens return a zero user ID without an error.
+func (s *Service) Authenticate(ctx context.Context, token string) (int, bool, error) {
+	principal, err := s.repository.GetPrincipal(ctx, hashToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("error while authenticating session %w", err)
+	}
+	return principal.UserID, principal.Admin, nil
+}
+
+// Run prunes expired sessions on every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.repository.DeleteExpired(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("session pruning failed", "error", err)
+			}
+		}
+	}
+}
+
+// hashToken hashes a session token so a leaked table cannot be used to log in
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"learning/internal/metrics"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// stubRepository knows one user; methods the tests don't reach panic through the nil
+// embedded interface
+type stubRepository struct {
+	RepositoryInterface
+	creds *Credentials
+	err   error
+}
+
+func (r *stubRepository) FindCredentials(ctx context.Context, username, canonicalEmail string) (*Credentials, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if username != "alice" {
+		return nil, errNotFound
+	}
+	return r.creds, nil
+}
+
+func (r *stubRepository) CreateSession(ctx context.Context, userID int, tokenHash []byte, userAgent *string, expiresAt time.Time) (*Session, error) {
+	return &Session{UserID: userID, ExpiresAt: expiresAt}, nil
+}
+
+func TestLoginCountsOutcomes(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := &Credentials{UserID: 1, Password: string(hash)}
+
+	tests := []struct {
+		name    string
+		req     LoginRequest
+		repoErr error
+		outcome string
+	}{
+		{name: "success", req: LoginRequest{Login: "alice", Password: "correct horse"}, outcome: "success"},
+		{name: "wrong password", req: LoginRequest{Login: "alice", Password: "wrong"}, outcome: "invalid"},
+		{name: "unknown user", req: LoginRequest{Login: "bob", Password: "correct horse"}, outcome: "invalid"},
+		{name: "malformed email", req: LoginRequest{Login: "@@", Password: "correct horse"}, outcome: "invalid"},
+		{name: "missing password", req: LoginRequest{Login: "alice"}, outcome: "invalid"},
+		{name: "database failure", req: LoginRequest{Login: "alice", Password: "correct horse"}, repoErr: errors.New("connection refused"), outcome: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(&stubRepository{creds: creds, err: tt.repoErr})
+			counter := metrics.LoginsTotal.WithLabelValues(tt.outcome)
+			before := testutil.ToFloat64(counter)
+
+			_, err := svc.Login(context.Background(), &tt.req, "test")
+			if (err == nil) != (tt.outcome == "success") {
+				t.Fatalf("Login() error = %v, want outcome %s", err, tt.outcome)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("%s logins counted %v times, want 1", tt.outcome, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package signup
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Check scores one aspect of a registration attempt. A zero score means nothing suspicious was found.
+type Check interface {
+	Name() string
+	Evaluate(ctx context.Context, attempt Attempt) (score int, reason string, err error)
+}
+
+// AttemptCounter counts recent registration attempts from an address range
+type AttemptCounter interface {
+	CountAttempts(ctx context.Context, prefix netip.Prefix, since time.Time) (int, error)
+}
+
+// VelocityCheck scores addresses and subnets that registered too often within the window.
+// IPv4 subnets are /24 and IPv6 subnets /64, the smallest range one party usually controls.
+type VelocityCheck struct {
+	Counter      AttemptCounter
+	Window       time.Duration
+	MaxPerIP     int
+	MaxPerSubnet int
+	IPScore      int
+	SubnetScore  int
+}
+
+func (c *VelocityCheck) Name() string { return "velocity" }
+
+func (c *VelocityCheck) Evaluate(ctx context.Context, attempt Attempt) (int, string, error) {
+	if !attempt.IP.IsValid() {
+		return 0, "", nil
+	}
+
+	ip := attempt.IP.Unmap()
+	since := time.Now().Add(-c.Window)
+
+	count, err := c.Counter.CountAttempts(ctx, netip.PrefixFrom(ip, ip.BitLen()), since)
+	if err != nil {
+		return 0, "", err
+	}
+	if count >= c.MaxPerIP {
+		return c.IPScore, fmt.Sprintf("%d registrations from %s in %s", count, ip, c.Window), nil
+	}
+
+	bits := 24
+	if ip.Is6() {
+		bits = 64
+	}
+	subnet, _ := ip.Prefix(bits)
+	if count, err = c.Counter.CountAttempts(ctx, subnet, since); err != nil {
+		return 0, "", err
+	}
+	if count >= c.MaxPerSubnet {
+		return c.SubnetScore, fmt.Sprintf("%d registrations from %s in %s", count, subnet, c.Window), nil
+	}
+	return 0, "", nil
+}
+
+// DisposableEmailCheck scores addresses at throwaway email providers, including their subdomains
+type DisposableEmailCheck struct {
+	Domains map[string]bool
+	Score   int
+}
+
+// LoadDisposableDomains reads one domain per line from path, ignoring blank lines and # comments
+func LoadDisposableDomains(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open disposable domain list: %w", err)
+	}
+	defer f.Close()
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.ToLower(strings.TrimSpace(line)); line != "" {
+			domains[strings.TrimSuffix(line, ".")] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read disposable domain list: %w", err)
+	}
+	return domains, nil
+}
+
+func (c *DisposableEmailCheck) Name() string { return "disposable_email" }
+
+func (c *DisposableEmailCheck) Evaluate(ctx context.Context, attempt Attempt) (int, string, error) {
+	at := strings.LastIndexByte(attempt.Email, '@')
+	if at < 0 {
+		return 0, "", nil
+	}
+
+	domain := strings.ToLower(attempt.Email[at+1:])
+	for candidate := domain; candidate != ""; {
+		if c.Domains[candidate] {
+			return c.Score, "email domain " + domain + " is disposable", nil
+		}
+		_, candidate, _ = strings.Cut(candidate, ".")
+	}
+	return 0, "", nil
+}
+
+// HoneypotCheck scores attempts that filled in the hidden honeypot field
+type HoneypotCheck struct {
+	Score int
+}
+
+func (c *HoneypotCheck) Name() string { return "honeypot" }
+
+func (c *HoneypotCheck) Evaluate(ctx context.Context, attempt Attempt) (int, string, error) {
+	if strings.TrimSpace(attempt.Honeypot) != "" {
+		return c.Score, "honeypot field was filled in", nil
+	}
+	return 0, "", nil
+}
+
+// UsernameEntropyCheck scores usernames that look machine-generated: long strings without
+// repeated characters, long consonant runs, letters interleaved with digits and mostly digits
+type UsernameEntropyCheck struct {
+	Score int
+}
+
+// Thresholds tuned so ordinary names such as "maria_garcia92" or "the_real_bob" pass
+const (
+	minEntropyLength  = 10
+	maxEntropyRatio   = 0.97 // Shannon entropy relative to the maximum for the length; 1 means no repeated characters
+	maxConsonantRun   = 5
+	maxDigitSwitches  = 3
+	maxDigitsFraction = 0.5
+)
+
+func (c *UsernameEntropyCheck) Name() string { return "username_entropy" }
+
+func (c *UsernameEntropyCheck) Evaluate(ctx context.Context, attempt Attempt) (int, string, error) {
+	name := strings.ToLower(attempt.Username)
+	runes := []rune(name)
+	var reasons []string
+	if bits := entropy(runes); len(runes) >= minEntropyLength && bits/math.Log2(float64(len(runes))) >= maxEntropyRatio {
+		reasons = append(reasons, fmt.Sprintf("entropy %.2f bits per character", bits))
+	}
+	if run := longestConsonantRun(runes); run > maxConsonantRun {
+		reasons = append(reasons, fmt.Sprintf("%d consonants in a row", run))
+	}
+
+	digits, switches := 0, 0
+	for i, r := range runes {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+		if i > 0 && unicode.IsDigit(r) != unicode.IsDigit(runes[i-1]) {
+			switches++
+		}
+	}
+	if switches > maxDigitSwitches {
+		reasons = append(reasons, fmt.Sprintf("letters and digits interleaved %d times", switches))
+	}
+	if float64(digits)/float64(len(runes)) > maxDigitsFraction {
+		reasons = append(reasons, "mostly digits")
+	}
+
+	if len(reasons) == 0 {
+		return 0, "", nil
+	}
+	// Each signal alone is weak, so the score grows with the number of signals
+	return c.Score * len(reasons) / 2, strings.Join(reasons, ", "), nil
+}
+
+// entropy returns the Shannon entropy of the characters in bits per character
+func entropy(runes []rune) float64 {
+	counts := make(map[rune]int)
+	for _, r := range runes {
+		counts[r]++
+	}
+
+	var bits float64
+	n := float64(len(runes))
+	for _, count := range counts {
+		p := float64(count) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// longestConsonantRun returns the length of the longest run of Latin consonants. Y counts as a vowel.
+func longestConsonantRun(runes []rune) int {
+	longest, run := 0, 0
+	for _, r := range runes {
+		if r >= 'a' && r <= 'z' && !strings.ContainsRune("aeiouy", r) {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
@@ -0,0 +1,66 @@
+package signup
+
+import (
+	"errors"
+	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles signup review HTTP requests
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new signup handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the attempt log for administrators tuning the checks
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	ar := r.PathPrefix("/admin").Subrouter()
+	ar.Use(middleware.RequireAdmin)
+
+	ar.HandleFunc("/signup-attempts", h.ListAttempts).Methods(http.MethodGet)
+}
+
+// ListAttempts handles listing recorded attempts, optionally filtered by decision
+func (h *Handler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := utils.ParsePagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	decision := r.URL.Query().Get("decision")
+	switch decision {
+	case "", DecisionAllow, DecisionVerify, DecisionReject:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, "invalid decision")
+		return
+	}
+
+	records, err := h.service.ListAttempts(r.Context(), decision, limit, offset)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, records)
+}
+
+// handleError processes errors and returns appropriate HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	// Default to internal server error
+	utils.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
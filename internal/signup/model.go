@@ -0,0 +1,56 @@
+package signup
+
+import (
+	"net/netip"
+	"time"
+)
+
+// Decisions, from least to most restrictive
+const (
+	DecisionAllow  = "allow"
+	DecisionVerify = "verify" // the account stays inactive until its email address is confirmed
+	DecisionReject = "reject"
+)
+
+// Attempt describes a registration request being scored
+type Attempt struct {
+	IP       netip.Addr
+	Username string
+	Email    string
+	Honeypot string // value of a form field hidden from people; bots tend to fill it in
+}
+
+// Finding is one check's contribution to an attempt's score
+type Finding struct {
+	Check  string `json:"check"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// Assessment is the scored outcome for an attempt
+type Assessment struct {
+	Decision string    `json:"decision"`
+	Score    int       `json:"score"`
+	Findings []Finding `json:"findings"`
+}
+
+// Reasons returns the reason of every finding, in check order
+func (a *Assessment) Reasons() []string {
+	reasons := make([]string, 0, len(a.Findings))
+	for _, finding := range a.Findings {
+		reasons = append(reasons, finding.Check+": "+finding.Reason)
+	}
+	return reasons
+}
+
+// Record is a stored attempt with its assessment, kept for tuning the checks
+type Record struct {
+	ID        int64     `json:"id" db:"id"`
+	IP        string    `json:"ip" db:"ip"`
+	Username  string    `json:"username" db:"username"`
+	Email     string    `json:"email" db:"email"`
+	Score     int       `json:"score" db:"score"`
+	Decision  string    `json:"decision" db:"decision"`
+	Reasons   []string  `json:"reasons" db:"reasons"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
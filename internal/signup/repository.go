@@ -0,0 +1,107 @@
+package signup
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/database"
+	"net/netip"
+	"time"
+)
+
+type Repository struct {
+	db *database.DataBase
+}
+
+// Ensure Repository implements the expected interface
+var _ RepositoryInterface = (*Repository)(nil)
+
+// RepositoryInterface defines persistence operations for registration attempts
+type RepositoryInterface interface {
+	AttemptCounter
+	RecordAttempt(ctx context.Context, attempt Attempt, assessment *Assessment) error
+	ListAttempts(ctx context.Context, decision string, limit, offset int) ([]Record, error)
+	DeleteAttemptsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// NewRepository creates a new signup repository
+func NewRepository(db *database.DataBase) *Repository {
+	return &Repository{db: db}
+}
+
+// CountAttempts returns the number of attempts from addresses within prefix since the given time
+func (r *Repository) CountAttempts(ctx context.Context, prefix netip.Prefix, since time.Time) (int, error) {
+	var count int
+	err := r.db.Querier(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FROM signup_attempts
+        WHERE ip <<= $1 AND created_at >= $2
+    `, prefix, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count signup attempts: %w", err)
+	}
+	return count, nil
+}
+
+// RecordAttempt stores an attempt with its score, decision and reasons
+func (r *Repository) RecordAttempt(ctx context.Context, attempt Attempt, assessment *Assessment) error {
+	var ip *netip.Addr
+	if attempt.IP.IsValid() {
+		unmapped := attempt.IP.Unmap()
+		ip = &unmapped
+	}
+
+	_, err := r.db.Querier(ctx).Exec(ctx, `
+        INSERT INTO signup_attempts (ip, username, email, score, decision, reasons, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, ip, attempt.Username, attempt.Email, assessment.Score, assessment.Decision, assessment.Reasons(), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record signup attempt: %w", err)
+	}
+	return nil
+}
+
+// ListAttempts returns recorded attempts, newest first, optionally filtered by decision
+func (r *Repository) ListAttempts(ctx context.Context, decision string, limit, offset int) ([]Record, error) {
+	rows, err := r.db.Reader(ctx).Query(ctx, `
+        SELECT id, host(ip), username, email, score, decision, reasons, created_at
+        FROM signup_attempts
+        WHERE $1 = '' OR decision = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `, decision, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signup attempts: %w", err)
+	}
+	defer rows.Close()
+
+	records := []Record{}
+	for rows.Next() {
+		var record Record
+		err := rows.Scan(
+			&record.ID,
+			&record.IP,
+			&record.Username,
+			&record.Email,
+			&record.Score,
+			&record.Decision,
+			&record.Reasons,
+			&record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signup attempt: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate signup attempts: %w", err)
+	}
+	return records, nil
+}
+
+// DeleteAttemptsBefore removes attempts recorded before the given time
+func (r *Repository) DeleteAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM signup_attempts WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete signup attempts: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,27 @@
+package signup
+
+import (
+	"learning/internal/config"
+	"learning/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes repository -> service -> handler with the default checks and registers routes
+func Register(r *mux.Router, db *database.DataBase, cfg config.SignupConfig) (*Service, error) {
+	repo := NewRepository(db)
+	checks, err := DefaultChecks(repo, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := NewService(repo, checks, cfg.VerifyScore, cfg.RejectScore)
+	h := NewHandler(svc)
+	h.RegisterRoutes(r)
+	return svc, nil
+}
@@ -0,0 +1,162 @@
+package signup
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/config"
+	"learning/internal/logging"
+	"learning/internal/metrics"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Attempts are kept this long for velocity checks and tuning, then pruned
+const (
+	attemptRetention = 30 * 24 * time.Hour
+	pruneInterval    = time.Hour
+)
+
+// Default check scores. With the default thresholds (verify at 50, reject at 100) a filled-in
+// honeypot rejects on its own, while a disposable address or a busy address requires verification.
+const (
+	honeypotScore   = 100
+	disposableScore = 60
+	ipScore         = 60
+	subnetScore     = 40
+	usernameScore   = 30
+)
+
+// ServiceInterface defines business operations for registration risk scoring
+type ServiceInterface interface {
+	Assess(ctx context.Context, attempt Attempt) (*Assessment, error)
+	ListAttempts(ctx context.Context, decision string, limit, offset int) ([]Record, error)
+}
+
+// Ensure Service implements ServiceInterface
+var _ ServiceInterface = (*Service)(nil)
+
+type Service struct {
+	repository RepositoryInterface
+	scoring    atomic.Pointer[scoring]
+}
+
+// scoring is the set of checks and thresholds in force, replaced as a whole on reload
+type scoring struct {
+	checks      []Check
+	verifyScore int
+	rejectScore int
+}
+
+// NewService creates a signup service that sums the scores of checks and decides with the
+// given thresholds
+func NewService(repository RepositoryInterface, checks []Check, verifyScore, rejectScore int) *Service {
+	s := &Service{repository: repository}
+	s.scoring.Store(&scoring{checks: checks, verifyScore: verifyScore, rejectScore: rejectScore})
+	return s
+}
+
+// PrepareConfig builds the default checks and thresholds from a replacement configuration,
+// reading the disposable domain list, and returns a function that puts them in force
+func (s *Service) PrepareConfig(cfg config.SignupConfig) (apply func(), err error) {
+	checks, err := DefaultChecks(s.repository, cfg)
+	if err != nil {
+		return nil, err
+	}
+	next := &scoring{checks: checks, verifyScore: cfg.VerifyScore, rejectScore: cfg.RejectScore}
+	return func() { s.scoring.Store(next) }, nil
+}
+
+// DefaultChecks builds the built-in checks from configuration. The disposable email check is
+// only included when a domain list file is configured.
+func DefaultChecks(repository RepositoryInterface, cfg config.SignupConfig) ([]Check, error) {
+	checks := []Check{
+		&HoneypotCheck{Score: honeypotScore},
+		&VelocityCheck{
+			Counter:      repository,
+			Window:       cfg.Window,
+			MaxPerIP:     cfg.MaxPerIP,
+			MaxPerSubnet: cfg.MaxPerSubnet,
+			IPScore:      ipScore,
+			SubnetScore:  subnetScore,
+		},
+		&UsernameEntropyCheck{Score: usernameScore},
+	}
+
+	if cfg.DisposableDomainsFile != "" {
+		domains, err := LoadDisposableDomains(cfg.DisposableDomainsFile)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("loaded disposable email domains", "count", len(domains))
+		checks = append(checks, &DisposableEmailCheck{Domains: domains, Score: disposableScore})
+	}
+	return checks, nil
+}
+
+// Assess scores a registration attempt, records it and logs the decision with its reasons.
+// Checks and recording that fail are logged and skipped so an outage doesn't block every registration.
+func (s *Service) Assess(ctx context.Context, attempt Attempt) (*Assessment, error) {
+	scoring := s.scoring.Load()
+	assessment := &Assessment{Findings: []Finding{}}
+	for _, check := range scoring.checks {
+		score, reason, err := check.Evaluate(ctx, attempt)
+		if err != nil {
+			logging.FromContext(ctx).Error("signup check failed", "check", check.Name(), "error", err)
+			continue
+		}
+		if score > 0 {
+			assessment.Score += score
+			assessment.Findings = append(assessment.Findings, Finding{Check: check.Name(), Score: score, Reason: reason})
+		}
+	}
+
+	switch {
+	case assessment.Score >= scoring.rejectScore:
+		assessment.Decision = DecisionReject
+	case assessment.Score >= scoring.verifyScore:
+		assessment.Decision = DecisionVerify
+	default:
+		assessment.Decision = DecisionAllow
+	}
+
+	metrics.SignupDecisions.WithLabelValues(assessment.Decision).Inc()
+	logging.FromContext(ctx).Info("signup assessed",
+		"decision", assessment.Decision,
+		"username", attempt.Username,
+		"ip", attempt.IP.String(),
+		"score", assessment.Score,
+		"reasons", assessment.Reasons(),
+	)
+
+	if err := s.repository.RecordAttempt(ctx, attempt, assessment); err != nil {
+		logging.FromContext(ctx).Error("failed to record signup attempt", "error", err)
+	}
+	return assessment, nil
+}
+
+// ListAttempts returns recorded attempts, optionally filtered by decision
+func (s *Service) ListAttempts(ctx context.Context, decision string, limit, offset int) ([]Record, error) {
+	records, err := s.repository.ListAttempts(ctx, decision, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing signup attempts %w", err)
+	}
+	return records, nil
+}
+
+// Run prunes old attempts on every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.repository.DeleteAttemptsBefore(ctx, time.Now().Add(-attemptRetention)); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("signup attempt pruning failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package stream
+
+import (
+	"fmt"
+	"learning/internal/logging"
+	"learning/internal/middleware"
+	"learning/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler serves Server-Sent Events streams
+type Handler struct {
+	hub *Hub
+	cfg Config
+}
+
+// NewHandler creates a new stream handler
+func NewHandler(hub *Hub) *Handler {
+	return &Handler{hub: hub, cfg: hub.cfg}
+}
+
+// RegisterRoutes registers streaming routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.Handle("/stream", middleware.RequireAuth(http.HandlerFunc(h.Stream))).Methods(http.MethodGet)
+}
+
+// Stream pushes the caller's events as Server-Sent Events until the client
+// disconnects or the hub is closed on shutdown
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	rc := http.NewResponseController(w)
+
+	// Streams are long-lived; lift the server-wide WriteTimeout for this connection only
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(r.Context()).Warn("stream write deadline could not be cleared", "error", err)
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+
+	sub, missed, resync := h.hub.Subscribe(userID, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds()); err != nil {
+		return
+	}
+	if resync {
+		if err := writeEvent(w, Event{Type: TypeResync, Data: []byte("{}")}); err != nil {
+			return
+		}
+	}
+	for _, e := range missed {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case e := <-sub.Events():
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes a single event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, e Event) error {
+	if e.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", e.ID); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, e.Data)
+	return err
+}
+
+// parseLastEventID reads the resume position from the Last-Event-ID header,
+// falling back to the lastEventId query parameter for clients that cannot set headers
+func parseLastEventID(r *http.Request) (uint64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
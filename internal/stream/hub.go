@@ -0,0 +1,241 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Event types pushed to clients. Notifications use notification.PublishedEventType.
+const (
+	// TypeFeedItem carries a post just published by a user the recipient follows
+	TypeFeedItem = "feed_item"
+	// TypeResync tells the client its Last-Event-ID fell outside the backlog
+	// and it should refetch state over the REST API
+	TypeResync = "resync"
+)
+
+// Config controls buffering and keep-alive behaviour of streams
+type Config struct {
+	BacklogSize       int           // events kept per user for Last-Event-ID resume
+	BacklogTTL        time.Duration // how long events stay resumable
+	SubscriberBuffer  int           // events queued per connection before it is dropped
+	HeartbeatInterval time.Duration // interval between keep-alive comments
+}
+
+// DefaultConfig returns the default stream configuration
+func DefaultConfig() Config {
+	return Config{
+		BacklogSize:       100,
+		BacklogTTL:        5 * time.Minute,
+		SubscriberBuffer:  64,
+		HeartbeatInterval: 15 * time.Second,
+	}
+}
+
+// Event is a single message delivered to a user's streams
+type Event struct {
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"-"`
+}
+
+// Subscription is one client connection listening for a user's events
+type Subscription struct {
+	userID int
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Events returns the channel on which new events are delivered
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the hub drops the subscription (slow consumer or shutdown)
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// userStream holds the resumable backlog and live subscriptions of one user
+type userStream struct {
+	backlog []Event
+	evicted uint64 // highest event ID dropped from the backlog
+	subs    map[*Subscription]struct{}
+}
+
+// Hub fans events out to every connection of the target user
+type Hub struct {
+	cfg Config
+
+	mu     sync.Mutex
+	nextID uint64
+	users  map[int]*userStream
+	closed bool
+}
+
+// NewHub creates a new event hub
+func NewHub(cfg Config) *Hub {
+	return &Hub{
+		cfg:   cfg,
+		users: make(map[int]*userStream),
+	}
+}
+
+// Publish sends an event to all of the user's connections and records it for resume
+func (h *Hub) Publish(userID int, eventType string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("failed to encode stream event", "event", eventType, "user_id", userID, "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	h.nextID++
+	event := Event{
+		ID:        h.nextID,
+		Type:      eventType,
+		Data:      payload,
+		CreatedAt: time.Now(),
+	}
+
+	us, _ := h.user(userID)
+	us.backlog = append(us.backlog, event)
+	h.trim(us, event.CreatedAt)
+
+	for sub := range us.subs {
+		select {
+		case sub.events <- event:
+		default:
+			// The client fell behind; drop it so it reconnects and resumes from the backlog
+			delete(us.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// Subscribe registers a new connection for the user. It returns the backlog events
+// newer than lastEventID and whether the client must resync because events were lost.
+func (h *Hub) Subscribe(userID int, lastEventID uint64) (*Subscription, []Event, bool) {
+	sub := &Subscription{
+		userID: userID,
+		events: make(chan Event, h.cfg.SubscriberBuffer),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.close()
+		return sub, nil, false
+	}
+
+	us, existed := h.user(userID)
+	h.trim(us, time.Now())
+	us.subs[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, false
+	}
+
+	var missed []Event
+	for _, e := range us.backlog {
+		if e.ID > lastEventID {
+			missed = append(missed, e)
+		}
+	}
+
+	// Events may have been lost if some were evicted after lastEventID, if the user's
+	// backlog was discarded entirely, or if the ID predates a restart of this process
+	resync := us.evicted > lastEventID ||
+		(!existed && lastEventID < h.nextID) ||
+		lastEventID > h.nextID
+	return sub, missed, resync
+}
+
+// Unsubscribe removes a connection from the hub
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if us, ok := h.users[sub.userID]; ok {
+		delete(us.subs, sub)
+	}
+	sub.close()
+}
+
+// Close disconnects every subscriber and rejects new ones. It is registered with
+// http.Server.RegisterOnShutdown so open streams do not hold up a graceful shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, us := range h.users {
+		for sub := range us.subs {
+			sub.close()
+		}
+	}
+	h.users = make(map[int]*userStream)
+}
+
+// Run periodically evicts expired backlogs of users without connections until ctx is cancelled
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.cfg.BacklogTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			for userID, us := range h.users {
+				h.trim(us, now)
+				if len(us.subs) == 0 && len(us.backlog) == 0 {
+					delete(h.users, userID)
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// user returns the stream state for userID, creating it if needed, and whether it
+// already existed. Callers hold h.mu.
+func (h *Hub) user(userID int) (*userStream, bool) {
+	us, ok := h.users[userID]
+	if !ok {
+		us = &userStream{subs: make(map[*Subscription]struct{})}
+		h.users[userID] = us
+	}
+	return us, ok
+}
+
+// trim drops backlog events beyond the size limit or older than the TTL. Callers hold h.mu.
+func (h *Hub) trim(us *userStream, now time.Time) {
+	drop := 0
+	if excess := len(us.backlog) - h.cfg.BacklogSize; excess > 0 {
+		drop = excess
+	}
+	for drop < len(us.backlog) && now.Sub(us.backlog[drop].CreatedAt) > h.cfg.BacklogTTL {
+		drop++
+	}
+	if drop > 0 {
+		us.evicted = us.backlog[drop-1].ID
+		us.backlog = append([]Event(nil), us.backlog[drop:]...)
+	}
+}
@@ -0,0 +1,14 @@
+package stream
+
+import "github.com/gorilla/mux"
+
+// RegisterRoutes is a convenience wrapper when you already have a Handler
+func RegisterRoutes(r *mux.Router, h *Handler) {
+	h.RegisterRoutes(r)
+}
+
+// Register composes hub -> handler and registers routes
+func Register(r *mux.Router, hub *Hub) {
+	h := NewHandler(hub)
+	h.RegisterRoutes(r)
+}
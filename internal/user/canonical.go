@@ -0,0 +1,155 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Username canonicalization errors
+var (
+	errUsernameCharacters = errors.New("usernames may only contain letters, digits and underscores")
+	errUsernameMixed      = errors.New("usernames may not mix letters from different scripts")
+	errUsernameConfusable = errors.New("username looks like a Latin username written in another script")
+	errInvalidEmail       = errors.New("invalid email address")
+)
+
+// NormalizeUsername returns the NFKC form of a username, keeping its case for display.
+// It rejects characters other than letters, ASCII digits and underscores, letters from
+// more than one script (except the Han/Kana and Han/Hangul combinations used by Japanese
+// and Korean) and names spelled entirely with Cyrillic or Greek lookalikes of Latin letters.
+// Uniqueness is enforced on lower(username) by a unique index.
+func NormalizeUsername(raw string) (string, error) {
+	username := norm.NFKC.String(strings.TrimSpace(raw))
+
+	scripts := make(map[string]bool)
+	lookalikes := true
+	for _, r := range username {
+		switch {
+		case r == '_' || r >= '0' && r <= '9':
+			continue
+		case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r):
+			// Combining marks inherit the script of the letter they follow
+			continue
+		case !unicode.IsLetter(r):
+			return "", errUsernameCharacters
+		}
+
+		script := scriptOf(r)
+		if script == "" {
+			return "", errUsernameCharacters
+		}
+		scripts[script] = true
+		if !latinLookalikes[unicode.ToLower(r)] {
+			lookalikes = false
+		}
+	}
+
+	if !compatibleScripts(scripts) {
+		return "", errUsernameMixed
+	}
+	if lookalikes && (scripts["Cyrillic"] || scripts["Greek"]) {
+		return "", errUsernameConfusable
+	}
+	return username, nil
+}
+
+// usernameScripts are the scripts a username may be written in
+var usernameScripts = []string{
+	"Latin", "Cyrillic", "Greek", "Armenian", "Georgian", "Hebrew", "Arabic", "Devanagari",
+	"Bengali", "Tamil", "Telugu", "Thai", "Han", "Hiragana", "Katakana", "Hangul",
+}
+
+// scriptOf returns the name of r's script, or "" if it is not an allowed username script
+func scriptOf(r rune) string {
+	for _, name := range usernameScripts {
+		if unicode.Is(unicode.Scripts[name], r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// compatibleScripts reports whether the scripts seen in one username may be combined
+func compatibleScripts(scripts map[string]bool) bool {
+	if len(scripts) <= 1 {
+		return true
+	}
+
+	var allowed map[string]bool
+	switch {
+	case scripts["Hiragana"] || scripts["Katakana"]:
+		allowed = map[string]bool{"Han": true, "Hiragana": true, "Katakana": true}
+	case scripts["Hangul"]:
+		allowed = map[string]bool{"Han": true, "Hangul": true}
+	default:
+		return false
+	}
+
+	for script := range scripts {
+		if !allowed[script] {
+			return false
+		}
+	}
+	return true
+}
+
+// latinLookalikes are lowercase Cyrillic and Greek letters commonly mistaken for Latin ones
+var latinLookalikes = map[rune]bool{
+	'а': true, 'в': true, 'е': true, 'к': true, 'м': true, 'н': true, 'о': true, 'р': true,
+	'с': true, 'т': true, 'у': true, 'х': true, 'і': true, 'ј': true, 'ѕ': true, 'һ': true,
+	'ԁ': true, 'ԛ': true, 'ԝ': true, 'ӏ': true,
+	'α': true, 'β': true, 'γ': true, 'ε': true, 'ι': true, 'κ': true, 'ν': true, 'ο': true,
+	'ρ': true, 'τ': true, 'υ': true, 'χ': true,
+}
+
+// emailProviders maps a mail domain to the rules its provider applies to local parts
+var emailProviders = map[string]struct {
+	domain     string // canonical domain for providers with several
+	ignoreDots bool
+}{
+	"gmail.com":      {domain: "gmail.com", ignoreDots: true},
+	"googlemail.com": {domain: "gmail.com", ignoreDots: true},
+	"outlook.com":    {domain: "outlook.com"},
+	"hotmail.com":    {domain: "hotmail.com"},
+	"live.com":       {domain: "live.com"},
+	"icloud.com":     {domain: "icloud.com"},
+	"me.com":         {domain: "icloud.com"},
+	"mac.com":        {domain: "icloud.com"},
+	"fastmail.com":   {domain: "fastmail.com"},
+	"protonmail.com": {domain: "proton.me"},
+	"proton.me":      {domain: "proton.me"},
+}
+
+// NormalizeEmail returns the address to store, with its domain lowercased, and the
+// canonical form used for uniqueness. The canonical form is fully lowercased and, for
+// the providers in emailProviders, drops "+tag" suffixes and (for Gmail) dots, so that
+// addresses delivering to the same mailbox cannot register twice.
+func NormalizeEmail(raw string) (address, canonical string, err error) {
+	address = strings.TrimSpace(raw)
+	at := strings.LastIndexByte(address, '@')
+	if at <= 0 || at == len(address)-1 {
+		return "", "", errInvalidEmail
+	}
+
+	local, domain := address[:at], strings.ToLower(address[at+1:])
+	address = local + "@" + domain
+
+	key := strings.ToLower(local)
+	if provider, ok := emailProviders[domain]; ok {
+		if i := strings.IndexByte(key, '+'); i >= 0 {
+			key = key[:i]
+		}
+		if provider.ignoreDots {
+			key = strings.ReplaceAll(key, ".", "")
+		}
+		if key == "" {
+			return "", "", errInvalidEmail
+		}
+		domain = provider.domain
+	}
+
+	return address, key + "@" + domain, nil
+}
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{name: "keeps case", raw: "Alice", want: "Alice"},
+		{name: "trims spaces", raw: "  bob_1 ", want: "bob_1"},
+		{name: "fullwidth folded", raw: "ｊｏｈｎ", want: "john"},
+		{name: "accent composed", raw: "cafe\u0301", want: "caf\u00e9"},
+		{name: "halfwidth katakana folded", raw: "日本ｶﾀｶﾅ", want: "日本カタカナ"},
+		{name: "cyrillic", raw: "Дима", want: "Дима"},
+		{name: "han with hiragana", raw: "東京たろう", want: "東京たろう"},
+		{name: "hangul with han", raw: "김哲洙", want: "김哲洙"},
+		{name: "greek", raw: "λογος", want: "λογος"},
+		{name: "hyphen", raw: "bob-smith", wantErr: errUsernameCharacters},
+		{name: "inner space", raw: "bob smith", wantErr: errUsernameCharacters},
+		{name: "non-ascii digit", raw: "bob١", wantErr: errUsernameCharacters},
+		{name: "emoji", raw: "bob😀", wantErr: errUsernameCharacters},
+		{name: "latin with cyrillic", raw: "pаypal", wantErr: errUsernameMixed},
+		{name: "hangul with latin", raw: "김abc", wantErr: errUsernameMixed},
+		{name: "cyrillic lookalikes", raw: "рау", wantErr: errUsernameConfusable},
+		{name: "uppercase cyrillic lookalikes", raw: "РАУ", wantErr: errUsernameConfusable},
+		{name: "greek lookalikes", raw: "οκ", wantErr: errUsernameConfusable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeUsername(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeUsername(%q) error = %v, want %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeUsername(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		raw           string
+		wantAddress   string
+		wantCanonical string
+		wantErr       error
+	}{
+		{raw: "Alice@Example.COM", wantAddress: "Alice@example.com", wantCanonical: "alice@example.com"},
+		{raw: " first.last+tag@example.org ", wantAddress: "first.last+tag@example.org", wantCanonical: "first.last+tag@example.org"},
+		{raw: "J.Doe+news@GMail.com", wantAddress: "J.Doe+news@gmail.com", wantCanonical: "jdoe@gmail.com"},
+		{raw: "j.doe@googlemail.com", wantAddress: "j.doe@googlemail.com", wantCanonical: "jdoe@gmail.com"},
+		{raw: "first.last+x@outlook.com", wantAddress: "first.last+x@outlook.com", wantCanonical: "first.last@outlook.com"},
+		{raw: "me+x@me.com", wantAddress: "me+x@me.com", wantCanonical: "me@icloud.com"},
+		{raw: "a.b+c@protonmail.com", wantAddress: "a.b+c@protonmail.com", wantCanonical: "a.b@proton.me"},
+		{raw: "+tag@gmail.com", wantErr: errInvalidEmail},
+		{raw: "...@gmail.com", wantErr: errInvalidEmail},
+		{raw: "no-at-sign", wantErr: errInvalidEmail},
+		{raw: "@example.com", wantErr: errInvalidEmail},
+		{raw: "user@", wantErr: errInvalidEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			address, canonical, err := NormalizeEmail(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeEmail(%q) error = %v, want %v", tt.raw, err, tt.wantErr)
+			}
+			if address != tt.wantAddress || canonical != tt.wantCanonical {
+				t.Errorf("NormalizeEmail(%q) = %q, %q, want %q, %q", tt.raw, address, canonical, tt.wantAddress, tt.wantCanonical)
+			}
+		})
+	}
+}
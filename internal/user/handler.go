@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	apperrors "learning/internal/errors"
+	"learning/internal/middleware"
+	"learning/internal/ratelimit"
 	"learning/internal/utils"
 	"net/http"
 	"strconv"
@@ -12,20 +14,45 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// Rate limits for unauthenticated routes that create accounts or consume tokens
+var (
+	createPolicy = ratelimit.Policy{Name: "users.create", Limit: ratelimit.PerHour(10), Key: ratelimit.ByIP}
+	verifyPolicy = ratelimit.Policy{Name: "users.verify-email", Limit: ratelimit.PerMinute(10), Key: ratelimit.ByIP}
+)
+
 // Handler handles user-related HTTP requests
 type Handler struct {
-	service ServiceInterface
+	service    ServiceInterface
+	limiter    *ratelimit.Limiter
+	byUsername *mux.Route
 }
 
-// NewHandler creates a new user handler
-func NewHandler(service ServiceInterface) *Handler {
-	return &Handler{service: service}
+// NewHandler creates a new user handler. A nil limiter disables per-route rate limits.
+func NewHandler(service ServiceInterface, limiter *ratelimit.Limiter) *Handler {
+	return &Handler{service: service, limiter: limiter}
 }
 
 // RegisterRoutes registers user-related routes
 func (h *Handler) RegisterRoutes(r *mux.Router) {
-	r.HandleFunc("/users", h.Create).Methods(http.MethodPost)
+	r.Handle("/users", h.limiter.Handler(createPolicy, http.HandlerFunc(h.Create))).Methods(http.MethodPost)
+	r.Handle("/users/verify-email", h.limiter.Handler(verifyPolicy, http.HandlerFunc(h.VerifyEmail))).Methods(http.MethodPost)
 	r.HandleFunc("/users/{id}", h.GetByID).Methods(http.MethodGet)
+	h.byUsername = r.HandleFunc("/users/by-username/{username}", h.GetByUsername).Methods(http.MethodGet)
+
+	mr := r.PathPrefix("/users/me").Subrouter()
+	mr.Use(middleware.RequireAuth)
+
+	mr.HandleFunc("/profile", h.UpdateProfile).Methods(http.MethodPatch)
+	mr.HandleFunc("/username", h.ChangeUsername).Methods(http.MethodPut)
+	mr.HandleFunc("/links/verify", h.VerifyLinks).Methods(http.MethodPost)
+
+	ar := r.PathPrefix("/admin").Subrouter()
+	ar.Use(middleware.RequireAdmin)
+
+	ar.HandleFunc("/users/{id}/verified", h.SetVerified).Methods(http.MethodPut)
+	ar.HandleFunc("/reserved-usernames", h.ListReserved).Methods(http.MethodGet)
+	ar.HandleFunc("/reserved-usernames", h.AddReserved).Methods(http.MethodPost)
+	ar.HandleFunc("/reserved-usernames/{id}", h.RemoveReserved).Methods(http.MethodDelete)
 }
 
 // Create handles user creation requests
@@ -36,15 +63,37 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.CreateUser(r.Context(), &req)
+	user, err := h.service.CreateUser(r.Context(), &req, utils.ClientIP(r))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	// Convert User to UserResponse to exclude password. Accounts awaiting email
+	// verification are created inactive.
+	userResponse := ToUserResponse(user, AudienceSelf)
+	status := http.StatusCreated
+	if !user.Active {
+		status = http.StatusAccepted
+	}
+	utils.WriteSuccess(w, status, userResponse)
+}
+
+// VerifyEmail handles confirming an email address with the token from a verification link
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	user, err := h.service.VerifyEmail(r.Context(), &req)
 	if err != nil {
 		h.handleError(w, err)
 		return
 	}
 
-	// Convert User to UserResponse to exclude password
-	userResponse := ToUserResponse(user)
-	utils.WriteSuccess(w, http.StatusCreated, userResponse)
+	utils.WriteSuccess(w, http.StatusOK, ToUserResponse(user, AudienceSelf))
 }
 
 // GetByID handles user retrieval by ID
@@ -62,11 +111,174 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert User to UserResponse to exclude password
-	userResponse := ToUserResponse(user)
+	h.writeProfile(w, r, user)
+}
+
+// writeProfile writes user as seen by the requesting viewer
+func (h *Handler) writeProfile(w http.ResponseWriter, r *http.Request, user *User) {
+	viewerID, _ := middleware.UserIDFromContext(r.Context())
+	audience, err := h.service.ResolveAudience(r.Context(), viewerID, user.ID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	// Convert User to UserResponse to exclude password and hidden profile fields
+	userResponse := ToUserResponse(user, audience)
 	utils.WriteSuccess(w, http.StatusOK, userResponse)
 }
 
+// GetByUsername handles user retrieval by username. A previous username answers with
+// 302 Found pointing at the user's current username.
+func (h *Handler) GetByUsername(w http.ResponseWriter, r *http.Request) {
+	user, redirect, err := h.service.GetUserByUsername(r.Context(), mux.Vars(r)["username"])
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	if redirect != nil {
+		location, err := h.byUsername.URL("username", redirect.Username)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+		w.Header().Set("Location", location.String())
+		utils.WriteSuccess(w, http.StatusFound, redirect)
+		return
+	}
+
+	h.writeProfile(w, r, user)
+}
+
+// ChangeUsername handles changing the caller's username
+func (h *Handler) ChangeUsername(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req ChangeUsernameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	user, err := h.service.ChangeUsername(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, ToUserResponse(user, AudienceSelf))
+}
+
+// UpdateProfile handles partial updates of the caller's profile fields
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	user, err := h.service.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	// Other fields are saved; a held bio is published once reviewed
+	status := http.StatusOK
+	if user.BioPending {
+		status = http.StatusAccepted
+	}
+	utils.WriteSuccess(w, status, ToUserResponse(user, AudienceSelf))
+}
+
+// VerifyLinks handles re-checking the caller's profile links for rel="me" backlinks
+func (h *Handler) VerifyLinks(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	user, err := h.service.VerifyLinks(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, ToUserResponse(user, AudienceSelf).Links)
+}
+
+// SetVerified handles granting or revoking a user's verified badge
+func (h *Handler) SetVerified(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var req SetVerifiedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.service.SetVerified(r.Context(), id, req.Verified); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	if req.Verified {
+		utils.WriteMessage(w, http.StatusOK, "user verified")
+		return
+	}
+	utils.WriteMessage(w, http.StatusOK, "verification revoked")
+}
+
+// ListReserved handles listing the stored reserved username patterns
+func (h *Handler) ListReserved(w http.ResponseWriter, r *http.Request) {
+	reserved, err := h.service.ListReservedUsernames(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, reserved)
+}
+
+// AddReserved handles reserving a username pattern
+func (h *Handler) AddReserved(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := middleware.UserIDFromContext(r.Context())
+
+	var req CreateReservedUsernameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	reserved, err := h.service.AddReservedUsername(r.Context(), adminID, &req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, reserved)
+}
+
+// RemoveReserved handles deleting a stored reserved username pattern
+func (h *Handler) RemoveReserved(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid reserved username id")
+		return
+	}
+
+	if err := h.service.RemoveReservedUsername(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	utils.WriteMessage(w, http.StatusOK, "reserved username removed")
+}
+
 // handleError processes errors and returns appropriate HTTP responses
 func (h *Handler) handleError(w http.ResponseWriter, err error) {
 	var appErr *apperrors.AppError
@@ -2,49 +2,194 @@ package user
 
 import "time"
 
+// MaxProfileLinks is the number of external links a profile may list
+const MaxProfileLinks = 5
+
+// UsernameChangeCooldown is the minimum time between two username changes
+const UsernameChangeCooldown = 30 * 24 * time.Hour
+
+// UsernameReservation is how long a released username stays reserved for its previous owner
+const UsernameReservation = 90 * 24 * time.Hour
+
+// EmailVerificationTTL is how long an email verification link stays valid
+const EmailVerificationTTL = 48 * time.Hour
+
+// birthdayLayout is the wire format of birthdays
+const birthdayLayout = "2006-01-02"
+
+// Visibility controls who may see a profile field
+type Visibility string
+
+const (
+	VisibilityPublic    Visibility = "public"
+	VisibilityFollowers Visibility = "followers"
+	VisibilityOnlyMe    Visibility = "only_me"
+)
+
+// Audience describes how a viewer relates to the profile being viewed
+type Audience int
+
+const (
+	AudiencePublic Audience = iota
+	AudienceFollower
+	AudienceSelf
+)
+
+// allows reports whether a field with visibility v may be shown to audience a
+func (v Visibility) allows(a Audience) bool {
+	switch v {
+	case VisibilityPublic:
+		return true
+	case VisibilityFollowers:
+		return a >= AudienceFollower
+	default:
+		return a == AudienceSelf
+	}
+}
+
 // User represents a user entity in the system
 type User struct {
-	ID         int       `json:"id" db:"id"`
-	Username   string    `json:"username" db:"username"`
-	Email      string    `json:"email" db:"email"`
-	Name       string    `json:"name" db:"name"`
-	Password   string    `json:"-" db:"password"`
-	MiddleName *string   `json:"middle_name,omitempty" db:"middle_name"`
-	Surname    *string   `json:"surname,omitempty" db:"surname"`
-	Bio        *string   `json:"bio,omitempty" db:"bio"`
-	Active     bool      `json:"active" db:"active"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	ID                 int        `json:"id" db:"id"`
+	Username           string     `json:"username" db:"username"`
+	Email              string     `json:"email" db:"email"`
+	Name               string     `json:"name" db:"name"`
+	Password           string     `json:"-" db:"password"`
+	MiddleName         *string    `json:"middle_name,omitempty" db:"middle_name"`
+	Surname            *string    `json:"surname,omitempty" db:"surname"`
+	Bio                *string    `json:"bio,omitempty" db:"bio"`
+	AvatarURL          *string    `json:"avatar_url,omitempty" db:"avatar_url"`
+	BannerURL          *string    `json:"banner_url,omitempty" db:"banner_url"`
+	Location           *string    `json:"location,omitempty" db:"location"`
+	LocationVisibility Visibility `json:"location_visibility" db:"location_visibility"`
+	Birthday           *time.Time `json:"birthday,omitempty" db:"birthday"`
+	BirthdayVisibility Visibility `json:"birthday_visibility" db:"birthday_visibility"`
+	Pronouns           *string    `json:"pronouns,omitempty" db:"pronouns"`
+	PronounsVisibility Visibility `json:"pronouns_visibility" db:"pronouns_visibility"`
+	Verified           bool       `json:"verified" db:"verified"`
+	UsernameChangedAt  *time.Time `json:"username_changed_at,omitempty" db:"username_changed_at"`
+	Links              []Link     `json:"links" db:"-"`
+	BioPending         bool       `json:"-" db:"-"` // a new bio is waiting for moderator review
+	Active             bool       `json:"active" db:"active"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Link is an external link shown on a profile. VerifiedAt is set once the linked
+// page has been seen linking back to the profile with rel="me".
+type Link struct {
+	ID         int        `json:"id" db:"id"`
+	URL        string     `json:"url" db:"url"`
+	Label      *string    `json:"label,omitempty" db:"label"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty" db:"verified_at"`
 }
 
 // CreateUserRequest represents the request payload for creating a user
 type CreateUserRequest struct {
-	Username   string  `json:"username" validate:"required,min=3,max=50"`
+	Username   string  `json:"username" validate:"required,min=3,max=50,username_allowed"`
 	Email      string  `json:"email" validate:"required,email"`
 	Name       string  `json:"name" validate:"required"`
 	Password   string  `json:"password" validate:"required,min=6"`
 	MiddleName *string `json:"middle_name,omitempty"`
 	Surname    *string `json:"surname,omitempty"`
-	Bio        *string `json:"bio,omitempty"`
+	Bio        *string `json:"bio,omitempty" validate:"omitempty,max=1000"`
+	Website    string  `json:"website,omitempty"` // honeypot: hidden in the signup form, so only bots fill it in
+}
+
+// EmailVerification is a pending email confirmation; only a hash of the token is stored
+type EmailVerification struct {
+	TokenHash []byte
+	ExpiresAt time.Time
+}
+
+// VerifyEmailRequest represents the payload for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,hexadecimal,len=64"`
+}
+
+// UpdateProfileRequest represents a partial update of profile fields; omitted fields are left unchanged
+// and empty strings clear a field
+type UpdateProfileRequest struct {
+	Bio                *string        `json:"bio,omitempty" validate:"omitempty,max=1000"`
+	Location           *string        `json:"location,omitempty" validate:"omitempty,max=100"`
+	LocationVisibility *Visibility    `json:"location_visibility,omitempty" validate:"omitempty,oneof=public followers only_me"`
+	Birthday           *string        `json:"birthday,omitempty"`
+	BirthdayVisibility *Visibility    `json:"birthday_visibility,omitempty" validate:"omitempty,oneof=public followers only_me"`
+	Pronouns           *string        `json:"pronouns,omitempty" validate:"omitempty,max=40"`
+	PronounsVisibility *Visibility    `json:"pronouns_visibility,omitempty" validate:"omitempty,oneof=public followers only_me"`
+	Links              *[]LinkRequest `json:"links,omitempty" validate:"omitempty,dive"`
+}
+
+// LinkRequest represents one external link in a profile update
+type LinkRequest struct {
+	URL   string  `json:"url" validate:"required,max=2048,http_url"`
+	Label *string `json:"label,omitempty" validate:"omitempty,max=50"`
+}
+
+// ChangeUsernameRequest represents the payload for changing the caller's username
+type ChangeUsernameRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50,username_allowed"`
+}
+
+// UsernameRedirect is returned with a redirect when a lookup matches a previous username
+type UsernameRedirect struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// ReservedUsername is a stored reserved username pattern, managed by administrators
+type ReservedUsername struct {
+	ID        int       `json:"id" db:"id"`
+	Pattern   string    `json:"pattern" db:"pattern"`
+	Reason    *string   `json:"reason,omitempty" db:"reason"`
+	CreatedBy *int      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateReservedUsernameRequest represents the payload for reserving a username pattern
+type CreateReservedUsernameRequest struct {
+	Pattern string  `json:"pattern" validate:"required,max=100"`
+	Reason  *string `json:"reason,omitempty" validate:"omitempty,max=255"`
+}
+
+// SetVerifiedRequest represents the payload for granting or revoking the verified badge
+type SetVerifiedRequest struct {
+	Verified bool `json:"verified"`
+}
+
+// ProfileVisibility is returned to the profile owner so they can see their settings
+type ProfileVisibility struct {
+	Location Visibility `json:"location"`
+	Birthday Visibility `json:"birthday"`
+	Pronouns Visibility `json:"pronouns"`
 }
 
 // UserResponse represents the user data returned in API responses (password excluded)
 type UserResponse struct {
-	ID         int       `json:"id"`
-	Username   string    `json:"username"`
-	Email      string    `json:"email"`
-	Name       string    `json:"name"`
-	MiddleName *string   `json:"middle_name,omitempty"`
-	Surname    *string   `json:"surname,omitempty"`
-	Bio        *string   `json:"bio,omitempty"`
-	Active     bool      `json:"active"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-}
-
-// ToUserResponse converts a User to UserResponse (excluding password)
-func ToUserResponse(user *User) *UserResponse {
-	return &UserResponse{
+	ID         int                `json:"id"`
+	Username   string             `json:"username"`
+	Email      string             `json:"email"`
+	Name       string             `json:"name"`
+	MiddleName *string            `json:"middle_name,omitempty"`
+	Surname    *string            `json:"surname,omitempty"`
+	Bio        *string            `json:"bio,omitempty"`
+	BioPending bool               `json:"bio_pending,omitempty"`
+	AvatarURL  *string            `json:"avatar_url,omitempty"`
+	BannerURL  *string            `json:"banner_url,omitempty"`
+	Location   *string            `json:"location,omitempty"`
+	Birthday   *string            `json:"birthday,omitempty"`
+	Pronouns   *string            `json:"pronouns,omitempty"`
+	Links      []Link             `json:"links"`
+	Verified   bool               `json:"verified"`
+	Visibility *ProfileVisibility `json:"visibility,omitempty"`
+	Active     bool               `json:"active"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// ToUserResponse converts a User to UserResponse (excluding password), hiding
+// profile fields whose visibility does not include the viewer's audience
+func ToUserResponse(user *User, audience Audience) *UserResponse {
+	resp := &UserResponse{
 		ID:         user.ID,
 		Username:   user.Username,
 		Email:      user.Email,
@@ -52,8 +197,37 @@ func ToUserResponse(user *User) *UserResponse {
 		MiddleName: user.MiddleName,
 		Surname:    user.Surname,
 		Bio:        user.Bio,
+		AvatarURL:  user.AvatarURL,
+		BannerURL:  user.BannerURL,
+		Links:      user.Links,
+		Verified:   user.Verified,
 		Active:     user.Active,
 		CreatedAt:  user.CreatedAt,
 		UpdatedAt:  user.UpdatedAt,
 	}
+	if resp.Links == nil {
+		resp.Links = []Link{}
+	}
+
+	if user.LocationVisibility.allows(audience) {
+		resp.Location = user.Location
+	}
+	if user.PronounsVisibility.allows(audience) {
+		resp.Pronouns = user.Pronouns
+	}
+	if user.Birthday != nil && user.BirthdayVisibility.allows(audience) {
+		birthday := user.Birthday.Format(birthdayLayout)
+		resp.Birthday = &birthday
+	}
+
+	if audience == AudienceSelf {
+		resp.BioPending = user.BioPending
+		resp.Visibility = &ProfileVisibility{
+			Location: user.LocationVisibility,
+			Birthday: user.BirthdayVisibility,
+			Pronouns: user.PronounsVisibility,
+		}
+	}
+
+	return resp
 }
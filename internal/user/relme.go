@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxRelMePageBytes bounds how much of a linked page is read while looking for rel="me"
+const maxRelMePageBytes = 1 << 20
+
+// errNonPublicAddress is returned when a link resolves to a loopback, private or otherwise internal address
+var errNonPublicAddress = errors.New("link resolves to a non-public address")
+
+// LinkVerifier checks whether an external page links back to a profile
+type LinkVerifier interface {
+	Verify(ctx context.Context, pageURL string, profileURLs []string) (bool, error)
+}
+
+// RelMeVerifier fetches linked pages and looks for <a rel="me"> or <link rel="me">
+// pointing at one of the profile's URLs
+type RelMeVerifier struct {
+	client *http.Client
+}
+
+// Ensure RelMeVerifier implements LinkVerifier
+var _ LinkVerifier = (*RelMeVerifier)(nil)
+
+// NewRelMeVerifier creates a verifier whose HTTP client only connects to public addresses,
+// so user-supplied links cannot be used to probe internal services
+func NewRelMeVerifier() *RelMeVerifier {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return errNonPublicAddress
+			}
+			return nil
+		},
+	}
+
+	return &RelMeVerifier{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   5 * time.Second,
+				ResponseHeaderTimeout: 5 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Verify fetches pageURL and reports whether it carries a rel="me" link to any of profileURLs
+func (v *RelMeVerifier) Verify(ctx context.Context, pageURL string, profileURLs []string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return false, nil
+	}
+
+	targets := make(map[string]bool, len(profileURLs))
+	for _, u := range profileURLs {
+		targets[canonicalURL(u)] = true
+	}
+
+	// Relative hrefs resolve against the final URL after redirects
+	base := resp.Request.URL
+	tokenizer := html.NewTokenizer(io.LimitReader(resp.Body, maxRelMePageBytes))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to parse %s: %w", pageURL, tokenizer.Err())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data != "a" && token.Data != "link" {
+				continue
+			}
+			href, rel := "", ""
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case "href":
+					href = attr.Val
+				case "rel":
+					rel = attr.Val
+				}
+			}
+			if href == "" || !hasRelMe(rel) {
+				continue
+			}
+			target, err := base.Parse(href)
+			if err == nil && targets[canonicalURL(target.String())] {
+				return true, nil
+			}
+		}
+	}
+}
+
+// hasRelMe reports whether the space-separated rel attribute contains "me"
+func hasRelMe(rel string) bool {
+	for _, value := range strings.Fields(rel) {
+		if strings.EqualFold(value, "me") {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalURL normalizes a URL for comparison: lowercase scheme and host, no fragment or trailing slash
+func canonicalURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
@@ -2,11 +2,25 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"learning/internal/database"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Sentinel errors returned by the repository
+var (
+	errNotFound             = errors.New("user not found")
+	errUsernameTaken        = errors.New("username is taken")
+	errEmailTaken           = errors.New("email is taken")
+	errUsernameCooldown     = errors.New("username changed too recently")
+	errReservedNotFound     = errors.New("reserved username not found")
+	errReservedExists       = errors.New("reserved username already exists")
+	errVerificationNotFound = errors.New("email verification not found")
 )
 
 type Repository struct {
@@ -18,8 +32,20 @@ var _ RepositoryInterface = (*Repository)(nil)
 
 // RepositoryInterface defines persistence operations for users
 type RepositoryInterface interface {
-	CreateUser(ctx context.Context, user *CreateUserRequest, hashedPassword string) (*User, error)
+	CreateUser(ctx context.Context, user *CreateUserRequest, canonicalEmail, hashedPassword string, verification *EmailVerification) (*User, error)
+	VerifyEmail(ctx context.Context, tokenHash []byte, now time.Time) (int, error)
 	GetUserById(ctx context.Context, id int) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	FindUsernameRedirect(ctx context.Context, username string) (*UsernameRedirect, error)
+	ChangeUsername(ctx context.Context, id int, username string, cooldown, reservation time.Duration) error
+	UpdateProfile(ctx context.Context, user *User, replaceLinks bool) error
+	SetLinkVerified(ctx context.Context, linkID int, verifiedAt *time.Time) error
+	SetVerified(ctx context.Context, id int, verified bool) error
+	SetAdmin(ctx context.Context, username string, admin bool) error
+	SetBio(ctx context.Context, id int, bio *string) error
+	ListReservedUsernames(ctx context.Context) ([]ReservedUsername, error)
+	CreateReservedUsername(ctx context.Context, pattern string, reason *string, createdBy int) (*ReservedUsername, error)
+	DeleteReservedUsername(ctx context.Context, id int) error
 }
 
 // NewRepository creates a new user repository
@@ -27,6 +53,11 @@ func NewRepository(db *database.DataBase) *Repository {
 	return &Repository{db: db}
 }
 
+// userColumns is the column list matching scanUserFromRow
+const userColumns = `id, username, email, name, password, middle_name, surname, bio, avatar_url, banner_url,
+        location, location_visibility, birthday, birthday_visibility, pronouns, pronouns_visibility, verified,
+        username_changed_at, active, created_at, updated_at`
+
 // scanUserFromRow scans a database row into a User model
 func (r *Repository) scanUserFromRow(row pgx.Row) (*User, error) {
 	var user User
@@ -40,6 +71,16 @@ func (r *Repository) scanUserFromRow(row pgx.Row) (*User, error) {
 		&user.MiddleName,
 		&user.Surname,
 		&user.Bio,
+		&user.AvatarURL,
+		&user.BannerURL,
+		&user.Location,
+		&user.LocationVisibility,
+		&user.Birthday,
+		&user.BirthdayVisibility,
+		&user.Pronouns,
+		&user.PronounsVisibility,
+		&user.Verified,
+		&user.UsernameChangedAt,
 		&user.Active,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -47,7 +88,7 @@ func (r *Repository) scanUserFromRow(row pgx.Row) (*User, error) {
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, errNotFound
 		}
 		return nil, fmt.Errorf("failed to scan user: %w", err)
 	}
@@ -55,49 +96,434 @@ func (r *Repository) scanUserFromRow(row pgx.Row) (*User, error) {
 	return &user, nil
 }
 
-// CreateUser creates a new user in the database
-func (r *Repository) CreateUser(ctx context.Context, user *CreateUserRequest, hashedPassword string) (*User, error) {
-	query := `
-        INSERT INTO users (username, email, name, password, middle_name, surname, bio, active, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, true, $8, $9)
-        RETURNING id, username, email, name, password, middle_name, surname, bio, active, created_at, updated_at
-    `
+// CreateUser creates a new user in the database. Usernames still reserved by a previous owner are rejected.
+// When verification is set the user is created inactive until the email address is verified.
+func (r *Repository) CreateUser(ctx context.Context, user *CreateUserRequest, canonicalEmail, hashedPassword string, verification *EmailVerification) (*User, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
 
 	now := time.Now()
-	row := r.db.Pool.QueryRow(ctx, query,
+	if err := r.checkReserved(ctx, tx, user.Username, 0, now); err != nil {
+		return nil, err
+	}
+
+	query := `
+        INSERT INTO users (username, email, email_canonical, name, password, middle_name, surname, bio, active, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING ` + userColumns
+
+	row := tx.QueryRow(ctx, query,
 		user.Username,
 		user.Email,
+		canonicalEmail,
 		user.Name,
 		hashedPassword,
 		user.MiddleName,
 		user.Surname,
 		user.Bio,
+		verification == nil,
 		now,
 		now,
 	)
 
 	createdUser, err := r.scanUserFromRow(row)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, fmt.Errorf("failed to create user: %w", mapUniqueViolation(err))
+	}
+
+	if verification != nil {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO email_verifications (user_id, token_hash, expires_at, created_at)
+            VALUES ($1, $2, $3, $4)
+        `, createdUser.ID, verification.TokenHash, verification.ExpiresAt, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email verification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit user: %w", mapUniqueViolation(err))
 	}
 
 	return createdUser, nil
 }
 
+// VerifyEmail consumes an unexpired verification token and activates its user, returning the user ID
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash []byte, now time.Time) (int, error) {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	var userID int
+	err = tx.QueryRow(ctx, `
+        DELETE FROM email_verifications
+        WHERE token_hash = $1 AND expires_at > $2
+        RETURNING user_id
+    `, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errVerificationNotFound
+		}
+		return 0, fmt.Errorf("failed to consume email verification: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET active = true, updated_at = $2 WHERE id = $1`, userID, now); err != nil {
+		return 0, fmt.Errorf("failed to activate user: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit email verification: %w", err)
+	}
+	return userID, nil
+}
+
 // GetUserById retrieves a user by ID from the database
 func (r *Repository) GetUserById(ctx context.Context, id int) (*User, error) {
 	query := `
-        SELECT id, username, email, name, password, middle_name, surname, bio, active, created_at, updated_at
+        SELECT ` + userColumns + `
         FROM users
         WHERE id = $1 AND active = true
     `
 
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	row := r.db.Reader(ctx).QueryRow(ctx, query, id)
 
 	user, err := r.scanUserFromRow(row)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	if user.Links, err = r.getLinks(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
+
+// GetUserByUsername retrieves an active user by their current username, ignoring case
+func (r *Repository) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	query := `
+        SELECT ` + userColumns + `
+        FROM users
+        WHERE lower(username) = lower($1) AND active = true
+    `
+
+	row := r.db.Reader(ctx).QueryRow(ctx, query, username)
+
+	user, err := r.scanUserFromRow(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.Links, err = r.getLinks(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// FindUsernameRedirect returns the active user who most recently gave up username
+func (r *Repository) FindUsernameRedirect(ctx context.Context, username string) (*UsernameRedirect, error) {
+	query := `
+        SELECT u.id, u.username
+        FROM username_history h
+        JOIN users u ON u.id = h.user_id
+        WHERE lower(h.username) = lower($1) AND u.active = true
+        ORDER BY h.changed_at DESC
+        LIMIT 1
+    `
+
+	var redirect UsernameRedirect
+	err := r.db.Reader(ctx).QueryRow(ctx, query, username).Scan(&redirect.ID, &redirect.Username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("failed to find username redirect: %w", err)
+	}
+	return &redirect, nil
+}
+
+// ChangeUsername renames a user, records the old username in the history and reserves it
+// for the given period. It fails with errUsernameCooldown if the previous change is more
+// recent than cooldown.
+func (r *Repository) ChangeUsername(ctx context.Context, id int, username string, cooldown, reservation time.Duration) error {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	var previous string
+	var changedAt *time.Time
+	err = tx.QueryRow(ctx, `SELECT username, username_changed_at FROM users WHERE id = $1 AND active = true FOR UPDATE`, id).
+		Scan(&previous, &changedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errNotFound
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	now := time.Now()
+	if changedAt != nil && now.Before(changedAt.Add(cooldown)) {
+		return errUsernameCooldown
+	}
+	if err := r.checkReserved(ctx, tx, username, id, now); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE users SET username = $2, username_changed_at = $3, updated_at = $3 WHERE id = $1`, id, username, now)
+	if err != nil {
+		return fmt.Errorf("failed to change username: %w", mapUniqueViolation(err))
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO username_history (user_id, username, changed_at, reserved_until)
+        VALUES ($1, $2, $3, $4)
+    `, id, previous, now, now.Add(reservation))
+	if err != nil {
+		return fmt.Errorf("failed to record username history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit username change: %w", mapUniqueViolation(err))
+	}
+	return nil
+}
+
+// checkReserved fails with errUsernameTaken if username is still reserved for a user other than userID
+func (r *Repository) checkReserved(ctx context.Context, tx pgx.Tx, username string, userID int, now time.Time) error {
+	var reserved bool
+	err := tx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM username_history
+            WHERE lower(username) = lower($1) AND user_id <> $2 AND reserved_until > $3
+        )
+    `, username, userID, now).Scan(&reserved)
+	if err != nil {
+		return fmt.Errorf("failed to check username reservation: %w", err)
+	}
+	if reserved {
+		return errUsernameTaken
+	}
+	return nil
+}
+
+// mapUniqueViolation converts unique constraint violations on users into sentinel errors
+func mapUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	if strings.Contains(pgErr.ConstraintName, "email") {
+		return errEmailTaken
+	}
+	return errUsernameTaken
+}
+
+// getLinks returns a user's profile links in display order
+func (r *Repository) getLinks(ctx context.Context, userID int) ([]Link, error) {
+	query := `
+        SELECT id, url, label, verified_at
+        FROM user_links
+        WHERE user_id = $1
+        ORDER BY position
+    `
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		var link Link
+		if err := rows.Scan(&link.ID, &link.URL, &link.Label, &link.VerifiedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate user links: %w", err)
+	}
+
+	return links, nil
+}
+
+// UpdateProfile saves the user's profile fields. When replaceLinks is set the stored links
+// are replaced by user.Links; links whose URL is unchanged keep their verification.
+func (r *Repository) UpdateProfile(ctx context.Context, user *User, replaceLinks bool) error {
+	tx, err := r.db.Querier(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	query := `
+        UPDATE users
+        SET location = $2, location_visibility = $3, birthday = $4, birthday_visibility = $5,
+            pronouns = $6, pronouns_visibility = $7, bio = $8, updated_at = $9
+        WHERE id = $1 AND active = true
+        RETURNING updated_at
+    `
+
+	err = tx.QueryRow(ctx, query,
+		user.ID,
+		user.Location,
+		user.LocationVisibility,
+		user.Birthday,
+		user.BirthdayVisibility,
+		user.Pronouns,
+		user.PronounsVisibility,
+		user.Bio,
+		time.Now(),
+	).Scan(&user.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errNotFound
+		}
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	if replaceLinks {
+		urls := make([]string, len(user.Links))
+		for i, link := range user.Links {
+			urls[i] = link.URL
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM user_links WHERE user_id = $1 AND url <> ALL($2)`, user.ID, urls); err != nil {
+			return fmt.Errorf("failed to remove user links: %w", err)
+		}
+
+		for i := range user.Links {
+			link := &user.Links[i]
+			err := tx.QueryRow(ctx, `
+                INSERT INTO user_links (user_id, position, url, label)
+                VALUES ($1, $2, $3, $4)
+                ON CONFLICT (user_id, url) DO UPDATE SET position = EXCLUDED.position, label = EXCLUDED.label
+                RETURNING id, verified_at
+            `, user.ID, i, link.URL, link.Label).Scan(&link.ID, &link.VerifiedAt)
+			if err != nil {
+				return fmt.Errorf("failed to save user link: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit profile: %w", err)
+	}
+	return nil
+}
+
+// SetLinkVerified records the outcome of a rel="me" check; nil clears the verification
+func (r *Repository) SetLinkVerified(ctx context.Context, linkID int, verifiedAt *time.Time) error {
+	if _, err := r.db.Querier(ctx).Exec(ctx, `UPDATE user_links SET verified_at = $2 WHERE id = $1`, linkID, verifiedAt); err != nil {
+		return fmt.Errorf("failed to update user link: %w", err)
+	}
+	return nil
+}
+
+// SetVerified grants or revokes the verified badge
+func (r *Repository) SetVerified(ctx context.Context, id int, verified bool) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `UPDATE users SET verified = $2, updated_at = $3 WHERE id = $1 AND active = true`, id, verified, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update verified flag: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+// SetAdmin grants or revokes the admin role of the user with the given username. It takes
+// effect on the user's next request.
+func (r *Repository) SetAdmin(ctx context.Context, username string, admin bool) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `UPDATE users SET is_admin = $2, updated_at = $3 WHERE lower(username) = lower($1)`, username, admin, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update admin role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+// SetBio replaces the user's bio
+func (r *Repository) SetBio(ctx context.Context, id int, bio *string) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `UPDATE users SET bio = $2, updated_at = $3 WHERE id = $1 AND active = true`, id, bio, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update bio: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+// ListReservedUsernames returns all stored reserved username patterns
+func (r *Repository) ListReservedUsernames(ctx context.Context) ([]ReservedUsername, error) {
+	query := `
+        SELECT id, pattern, reason, created_by, created_at
+        FROM reserved_usernames
+        ORDER BY pattern
+    `
+
+	rows, err := r.db.Querier(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reserved usernames: %w", err)
+	}
+	defer rows.Close()
+
+	reserved := []ReservedUsername{}
+	for rows.Next() {
+		var entry ReservedUsername
+		if err := rows.Scan(&entry.ID, &entry.Pattern, &entry.Reason, &entry.CreatedBy, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reserved username: %w", err)
+		}
+		reserved = append(reserved, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reserved usernames: %w", err)
+	}
+
+	return reserved, nil
+}
+
+// CreateReservedUsername stores a reserved username pattern
+func (r *Repository) CreateReservedUsername(ctx context.Context, pattern string, reason *string, createdBy int) (*ReservedUsername, error) {
+	query := `
+        INSERT INTO reserved_usernames (pattern, reason, created_by, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, pattern, reason, created_by, created_at
+    `
+
+	var entry ReservedUsername
+	err := r.db.Querier(ctx).QueryRow(ctx, query, pattern, reason, createdBy, time.Now()).
+		Scan(&entry.ID, &entry.Pattern, &entry.Reason, &entry.CreatedBy, &entry.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, errReservedExists
+		}
+		return nil, fmt.Errorf("failed to create reserved username: %w", err)
+	}
+	return &entry, nil
+}
+
+// DeleteReservedUsername removes a stored reserved username pattern
+func (r *Repository) DeleteReservedUsername(ctx context.Context, id int) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx, `DELETE FROM reserved_usernames WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reserved username: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errReservedNotFound
+	}
+	return nil
+}
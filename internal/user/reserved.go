@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"learning/internal/logging"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/unicode/norm"
+)
+
+// reservedRefreshInterval is how often stored reserved patterns are reloaded, so changes
+// made through another instance's admin API are picked up
+const reservedRefreshInterval = time.Minute
+
+// DefaultReservedUsernames are always reserved: administrative names, and words that
+// collide with current or likely future routes. Patterns use path.Match syntax.
+var DefaultReservedUsernames = []string{
+	"admin*", "administrator*", "root", "sysadmin", "system", "staff", "mod", "moderator*",
+	"support*", "help*", "official*", "security", "abuse", "postmaster", "webmaster", "noreply",
+	"api", "www", "mail", "status", "about", "settings", "account*", "login", "logout", "signin",
+	"signup", "register", "me", "user", "users", "tags", "media", "images", "notifications",
+	"conversations", "messaging", "stream", "ws", "health", "metrics", "static", "assets",
+	"explore", "search", "home", "null", "undefined",
+}
+
+// ReservedNames holds the reserved username patterns: built-in and configured ones plus
+// those managed through the admin API
+type ReservedNames struct {
+	repository RepositoryInterface
+
+	mu       sync.RWMutex
+	static   []string // defaults and configured patterns, which lead patterns
+	patterns []string
+}
+
+// NewReservedNames creates a reserved list from the defaults and extra configured patterns.
+// Stored patterns are added by Refresh.
+func NewReservedNames(repository RepositoryInterface, extra []string) *ReservedNames {
+	static := make([]string, 0, len(DefaultReservedUsernames)+len(extra))
+	for _, raw := range append(append([]string{}, DefaultReservedUsernames...), extra...) {
+		pattern, err := normalizePattern(raw)
+		if err != nil {
+			slog.Warn("ignoring reserved username pattern", "pattern", raw, "error", err)
+			continue
+		}
+		static = append(static, pattern)
+	}
+
+	return &ReservedNames{
+		repository: repository,
+		static:     static,
+		patterns:   static,
+	}
+}
+
+// PrepareConfigured validates a replacement set of configured patterns and returns a
+// function that puts them in force. Stored patterns are kept until the next Refresh.
+func (n *ReservedNames) PrepareConfigured(extra []string) (apply func(), err error) {
+	static := make([]string, 0, len(DefaultReservedUsernames)+len(extra))
+	static = append(static, DefaultReservedUsernames...)
+	for _, raw := range extra {
+		pattern, err := normalizePattern(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reserved username pattern %q: %w", raw, err)
+		}
+		static = append(static, pattern)
+	}
+
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		stored := n.patterns[len(n.static):]
+		n.static = static
+		n.patterns = append(append([]string{}, static...), stored...)
+	}, nil
+}
+
+// IsReserved reports whether username matches any reserved pattern, ignoring case
+func (n *ReservedNames) IsReserved(username string) bool {
+	key := strings.ToLower(norm.NFKC.String(strings.TrimSpace(username)))
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, pattern := range n.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Refresh reloads stored patterns
+func (n *ReservedNames) Refresh(ctx context.Context) error {
+	stored, err := n.repository.ListReservedUsernames(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to refresh reserved usernames: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	patterns := make([]string, 0, len(n.static)+len(stored))
+	patterns = append(patterns, n.static...)
+	for _, reserved := range stored {
+		patterns = append(patterns, reserved.Pattern)
+	}
+	n.patterns = patterns
+	return nil
+}
+
+// Run refreshes stored patterns on every interval until ctx is cancelled
+func (n *ReservedNames) Run(ctx context.Context) {
+	ticker := time.NewTicker(reservedRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("reserved usernames refresh failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RegisterUsernameValidation registers the username_allowed tag on v, rejecting
+// usernames that match a reserved pattern. Any request struct validated by v can use it.
+func RegisterUsernameValidation(v *validator.Validate, names *ReservedNames) error {
+	return v.RegisterValidation("username_allowed", func(fl validator.FieldLevel) bool {
+		return !names.IsReserved(fl.Field().String())
+	})
+}
+
+// normalizePattern lowercases a pattern and checks its syntax
+func normalizePattern(pattern string) (string, error) {
+	pattern = strings.ToLower(norm.NFKC.String(strings.TrimSpace(pattern)))
+	if pattern == "" {
+		return "", fmt.Errorf("empty pattern")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
@@ -1,7 +1,10 @@
 package user
 
 import (
+	"learning/internal/contentfilter"
 	"learning/internal/database"
+	"learning/internal/ratelimit"
+	"learning/internal/signup"
 
 	"github.com/gorilla/mux"
 )
@@ -11,10 +14,20 @@ func RegisterRoutes(r *mux.Router, h *Handler) {
 	h.RegisterRoutes(r)
 }
 
-// Register composes repository -> service -> handler and registers routes
-func Register(r *mux.Router, db *database.DataBase) {
+// Register composes repository -> service -> handler and registers routes.
+// publicURL is the externally visible base URL used when verifying profile links and
+// reservedUsernames are configured patterns added to DefaultReservedUsernames.
+// Bios are screened by filters, which publishes held bios through the service once approved,
+// registrations are scored by signups, followers-only fields are gated by relationships
+// and unauthenticated routes are rate limited by limiter.
+// Verification links are only logged until outgoing email exists. The returned list must be
+// run to load patterns managed through the admin API.
+func Register(r *mux.Router, db *database.DataBase, filters *contentfilter.Service, signups *signup.Service, relationships Relationships, limiter *ratelimit.Limiter, publicURL string, reservedUsernames []string) *ReservedNames {
 	repo := NewRepository(db)
-	svc := NewService(repo)
-	h := NewHandler(svc)
+	reserved := NewReservedNames(repo, reservedUsernames)
+	svc := NewService(repo, relationships, NewRelMeVerifier(), filters, signups, LogVerificationSender{}, reserved, publicURL)
+	filters.OnRelease(contentfilter.ScopeBio, svc.ReleaseBio)
+	h := NewHandler(svc, limiter)
 	h.RegisterRoutes(r)
+	return reserved
 }
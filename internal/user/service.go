@@ -2,32 +2,115 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"learning/internal/contentfilter"
+	"learning/internal/database"
+	apperrors "learning/internal/errors"
+	"learning/internal/logging"
+	"learning/internal/metrics"
+	"learning/internal/signup"
+	"learning/internal/tracing"
+	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/unicode/norm"
 )
 
+var tracer = otel.Tracer("learning/internal/user")
+
 // ServiceInterface defines business operations for users
 type ServiceInterface interface {
-	CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error)
+	CreateUser(ctx context.Context, req *CreateUserRequest, clientIP netip.Addr) (*User, error)
+	VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*User, error)
 	GetUserById(ctx context.Context, id int) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, *UsernameRedirect, error)
+	ChangeUsername(ctx context.Context, userID int, req *ChangeUsernameRequest) (*User, error)
+	ResolveAudience(ctx context.Context, viewerID, userID int) (Audience, error)
+	UpdateProfile(ctx context.Context, userID int, req *UpdateProfileRequest) (*User, error)
+	VerifyLinks(ctx context.Context, userID int) (*User, error)
+	SetVerified(ctx context.Context, userID int, verified bool) error
+	ListReservedUsernames(ctx context.Context) ([]ReservedUsername, error)
+	AddReservedUsername(ctx context.Context, adminID int, req *CreateReservedUsernameRequest) (*ReservedUsername, error)
+	RemoveReservedUsername(ctx context.Context, id int) error
 }
 
 // Ensure Service implements ServiceInterface
 var _ ServiceInterface = (*Service)(nil)
 
+// Relationships answers social-graph questions that gate profile field visibility
+type Relationships interface {
+	IsFollower(ctx context.Context, followerID, followeeID int) (bool, error)
+}
+
+// ContentFilter screens user-written text such as bios against moderation rules
+type ContentFilter interface {
+	Check(scope, text string) contentfilter.Verdict
+	Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict contentfilter.Verdict) error
+	Withdraw(ctx context.Context, scope string, subjectID int64) error
+}
+
+// noFilter allows all text; it is used when no content filter is configured
+type noFilter struct{}
+
+func (noFilter) Check(scope, text string) contentfilter.Verdict {
+	return contentfilter.Verdict{Action: contentfilter.ActionAllow, Text: text}
+}
+
+func (noFilter) Hold(ctx context.Context, scope string, authorID int, subjectID int64, text string, verdict contentfilter.Verdict) error {
+	return nil
+}
+
+func (noFilter) Withdraw(ctx context.Context, scope string, subjectID int64) error {
+	return nil
+}
+
 type Service struct {
-	repository RepositoryInterface
-	validator  *validator.Validate
+	repository    RepositoryInterface
+	validator     *validator.Validate
+	relationships Relationships
+	verifier      LinkVerifier
+	filter        ContentFilter
+	screener      SignupScreener
+	sender        VerificationSender
+	reserved      *ReservedNames
+	publicURL     string
 }
 
-// NewService creates a new user service
-func NewService(repository RepositoryInterface) *Service {
+// NewService creates a new user service. publicURL is the externally visible base URL
+// that rel="me" links must point back to.
+func NewService(repository RepositoryInterface, relationships Relationships, verifier LinkVerifier, filter ContentFilter, screener SignupScreener, sender VerificationSender, reserved *ReservedNames, publicURL string) *Service {
+	if filter == nil {
+		filter = noFilter{}
+	}
+	if screener == nil {
+		screener = allowAllSignups{}
+	}
+	if sender == nil {
+		sender = LogVerificationSender{}
+	}
+
+	v := validator.New()
+	// Registration only fails for an empty tag or a nil function
+	_ = RegisterUsernameValidation(v, reserved)
+
 	return &Service{
-		repository: repository,
-		validator:  validator.New(),
+		repository:    repository,
+		validator:     v,
+		relationships: relationships,
+		verifier:      verifier,
+		filter:        filter,
+		screener:      screener,
+		sender:        sender,
+		reserved:      reserved,
+		publicURL:     strings.TrimSuffix(publicURL, "/"),
 	}
 }
 
@@ -50,34 +133,488 @@ func (s *Service) validateCreateUserRequests(req *CreateUserRequest) error {
 	return nil
 }
 
-// CreateUser creates a new user
-func (s *Service) CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error) {
+// CreateUser creates a new user. Registrations are scored by the signup screener first: risky
+// ones are rejected, and suspicious ones create an inactive account until the email address is verified.
+func (s *Service) CreateUser(ctx context.Context, req *CreateUserRequest, clientIP netip.Addr) (_ *User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.CreateUser")
+	defer tracing.End(span, &err)
+
 	if err := s.validateCreateUserRequests(req); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
 
+	username, err := NormalizeUsername(req.Username)
+	if err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, err.Error())
+	}
+	email, canonicalEmail, err := NormalizeEmail(req.Email)
+	if err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, err.Error())
+	}
+	req.Username, req.Email = username, email
+
+	assessment, err := s.screener.Assess(ctx, signup.Attempt{IP: clientIP, Username: username, Email: email, Honeypot: req.Website})
+	if err != nil {
+		return nil, fmt.Errorf("error while screening registration %w", err)
+	}
+	if assessment.Decision == signup.DecisionReject {
+		// Reasons are logged for tuning but not disclosed to the client
+		return nil, apperrors.NewAppError(http.StatusForbidden, "registration was rejected", nil)
+	}
+
+	var verification *EmailVerification
+	var token string
+	if assessment.Decision == signup.DecisionVerify {
+		var tokenHash []byte
+		if token, tokenHash, err = newVerificationToken(); err != nil {
+			return nil, err
+		}
+		verification = &EmailVerification{TokenHash: tokenHash, ExpiresAt: time.Now().Add(EmailVerificationTTL)}
+	}
+
+	// A held bio is left out of the account until a moderator approves it
+	submittedBio := req.Bio
+	var verdict contentfilter.Verdict
+	if req.Bio, verdict, err = s.screenBio(req.Bio); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := s.hashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("error while hashing password %w", err)
 	}
 
-	user, err := s.repository.CreateUser(ctx, req, hashedPassword)
+	user, err := s.repository.CreateUser(ctx, req, canonicalEmail, hashedPassword, verification)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user %w", err)
+		return nil, mapError(err, "failed to create user")
+	}
+	metrics.UsersCreated.Inc()
+
+	if verification != nil {
+		link := s.publicURL + "/verify-email?token=" + token
+		if err := s.sender.SendVerification(ctx, user, link); err != nil {
+			logging.FromContext(ctx).Error("failed to send email verification", "user_id", user.ID, "error", err)
+		}
+	}
+
+	if verdict.Action == contentfilter.ActionHold {
+		// The account exists at this point, so a failed hold only loses the bio
+		if err := s.filter.Hold(ctx, contentfilter.ScopeBio, user.ID, int64(user.ID), *submittedBio, verdict); err != nil {
+			logging.FromContext(ctx).Error("failed to hold bio", "user_id", user.ID, "error", err)
+		} else {
+			user.BioPending = true
+		}
 	}
 
 	return user, nil
 }
 
+// VerifyEmail activates the account a verification token was issued for
+func (s *Service) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (_ *User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.VerifyEmail")
+	defer tracing.End(span, &err)
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	userID, err := s.repository.VerifyEmail(ctx, hashVerificationToken(strings.ToLower(req.Token)), time.Now())
+	if err != nil {
+		return nil, mapError(err, "error while verifying email")
+	}
+	metrics.EmailsVerified.Inc()
+
+	user, err := s.repository.GetUserById(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return nil, mapError(err, "error while getting user by id")
+	}
+	return user, nil
+}
+
 // GetUserById retrieves a user by ID
-func (s *Service) GetUserById(ctx context.Context, id int) (*User, error) {
+func (s *Service) GetUserById(ctx context.Context, id int) (_ *User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.GetUserById")
+	defer tracing.End(span, &err)
+
 	if id < 0 {
 		return nil, fmt.Errorf("invalid id %d", id)
 	}
 
 	user, err := s.repository.GetUserById(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("error while getting user by id %w", err)
+		return nil, mapError(err, "error while getting user by id")
 	}
 	return user, nil
 }
+
+// GetUserByUsername retrieves a user by current username, ignoring case. If username belonged to
+// someone before a rename, a redirect to their current username is returned instead.
+func (s *Service) GetUserByUsername(ctx context.Context, username string) (_ *User, _ *UsernameRedirect, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.GetUserByUsername")
+	defer tracing.End(span, &err)
+
+	username = norm.NFKC.String(username)
+
+	user, err := s.repository.GetUserByUsername(ctx, username)
+	if err == nil {
+		return user, nil, nil
+	}
+	if !errors.Is(err, errNotFound) {
+		return nil, nil, fmt.Errorf("error while getting user by username %w", err)
+	}
+
+	redirect, err := s.repository.FindUsernameRedirect(ctx, username)
+	if err != nil {
+		return nil, nil, mapError(err, "error while resolving previous username")
+	}
+	return nil, redirect, nil
+}
+
+// ChangeUsername renames the user, subject to the change cooldown. The old username
+// stays reserved for UsernameReservation and redirects to the new one.
+func (s *Service) ChangeUsername(ctx context.Context, userID int, req *ChangeUsernameRequest) (_ *User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.ChangeUsername")
+	defer tracing.End(span, &err)
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	username, err := NormalizeUsername(req.Username)
+	if err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, err.Error())
+	}
+
+	user, err := s.repository.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, mapError(err, "error while getting user by id")
+	}
+	if user.Username == username {
+		return user, nil
+	}
+
+	if err := s.repository.ChangeUsername(ctx, userID, username, UsernameChangeCooldown, UsernameReservation); err != nil {
+		return nil, mapError(err, "error while changing username")
+	}
+
+	user, err = s.repository.GetUserById(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return nil, mapError(err, "error while getting user by id")
+	}
+	return user, nil
+}
+
+// ResolveAudience determines how viewerID relates to userID; a zero viewerID is an anonymous viewer
+func (s *Service) ResolveAudience(ctx context.Context, viewerID, userID int) (_ Audience, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.ResolveAudience")
+	defer tracing.End(span, &err)
+
+	if viewerID <= 0 {
+		return AudiencePublic, nil
+	}
+	if viewerID == userID {
+		return AudienceSelf, nil
+	}
+
+	follows, err := s.relationships.IsFollower(ctx, viewerID, userID)
+	if err != nil {
+		return AudiencePublic, fmt.Errorf("error while checking follower %w", err)
+	}
+	if follows {
+		return AudienceFollower, nil
+	}
+	return AudiencePublic, nil
+}
+
+// UpdateProfile validates and applies a partial profile update
+func (s *Service) UpdateProfile(ctx context.Context, userID int, req *UpdateProfileRequest) (_ *User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.UpdateProfile")
+	defer tracing.End(span, &err)
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	user, err := s.repository.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, mapError(err, "error while getting user by id")
+	}
+
+	var submittedBio, bio *string
+	var verdict contentfilter.Verdict
+	if req.Bio != nil {
+		submittedBio = optionalText(*req.Bio)
+		if bio, verdict, err = s.screenBio(submittedBio); err != nil {
+			return nil, err
+		}
+		if verdict.Action != contentfilter.ActionHold {
+			user.Bio = bio
+		}
+	}
+	if req.Location != nil {
+		user.Location = optionalText(*req.Location)
+	}
+	if req.LocationVisibility != nil {
+		user.LocationVisibility = *req.LocationVisibility
+	}
+	if req.Pronouns != nil {
+		user.Pronouns = optionalText(*req.Pronouns)
+	}
+	if req.PronounsVisibility != nil {
+		user.PronounsVisibility = *req.PronounsVisibility
+	}
+	if req.BirthdayVisibility != nil {
+		user.BirthdayVisibility = *req.BirthdayVisibility
+	}
+	if req.Birthday != nil {
+		if user.Birthday, err = parseBirthday(*req.Birthday); err != nil {
+			return nil, err
+		}
+	}
+	if req.Links != nil {
+		if user.Links, err = buildLinks(*req.Links); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repository.UpdateProfile(ctx, user, req.Links != nil); err != nil {
+		return nil, mapError(err, "error while updating profile")
+	}
+
+	if req.Bio != nil {
+		// Holding replaces any earlier pending bio; an unheld bio withdraws it
+		if verdict.Action == contentfilter.ActionHold {
+			err = s.filter.Hold(ctx, contentfilter.ScopeBio, user.ID, int64(user.ID), *submittedBio, verdict)
+			user.BioPending = err == nil
+		} else {
+			err = s.filter.Withdraw(ctx, contentfilter.ScopeBio, int64(user.ID))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while screening bio %w", err)
+		}
+	}
+	return user, nil
+}
+
+// VerifyLinks checks every profile link for a rel="me" link back to the profile and
+// records the result. Links that no longer link back lose their verification.
+func (s *Service) VerifyLinks(ctx context.Context, userID int) (_ *User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.VerifyLinks")
+	defer tracing.End(span, &err)
+
+	user, err := s.repository.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, mapError(err, "error while getting user by id")
+	}
+
+	profileURLs := s.profileURLs(user)
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range user.Links {
+		wg.Add(1)
+		go func(link *Link) {
+			defer wg.Done()
+			ok, err := s.verifier.Verify(ctx, link.URL, profileURLs)
+			if err != nil {
+				logging.FromContext(ctx).Warn("rel=me verification failed", "url", link.URL, "error", err)
+			}
+			link.VerifiedAt = nil
+			if ok {
+				link.VerifiedAt = &now
+			}
+		}(&user.Links[i])
+	}
+	wg.Wait()
+
+	for _, link := range user.Links {
+		if err := s.repository.SetLinkVerified(ctx, link.ID, link.VerifiedAt); err != nil {
+			return nil, fmt.Errorf("error while saving link verification %w", err)
+		}
+	}
+	return user, nil
+}
+
+// SetVerified grants or revokes the verified badge; callers must be administrators
+func (s *Service) SetVerified(ctx context.Context, userID int, verified bool) (err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.SetVerified")
+	defer tracing.End(span, &err)
+
+	if err := s.repository.SetVerified(ctx, userID, verified); err != nil {
+		return mapError(err, "error while setting verified flag")
+	}
+	return nil
+}
+
+// ListReservedUsernames returns the patterns managed through the admin API
+func (s *Service) ListReservedUsernames(ctx context.Context) (_ []ReservedUsername, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.ListReservedUsernames")
+	defer tracing.End(span, &err)
+
+	reserved, err := s.repository.ListReservedUsernames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing reserved usernames %w", err)
+	}
+	return reserved, nil
+}
+
+// AddReservedUsername reserves a username pattern; it applies to registrations and renames immediately
+func (s *Service) AddReservedUsername(ctx context.Context, adminID int, req *CreateReservedUsernameRequest) (_ *ReservedUsername, err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.AddReservedUsername")
+	defer tracing.End(span, &err)
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	pattern, err := normalizePattern(req.Pattern)
+	if err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, "invalid pattern")
+	}
+
+	reserved, err := s.repository.CreateReservedUsername(ctx, pattern, optionalText(stringValue(req.Reason)), adminID)
+	if err != nil {
+		return nil, mapError(err, "error while reserving username")
+	}
+
+	s.refreshReserved(ctx)
+	return reserved, nil
+}
+
+// RemoveReservedUsername deletes a stored reserved pattern
+func (s *Service) RemoveReservedUsername(ctx context.Context, id int) (err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.RemoveReservedUsername")
+	defer tracing.End(span, &err)
+
+	if err := s.repository.DeleteReservedUsername(ctx, id); err != nil {
+		return mapError(err, "error while removing reserved username")
+	}
+
+	s.refreshReserved(ctx)
+	return nil
+}
+
+// refreshReserved reloads reserved patterns after a change; on failure the periodic refresh catches up
+func (s *Service) refreshReserved(ctx context.Context) {
+	if err := s.reserved.Refresh(ctx); err != nil {
+		logging.FromContext(ctx).Error("reserved usernames refresh failed", "error", err)
+	}
+}
+
+// profileURLs lists the public URLs of a profile that a rel="me" link may point to
+func (s *Service) profileURLs(user *User) []string {
+	return []string{
+		s.publicURL + "/@" + user.Username,
+		s.publicURL + "/api/v1/users/" + strconv.Itoa(user.ID),
+	}
+}
+
+// stringValue dereferences an optional string
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// screenBio checks a bio against the content filter and returns the text to store now.
+// Rejected bios fail with 422; held bios return nil with a hold verdict.
+func (s *Service) screenBio(bio *string) (*string, contentfilter.Verdict, error) {
+	if bio == nil {
+		return nil, contentfilter.Verdict{Action: contentfilter.ActionAllow}, nil
+	}
+
+	verdict := s.filter.Check(contentfilter.ScopeBio, *bio)
+	switch verdict.Action {
+	case contentfilter.ActionReject:
+		return nil, verdict, apperrors.NewAppError(http.StatusUnprocessableEntity, "bio contains disallowed content", nil)
+	case contentfilter.ActionHold:
+		return nil, verdict, nil
+	case contentfilter.ActionMask:
+		return &verdict.Text, verdict, nil
+	}
+	return bio, verdict, nil
+}
+
+// ReleaseBio publishes a held bio once a moderator approves it
+func (s *Service) ReleaseBio(ctx context.Context, hold contentfilter.Hold) (err error) {
+	ctx, span := tracer.Start(ctx, "user.Service.ReleaseBio")
+	defer tracing.End(span, &err)
+
+	if err := s.repository.SetBio(ctx, int(hold.SubjectID), &hold.Content); err != nil {
+		return mapError(err, "error while releasing bio")
+	}
+	return nil
+}
+
+// optionalText trims free text and maps an empty value to nil
+func optionalText(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// parseBirthday parses a YYYY-MM-DD birthday; an empty value clears it
+func parseBirthday(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	birthday, err := time.Parse(birthdayLayout, value)
+	if err != nil {
+		return nil, apperrors.WrapWithMessage(err, http.StatusBadRequest, "birthday must be formatted as YYYY-MM-DD")
+	}
+	if birthday.Year() < 1900 || birthday.After(time.Now()) {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("birthday %s out of range", value), http.StatusBadRequest, "birthday is out of range")
+	}
+	return &birthday, nil
+}
+
+// buildLinks validates requested links and converts them to their stored form
+func buildLinks(requests []LinkRequest) ([]Link, error) {
+	if len(requests) > MaxProfileLinks {
+		return nil, apperrors.WrapWithMessage(fmt.Errorf("%d links requested", len(requests)), http.StatusBadRequest,
+			fmt.Sprintf("a profile may have at most %d links", MaxProfileLinks))
+	}
+
+	links := make([]Link, 0, len(requests))
+	seen := make(map[string]bool, len(requests))
+	for _, req := range requests {
+		url := strings.TrimSpace(req.URL)
+		if seen[canonicalURL(url)] {
+			return nil, apperrors.WrapWithMessage(fmt.Errorf("duplicate link %s", url), http.StatusBadRequest, "duplicate link")
+		}
+		seen[canonicalURL(url)] = true
+
+		link := Link{URL: url}
+		if req.Label != nil {
+			link.Label = optionalText(*req.Label)
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// mapError converts repository sentinel errors into client errors and wraps anything else
+func mapError(err error, message string) error {
+	switch {
+	case errors.Is(err, errNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "user not found")
+	case errors.Is(err, errUsernameTaken):
+		return apperrors.WrapWithMessage(err, http.StatusConflict, "username is taken")
+	case errors.Is(err, errEmailTaken):
+		return apperrors.WrapWithMessage(err, http.StatusConflict, "email is already registered")
+	case errors.Is(err, errUsernameCooldown):
+		return apperrors.WrapWithMessage(err, http.StatusTooManyRequests, "username was changed too recently")
+	case errors.Is(err, errReservedNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusNotFound, "reserved username not found")
+	case errors.Is(err, errReservedExists):
+		return apperrors.WrapWithMessage(err, http.StatusConflict, "reserved username already exists")
+	case errors.Is(err, errVerificationNotFound):
+		return apperrors.WrapWithMessage(err, http.StatusBadRequest, "verification link is invalid or has expired")
+	}
+	return fmt.Errorf("%s %w", message, err)
+}
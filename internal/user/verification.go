@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"learning/internal/logging"
+	"learning/internal/signup"
+)
+
+// SignupScreener scores registration attempts for spam and abuse
+type SignupScreener interface {
+	Assess(ctx context.Context, attempt signup.Attempt) (*signup.Assessment, error)
+}
+
+// allowAllSignups is used when no screener is configured
+type allowAllSignups struct{}
+
+func (allowAllSignups) Assess(ctx context.Context, attempt signup.Attempt) (*signup.Assessment, error) {
+	return &signup.Assessment{Decision: signup.DecisionAllow, Findings: []signup.Finding{}}, nil
+}
+
+// VerificationSender delivers email verification links
+type VerificationSender interface {
+	SendVerification(ctx context.Context, user *User, link string) error
+}
+
+// LogVerificationSender writes verification links to the log. It stands in until outgoing
+// email is configured and should not be relied on in production.
+type LogVerificationSender struct{}
+
+func (LogVerificationSender) SendVerification(ctx context.Context, user *User, link string) error {
+	logging.FromContext(ctx).Info("email verification link", "user_id", user.ID, "email", user.Email, "link", link)
+	return nil
+}
+
+// newVerificationToken returns a random token for the verification link and the hash stored for it
+func newVerificationToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	return token, hashVerificationToken(token), nil
+}
+
+// hashVerificationToken hashes a token so a leaked table cannot be used to verify accounts
+func hashVerificationToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
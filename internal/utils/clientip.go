@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// ClientIP returns the address of the peer that sent the request, or the zero Addr if
+// it cannot be parsed. Forwarding headers are ignored because they are client-controlled.
+func ClientIP(r *http.Request) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addrPort.Addr().Unmap()
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Pagination defaults applied when the query string omits them
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ParsePagination reads limit and offset query parameters from the request
+func ParsePagination(r *http.Request) (limit, offset int, err error) {
+	limit = DefaultPageLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit")
+		}
+		if limit > MaxPageLimit {
+			limit = MaxPageLimit
+		}
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset")
+		}
+	}
+
+	return limit, offset, nil
+}
@@ -5,12 +5,17 @@ import (
 	"net/http"
 )
 
+// TraceIDHeader carries the trace ID of the request in responses. Tracing middleware sets
+// it before handlers run so error responses can include it.
+const TraceIDHeader = "X-Trace-ID"
+
 // Response represents a standard API response
 type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	TraceID string      `json:"trace_id,omitempty"`
 }
 
 // WriteSuccess writes a successful JSON response
@@ -23,13 +28,14 @@ func WriteSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
 	})
 }
 
-// WriteError writes an error JSON response
+// WriteError writes an error JSON response, including the trace ID when the request is traced
 func WriteError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	_ = json.NewEncoder(w).Encode(Response{
 		Success: false,
 		Error:   message,
+		TraceID: w.Header().Get(TraceIDHeader),
 	})
 }
 
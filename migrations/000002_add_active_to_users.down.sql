@@ -1 +1 @@
-ALTER TABLE users DROP COLUMN active;
\ No newline at end of file
+ALTER TABLE users DROP COLUMN active;
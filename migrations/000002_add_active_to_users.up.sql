@@ -1 +1 @@
-ALTER TABLE users  ADD COLUMN active BOOLEAN DEFAULT true NOT NULL;
\ No newline at end of file
+ALTER TABLE users ADD COLUMN active BOOLEAN DEFAULT true NOT NULL;
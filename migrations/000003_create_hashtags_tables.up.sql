@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS hashtags (
+id SERIAL PRIMARY KEY,
+tag VARCHAR(100) UNIQUE NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_hashtags (
+post_id BIGINT NOT NULL,
+hashtag_id INTEGER NOT NULL REFERENCES hashtags(id) ON DELETE CASCADE,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (post_id, hashtag_id)
+);
+
+CREATE INDEX idx_post_hashtags_hashtag_created ON post_hashtags(hashtag_id, created_at DESC);
+CREATE INDEX idx_post_hashtags_created ON post_hashtags(created_at);
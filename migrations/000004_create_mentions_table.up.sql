@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS mentions (
+id SERIAL PRIMARY KEY,
+source_type VARCHAR(20) NOT NULL,
+source_id BIGINT NOT NULL,
+author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+start_offset INTEGER NOT NULL,
+end_offset INTEGER NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_mentions_source ON mentions(source_type, source_id);
+CREATE INDEX idx_mentions_user ON mentions(user_id, created_at DESC);
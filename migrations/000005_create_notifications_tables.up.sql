@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS notifications (
+id BIGSERIAL PRIMARY KEY,
+recipient_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+type VARCHAR(30) NOT NULL,
+subject_type VARCHAR(20),
+subject_id BIGINT,
+group_key VARCHAR(100) NOT NULL,
+actor_count INTEGER NOT NULL DEFAULT 0,
+read_at TIMESTAMP,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notification_actors (
+notification_id BIGINT NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+actor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (notification_id, actor_id)
+);
+
+-- Only one unread notification per group, so new events fold into it
+CREATE UNIQUE INDEX idx_notifications_unread_group ON notifications(recipient_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX idx_notifications_recipient_updated ON notifications(recipient_id, updated_at DESC);
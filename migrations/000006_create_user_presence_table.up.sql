@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS user_presence (
+user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+last_seen_at TIMESTAMP NOT NULL,
+online_until TIMESTAMP NOT NULL
+);
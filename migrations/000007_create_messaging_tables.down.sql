@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS messaging_settings;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS conversation_members;
+DROP TABLE IF EXISTS conversations;
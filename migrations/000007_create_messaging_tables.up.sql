@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS conversations (
+id BIGSERIAL PRIMARY KEY,
+kind VARCHAR(10) NOT NULL,
+title VARCHAR(100),
+created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+direct_key VARCHAR(30) UNIQUE,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS conversation_members (
+conversation_id BIGINT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+role VARCHAR(10) NOT NULL DEFAULT 'member',
+last_read_message_id BIGINT NOT NULL DEFAULT 0,
+joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+id BIGSERIAL PRIMARY KEY,
+conversation_id BIGINT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+body TEXT NOT NULL,
+edited_at TIMESTAMP,
+deleted_at TIMESTAMP,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS messaging_settings (
+user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+allow_from VARCHAR(20) NOT NULL DEFAULT 'everyone',
+updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_conversation_members_user ON conversation_members(user_id);
+CREATE INDEX idx_messages_conversation_id ON messages(conversation_id, id DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS post_media;
+DROP TABLE IF EXISTS media_uploads;
+DROP TABLE IF EXISTS media;
+DROP TABLE IF EXISTS media_blobs;
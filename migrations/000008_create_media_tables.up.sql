@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS media_blobs (
+sha256 CHAR(64) PRIMARY KEY,
+size BIGINT NOT NULL,
+content_type VARCHAR(100) NOT NULL,
+storage_key VARCHAR(255) NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS media (
+id BIGSERIAL PRIMARY KEY,
+owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+sha256 CHAR(64) NOT NULL REFERENCES media_blobs(sha256),
+filename VARCHAR(255),
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS media_uploads (
+id CHAR(32) PRIMARY KEY,
+owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+filename VARCHAR(255),
+total_size BIGINT NOT NULL,
+received BIGINT NOT NULL DEFAULT 0,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS post_media (
+post_id BIGINT NOT NULL,
+media_id BIGINT NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+position SMALLINT NOT NULL,
+PRIMARY KEY (post_id, media_id)
+);
+
+CREATE INDEX idx_media_owner ON media(owner_id, created_at DESC);
+CREATE INDEX idx_media_uploads_expires ON media_uploads(expires_at);
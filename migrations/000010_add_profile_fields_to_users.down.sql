@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS user_links;
+
+ALTER TABLE users DROP COLUMN verified;
+ALTER TABLE users DROP COLUMN pronouns_visibility;
+ALTER TABLE users DROP COLUMN pronouns;
+ALTER TABLE users DROP COLUMN birthday_visibility;
+ALTER TABLE users DROP COLUMN birthday;
+ALTER TABLE users DROP COLUMN location_visibility;
+ALTER TABLE users DROP COLUMN location;
@@ -0,0 +1,24 @@
+ALTER TABLE users ADD COLUMN location VARCHAR(100);
+ALTER TABLE users ADD COLUMN location_visibility VARCHAR(20) DEFAULT 'public' NOT NULL;
+ALTER TABLE users ADD COLUMN birthday DATE;
+ALTER TABLE users ADD COLUMN birthday_visibility VARCHAR(20) DEFAULT 'only_me' NOT NULL;
+ALTER TABLE users ADD COLUMN pronouns VARCHAR(40);
+ALTER TABLE users ADD COLUMN pronouns_visibility VARCHAR(20) DEFAULT 'public' NOT NULL;
+ALTER TABLE users ADD COLUMN verified BOOLEAN DEFAULT false NOT NULL;
+
+ALTER TABLE users ADD CONSTRAINT chk_users_location_visibility CHECK (location_visibility IN ('public', 'followers', 'only_me'));
+ALTER TABLE users ADD CONSTRAINT chk_users_birthday_visibility CHECK (birthday_visibility IN ('public', 'followers', 'only_me'));
+ALTER TABLE users ADD CONSTRAINT chk_users_pronouns_visibility CHECK (pronouns_visibility IN ('public', 'followers', 'only_me'));
+
+CREATE TABLE IF NOT EXISTS user_links (
+id SERIAL PRIMARY KEY,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+position SMALLINT NOT NULL,
+url VARCHAR(2048) NOT NULL,
+label VARCHAR(50),
+verified_at TIMESTAMP,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+UNIQUE (user_id, url)
+);
+
+CREATE INDEX idx_user_links_user ON user_links(user_id, position);
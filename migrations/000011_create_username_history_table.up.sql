@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN username_changed_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS username_history (
+id SERIAL PRIMARY KEY,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+username VARCHAR(50) NOT NULL,
+changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+reserved_until TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_username_history_username ON username_history(username, changed_at DESC);
+CREATE INDEX idx_username_history_user ON username_history(user_id, changed_at DESC);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_username_history_username;
+CREATE INDEX idx_username_history_username ON username_history(username, changed_at DESC);
+
+DROP INDEX IF EXISTS ux_users_email_canonical;
+DROP INDEX IF EXISTS ux_users_username_lower;
+
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+CREATE INDEX idx_user_username ON users(username);
+
+ALTER TABLE users DROP COLUMN email_canonical;
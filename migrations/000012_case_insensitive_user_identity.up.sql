@@ -0,0 +1,51 @@
+ALTER TABLE users ADD COLUMN email_canonical VARCHAR(100);
+
+UPDATE users SET email_canonical = CASE
+WHEN lower(split_part(email, '@', 2)) IN ('gmail.com', 'googlemail.com')
+THEN replace(split_part(lower(split_part(email, '@', 1)), '+', 1), '.', '') || '@gmail.com'
+WHEN lower(split_part(email, '@', 2)) IN ('me.com', 'mac.com')
+THEN split_part(lower(split_part(email, '@', 1)), '+', 1) || '@icloud.com'
+WHEN lower(split_part(email, '@', 2)) IN ('protonmail.com')
+THEN split_part(lower(split_part(email, '@', 1)), '+', 1) || '@proton.me'
+WHEN lower(split_part(email, '@', 2)) IN ('outlook.com', 'hotmail.com', 'live.com', 'icloud.com', 'fastmail.com', 'proton.me')
+THEN split_part(lower(split_part(email, '@', 1)), '+', 1) || '@' || lower(split_part(email, '@', 2))
+ELSE lower(email)
+END;
+
+DO $$
+DECLARE
+collision RECORD;
+collisions INTEGER := 0;
+BEGIN
+FOR collision IN
+SELECT lower(username) AS identity, string_agg(id::TEXT, ', ' ORDER BY id) AS user_ids
+FROM users GROUP BY lower(username) HAVING count(*) > 1
+LOOP
+RAISE WARNING 'username collision on "%": users %', collision.identity, collision.user_ids;
+collisions := collisions + 1;
+END LOOP;
+
+FOR collision IN
+SELECT email_canonical AS identity, string_agg(id::TEXT, ', ' ORDER BY id) AS user_ids
+FROM users GROUP BY email_canonical HAVING count(*) > 1
+LOOP
+RAISE WARNING 'email collision on "%": users %', collision.identity, collision.user_ids;
+collisions := collisions + 1;
+END LOOP;
+
+IF collisions > 0 THEN
+RAISE EXCEPTION '% username/email collisions found; rename or merge the listed users and rerun this migration', collisions;
+END IF;
+END $$;
+
+ALTER TABLE users ALTER COLUMN email_canonical SET NOT NULL;
+
+ALTER TABLE users DROP CONSTRAINT users_username_key;
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+DROP INDEX IF EXISTS idx_user_username;
+
+CREATE UNIQUE INDEX ux_users_username_lower ON users (lower(username));
+CREATE UNIQUE INDEX ux_users_email_canonical ON users (email_canonical);
+
+DROP INDEX IF EXISTS idx_username_history_username;
+CREATE INDEX idx_username_history_username ON username_history (lower(username), changed_at DESC);
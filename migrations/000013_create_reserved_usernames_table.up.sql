@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS reserved_usernames (
+id SERIAL PRIMARY KEY,
+pattern VARCHAR(100) UNIQUE NOT NULL,
+reason VARCHAR(255),
+created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_moderation_actions_append_only ON moderation_actions;
+DROP FUNCTION IF EXISTS reject_moderation_action_change();
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS reports;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS reports (
+id BIGSERIAL PRIMARY KEY,
+reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('user', 'post', 'comment', 'message')),
+target_id BIGINT NOT NULL,
+target_user_id INTEGER REFERENCES users(id),
+category VARCHAR(30) NOT NULL,
+details TEXT,
+content_snapshot TEXT,
+status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'triaged', 'actioned', 'dismissed')),
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+resolved_at TIMESTAMP
+);
+
+-- A reporter can have only one unresolved report per target
+CREATE UNIQUE INDEX idx_reports_unresolved_target ON reports(reporter_id, target_type, target_id) WHERE status IN ('open', 'triaged');
+CREATE INDEX idx_reports_status_created ON reports(status, created_at);
+CREATE INDEX idx_reports_target ON reports(target_type, target_id);
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+id BIGSERIAL PRIMARY KEY,
+report_id BIGINT REFERENCES reports(id),
+moderator_id INTEGER NOT NULL REFERENCES users(id),
+action VARCHAR(30) NOT NULL,
+target_type VARCHAR(20) NOT NULL,
+target_id BIGINT NOT NULL,
+target_user_id INTEGER REFERENCES users(id),
+note TEXT,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_moderation_actions_report ON moderation_actions(report_id);
+CREATE INDEX idx_moderation_actions_target_user ON moderation_actions(target_user_id, created_at DESC);
+
+-- The moderation log is append-only
+CREATE OR REPLACE FUNCTION reject_moderation_action_change() RETURNS TRIGGER AS $$
+BEGIN
+RAISE EXCEPTION 'moderation_actions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_moderation_actions_append_only
+BEFORE UPDATE OR DELETE ON moderation_actions
+FOR EACH ROW EXECUTE FUNCTION reject_moderation_action_change();
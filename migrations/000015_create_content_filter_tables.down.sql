@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS content_filter_holds;
+DROP TABLE IF EXISTS content_filter_rules;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS content_filter_rules (
+id SERIAL PRIMARY KEY,
+pattern VARCHAR(500) NOT NULL,
+match_type VARCHAR(10) NOT NULL CHECK (match_type IN ('word', 'phrase', 'regex')),
+action VARCHAR(10) NOT NULL CHECK (action IN ('reject', 'hold', 'mask')),
+scopes VARCHAR(20)[] NOT NULL,
+enabled BOOLEAN DEFAULT true NOT NULL,
+note VARCHAR(255),
+created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS content_filter_holds (
+id BIGSERIAL PRIMARY KEY,
+scope VARCHAR(20) NOT NULL,
+subject_id BIGINT NOT NULL,
+author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+content TEXT NOT NULL,
+rule_ids INTEGER[] NOT NULL,
+status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'superseded')),
+reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+reviewed_at TIMESTAMP,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_content_filter_holds_status ON content_filter_holds(status, created_at);
+CREATE INDEX idx_content_filter_holds_subject ON content_filter_holds(scope, subject_id) WHERE status = 'pending';
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS signup_attempts (
+id BIGSERIAL PRIMARY KEY,
+ip INET,
+username VARCHAR(50) NOT NULL,
+email VARCHAR(255) NOT NULL,
+score INTEGER NOT NULL,
+decision VARCHAR(10) NOT NULL CHECK (decision IN ('allow', 'verify', 'reject')),
+reasons TEXT[] NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_signup_attempts_ip ON signup_attempts USING GIST (ip inet_ops);
+CREATE INDEX idx_signup_attempts_created_at ON signup_attempts(created_at);
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+token_hash BYTEA UNIQUE NOT NULL,
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS rate_limits (
+key VARCHAR(255) PRIMARY KEY,
+tat BIGINT NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_tat ON rate_limits(tat);
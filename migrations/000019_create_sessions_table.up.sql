@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sessions (
+id BIGSERIAL PRIMARY KEY,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+token_hash BYTEA UNIQUE NOT NULL,
+user_agent VARCHAR(255),
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_presence_instances (
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+instance_id VARCHAR(32) NOT NULL,
+expires_at TIMESTAMP NOT NULL,
+PRIMARY KEY (user_id, instance_id)
+);
+
+CREATE INDEX idx_user_presence_instances_expires_at ON user_presence_instances(expires_at);
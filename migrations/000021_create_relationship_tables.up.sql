@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS follows (
+follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (follower_id, followee_id),
+CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX idx_follows_followee_id ON follows(followee_id);
+
+CREATE TABLE IF NOT EXISTS blocks (
+blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (blocker_id, blocked_id),
+CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked_id ON blocks(blocked_id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS posts (
+id BIGSERIAL PRIMARY KEY,
+author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+body TEXT NOT NULL,
+edited_at TIMESTAMP,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_posts_author_id ON posts(author_id, id DESC);
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN held BOOLEAN DEFAULT false NOT NULL;